@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"text/tabwriter"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/repository"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+)
+
+// runManifest runs every job of a manifest and prints their reports followed
+// by an overview. Jobs are scoped by name for closed periods. It returns the
+// process exit code: that of the worst failure when jobs failed, otherwise
+// that of the worst job, checked against thresholds one by one.
+func runManifest(ctx context.Context, manifestPath string, parallelism int, closes usecase.PeriodCloseStore, observer domain.Observer, thresholds usecase.Thresholds, out *reportOutput) int {
+	manifest, err := repository.LoadJobManifest(manifestPath)
+	if err != nil {
+		log.Printf("%v", err)
+		return exitInputError
+	}
+	if parallelism <= 0 {
+		parallelism = manifest.Parallelism
+	}
+
+	jobs := make([]usecase.BatchJob, len(manifest.Jobs))
+	for i, config := range manifest.Jobs {
+		job, closer, err := buildJob(config)
+		if err != nil {
+			job = usecase.BatchJob{Name: config.Name, Err: fmt.Errorf("%w: %w", usecase.ErrInvalidRequest, err)}
+		} else {
+			job.Usecase.WithPeriodCloses(closes).WithObserver(observer)
+			job.Request.Scope = config.Name
+			job.Request.Policy.KeepMatchedPairs = out.listsMatchedPairs()
+		}
+		defer closer.Close()
+		jobs[i] = job
+	}
+
+	log.Printf("Starting %d reconciliation jobs...", len(jobs))
+	result := usecase.RunBatch(ctx, jobs, parallelism)
+
+	for _, job := range result.Jobs {
+		if job.Err == nil {
+			job.Response.Summary.ProcessingDurationSeconds = job.Duration.Seconds()
+		}
+	}
+	if err := out.writeBatch(result); err != nil {
+		log.Printf("%v", err)
+		return exitFailure
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		log.Println("Reconciliation interrupted.")
+		return exitInterrupted
+	case result.Overview.Failed > 0:
+		log.Printf("%d of %d reconciliation jobs failed.", result.Overview.Failed, result.Overview.Jobs)
+		code := exitInputError
+		for _, job := range result.Jobs {
+			if job.Err != nil && errorExitCode(job.Err) != exitInputError {
+				code = exitFailure
+			}
+		}
+		return code
+	}
+	log.Println("Reconciliation jobs completed successfully.")
+	code := exitClean
+	for _, job := range result.Jobs {
+		code = max(code, summaryExitCode(job.Name, job.Response.Summary, thresholds))
+	}
+	return code
+}
+
+func printBatchOverview(out io.Writer, result *usecase.BatchResult) {
+	fmt.Fprintln(out, "\n[Batch Overview]")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Job\tStatus\tSystem\tBank\tMatched\tUnmatched System\tUnmatched Bank\tDiscrepancy")
+	for _, job := range result.Jobs {
+		if job.Err != nil {
+			fmt.Fprintf(w, "%s\tFAILED\t-\t-\t-\t-\t-\t-\n", job.Name)
+			continue
+		}
+		summary := job.Response.Summary
+		fmt.Fprintf(w, "%s\tOK\t%d\t%d\t%d\t%d\t%d\t%s\n", job.Name,
+			summary.TotalSystemTransactions, summary.TotalBankTransactions, summary.MatchedTransactions,
+			len(summary.UnmatchedSystemTransactions), summary.UnmatchedBankCount(), summary.AmountDiscrepancyTotal.StringFixed(2))
+	}
+	total := result.Overview
+	fmt.Fprintf(w, "Total\t%d/%d OK\t%d\t%d\t%d\t%d\t%d\t%s\n", total.Succeeded, total.Jobs,
+		total.TotalSystemTransactions, total.TotalBankTransactions, total.MatchedTransactions,
+		total.UnmatchedSystemTransactions, total.UnmatchedBankTransactions, total.AmountDiscrepancyTotal.StringFixed(2))
+	w.Flush()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/report"
+	"github.com/nmmugia/reconciliation-service/internal/service"
+
+	"github.com/shopspring/decimal"
+)
+
+// runDiff implements "reconciler diff OLD NEW", comparing two JSON reports,
+// as saved with -save or written with -format=json, or period close records.
+// It returns the process exit code: exitInputError for invalid arguments and
+// files that cannot be read as reports, exitFailure for anything else.
+func runDiff(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := flags.String("format", "text", "Output format: text or json.")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: reconciler diff [-format text|json] OLD.json NEW.json")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitClean
+		}
+		return exitInputError
+	}
+	if flags.NArg() != 2 || (*format != "text" && *format != "json") {
+		flags.Usage()
+		return exitInputError
+	}
+
+	previous, err := report.LoadSummary(flags.Arg(0))
+	if err != nil {
+		log.Printf("%v", err)
+		return exitInputError
+	}
+	current, err := report.LoadSummary(flags.Arg(1))
+	if err != nil {
+		log.Printf("%v", err)
+		return exitInputError
+	}
+	diff := service.DiffSummaries(previous, current)
+
+	if *format == "json" {
+		if err := report.WriteDiffJSON(out, diff, flags.Arg(0), flags.Arg(1), time.Now().UTC()); err != nil {
+			log.Printf("%v", err)
+			return exitFailure
+		}
+		return exitClean
+	}
+	printDiff(out, flags.Arg(0), flags.Arg(1), diff)
+	return exitClean
+}
+
+func printDiff(w io.Writer, oldPath, newPath string, diff *domain.SummaryDiff) {
+	fmt.Fprintln(w, "\n--- Reconciliation Diff ---")
+	fmt.Fprintf(w, "Old: %s\n", oldPath)
+	fmt.Fprintf(w, "New: %s\n", newPath)
+	if diff.Empty() {
+		fmt.Fprintln(w, "\nNo differences.")
+		fmt.Fprintln(w, "\n--- End of Diff ---")
+		return
+	}
+
+	fmt.Fprintln(w, "\n[Changes]")
+	fmt.Fprintf(w, "Total System Transactions Processed: %+d\n", diff.TotalSystemTransactionsDelta)
+	fmt.Fprintf(w, "Total Bank Transactions Processed:   %+d\n", diff.TotalBankTransactionsDelta)
+	fmt.Fprintf(w, "Matched Transactions:                %+d\n", diff.MatchedTransactionsDelta)
+	fmt.Fprintf(w, "Unmatched System Transactions:       %+d\n", diff.UnmatchedSystemTransactionsDelta)
+	fmt.Fprintf(w, "Unmatched Bank Transactions:         %+d\n", diff.UnmatchedBankTransactionsDelta)
+	fmt.Fprintf(w, "Total Amount Discrepancy:            %s\n", signedAmount(diff.AmountDiscrepancyDelta))
+
+	if len(diff.NewlyMatched) > 0 {
+		fmt.Fprintln(w, "\n[Newly Matched]")
+		for _, pair := range diff.NewlyMatched {
+			fmt.Fprintf(w, "- System ID: %s, Amount: %s matched %s ID: %s, Amount: %s, Discrepancy: %s\n",
+				pair.System.ID, pair.System.Amount.StringFixed(2), pair.Bank.BankName, pair.Bank.ID,
+				pair.Bank.Amount.StringFixed(2), pair.Discrepancy.StringFixed(2))
+		}
+	}
+	if len(diff.NewlyUnmatchedSystem) > 0 {
+		fmt.Fprintln(w, "\n[Newly Unmatched System Transactions]")
+		for _, tx := range diff.NewlyUnmatchedSystem {
+			fmt.Fprintf(w, "- ID: %s, Amount: %s, Type: %s, Time: %s\n",
+				tx.ID, tx.Amount.StringFixed(2), tx.Type, tx.TransactionTime.Format(time.RFC3339))
+		}
+	}
+	if len(diff.NewlyUnmatchedBank) > 0 {
+		fmt.Fprintln(w, "\n[Newly Unmatched Bank Transactions]")
+		for _, tx := range diff.NewlyUnmatchedBank {
+			fmt.Fprintf(w, "- %s ID: %s, Amount: %s, Date: %s\n",
+				tx.BankName, tx.ID, tx.Amount.StringFixed(2), tx.Date.Format("2006-01-02"))
+		}
+	}
+	if len(diff.ChangedPairings) > 0 {
+		fmt.Fprintln(w, "\n[Changed Pairings]")
+		for _, change := range diff.ChangedPairings {
+			fmt.Fprintf(w, "- System ID: %s: %s ID: %s (discrepancy %s) is now %s ID: %s (discrepancy %s)\n",
+				change.System.ID, change.OldBank.BankName, change.OldBank.ID, change.OldDiscrepancy.StringFixed(2),
+				change.NewBank.BankName, change.NewBank.ID, change.NewDiscrepancy.StringFixed(2))
+		}
+	}
+	if len(diff.DiscrepancyChanges) > 0 {
+		fmt.Fprintln(w, "\n[Discrepancy Changes]")
+		for _, change := range diff.DiscrepancyChanges {
+			fmt.Fprintf(w, "- System ID: %s, %s ID: %s: %s -> %s (%s)\n",
+				change.Pair.System.ID, change.Pair.Bank.BankName, change.Pair.Bank.ID,
+				change.OldDiscrepancy.StringFixed(2), change.Pair.Discrepancy.StringFixed(2),
+				signedAmount(change.Pair.Discrepancy.Sub(change.OldDiscrepancy)))
+		}
+	}
+	fmt.Fprintln(w, "\n--- End of Diff ---")
+}
+
+func signedAmount(amount decimal.Decimal) string {
+	if amount.IsPositive() {
+		return "+" + amount.StringFixed(2)
+	}
+	return amount.StringFixed(2)
+}
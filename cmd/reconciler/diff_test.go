@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/report"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	date := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	summary := &domain.ReconciliationSummary{
+		TotalSystemTransactions: 1,
+		TotalBankTransactions:   1,
+		MatchedTransactions:     1,
+		MatchedPairs: []domain.MatchedPair{{
+			System:      domain.SystemTransaction{ID: "S1", Amount: decimal.RequireFromString("100"), Type: domain.Credit, TransactionTime: date},
+			Bank:        domain.BankTransaction{ID: "B1", Amount: decimal.RequireFromString("100"), Date: date, BankName: "BCA"},
+			Discrepancy: decimal.Zero,
+		}},
+		AmountDiscrepancyTotal: decimal.Zero,
+		BankNames:              []string{"BCA"},
+	}
+	saved := filepath.Join(dir, "june.json")
+	require.NoError(t, report.SaveJSON(saved, summary, report.Metadata{GeneratedAt: date}))
+	manifest := filepath.Join(dir, "manifest.json")
+	require.NoError(t, os.WriteFile(manifest, []byte(`{"jobs": []}`), 0o644))
+
+	tests := map[string]struct {
+		args   []string
+		want   int
+		output string
+	}{
+		"same report":    {args: []string{saved, saved}, want: exitClean, output: "No differences."},
+		"json":           {args: []string{"-format=json", saved, saved}, want: exitClean, output: `"newly_matched": []`},
+		"help":           {args: []string{"-h"}, want: exitClean},
+		"unknown flag":   {args: []string{"-since=2025", saved, saved}, want: exitInputError},
+		"unknown format": {args: []string{"-format=html", saved, saved}, want: exitInputError},
+		"one file":       {args: []string{saved}, want: exitInputError},
+		"missing file":   {args: []string{saved, filepath.Join(dir, "missing.json")}, want: exitInputError},
+		"not a report":   {args: []string{manifest, saved}, want: exitInputError},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			assert.Equal(t, tt.want, runDiff(tt.args, &out))
+			assert.Contains(t, out.String(), tt.output)
+		})
+	}
+
+	t.Run("json output parses", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Equal(t, exitClean, runDiff([]string{"-format=json", saved, saved}, &out))
+		var diff report.JSONDiff
+		require.NoError(t, json.Unmarshal(out.Bytes(), &diff))
+		assert.Equal(t, report.SchemaVersion, diff.SchemaVersion)
+		assert.Equal(t, saved, diff.Old)
+		assert.Equal(t, "0", diff.Deltas.AmountDiscrepancy)
+		assert.Empty(t, diff.NewlyMatched)
+	})
+}
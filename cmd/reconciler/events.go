@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/notify"
+)
+
+// buildObserver opens the event sinks selected on the command line. It
+// returns a nil observer when none is. The returned function closes the
+// sinks and reports their delivery errors.
+func buildObserver(eventsFile, webhookURL string, webhookEvents []string) (domain.Observer, func() error, error) {
+	var observers notify.Observers
+	var closers []func() error
+	closeAll := func() error {
+		var errs []error
+		for _, closeSink := range closers {
+			errs = append(errs, closeSink())
+		}
+		return errors.Join(errs...)
+	}
+
+	if eventsFile != "" {
+		sink, err := notify.OpenNDJSONFile(eventsFile)
+		if err != nil {
+			return nil, closeAll, err
+		}
+		observers = append(observers, sink)
+		closers = append(closers, sink.Close)
+	}
+	if webhookURL != "" {
+		types, err := notify.ParseEventTypes(webhookEvents)
+		if err != nil {
+			closeAll()
+			return nil, func() error { return nil }, fmt.Errorf("invalid -webhook-events: %w", err)
+		}
+		sink := notify.NewWebhookSink(webhookURL, nil)
+		observers = append(observers, notify.Filter(sink, types...))
+		closers = append(closers, sink.Close)
+	}
+
+	if len(observers) == 0 {
+		return nil, closeAll, nil
+	}
+	return observers, closeAll, nil
+}
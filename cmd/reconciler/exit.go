@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"runtime/debug"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+)
+
+// Process exit codes, documented in the README.
+const (
+	exitClean = 0
+	// exitExceptions is a completed run that left something to review.
+	exitExceptions = 1
+	// exitInputError covers invalid flags, manifests and inputs that cannot
+	// be read, as well as runs refused over a closed period.
+	exitInputError = 2
+	exitFailure    = 3
+	// exitThreshold is a completed run beyond -max-unmatched or
+	// -max-discrepancy.
+	exitThreshold   = 4
+	exitInterrupted = 130
+)
+
+// atExit lists what has to happen before the process exits, such as
+// delivering queued events; exit runs it, most recent first. Deferred calls
+// do not run on os.Exit.
+var atExit []func()
+
+// fail logs like log.Fatalf and exits with code. Use it instead of log.Fatal,
+// whose exit code 1 means exceptions here.
+func fail(code int, format string, args ...any) {
+	log.Printf(format, args...)
+	exit(code)
+}
+
+// exit runs atExit and exits with code.
+func exit(code int) {
+	for i := len(atExit) - 1; i >= 0; i-- {
+		atExit[i]()
+	}
+	atExit = nil
+	os.Exit(code)
+}
+
+// exitOnPanic, deferred in main, exits with exitFailure on a panic instead of
+// the exit code 2 of an unrecovered panic, which means an input error here.
+func exitOnPanic() {
+	if r := recover(); r != nil {
+		log.Printf("Internal error: %v\n%s", r, debug.Stack())
+		exit(exitFailure)
+	}
+}
+
+// errorExitCode is the exit code of a run that returned err.
+func errorExitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, usecase.ErrInvalidRequest), errors.Is(err, usecase.ErrUnreadableInput), errors.Is(err, domain.ErrPeriodClosed):
+		return exitInputError
+	}
+	return exitFailure
+}
+
+// summaryExitCode is the exit code of a completed run. Exceeded thresholds
+// are logged, prefixed with name for batch jobs.
+func summaryExitCode(name string, summary *domain.ReconciliationSummary, thresholds usecase.Thresholds) int {
+	if err := thresholds.Check(summary); err != nil {
+		if name != "" {
+			name = "Job " + name + ": "
+		}
+		log.Printf("%s%v", name, err)
+		return exitThreshold
+	}
+	if usecase.HasExceptions(summary) {
+		return exitExceptions
+	}
+	return exitClean
+}
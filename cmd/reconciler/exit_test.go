@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorExitCode(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want int
+	}{
+		"cancelled":        {fmt.Errorf("reconciliation cancelled: %w", context.Canceled), exitInterrupted},
+		"invalid request":  {fmt.Errorf("%w: no bank statement files", usecase.ErrInvalidRequest), exitInputError},
+		"unreadable input": {fmt.Errorf("failed to read: %w", usecase.ErrUnreadableInput), exitInputError},
+		"period closed":    {fmt.Errorf("june: %w", domain.ErrPeriodClosed), exitInputError},
+		"timed out":        {context.DeadlineExceeded, exitFailure},
+		"other":            {errors.New("disk full"), exitFailure},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errorExitCode(tt.err))
+		})
+	}
+}
+
+func TestSummaryExitCode(t *testing.T) {
+	clean := func() *domain.ReconciliationSummary {
+		return &domain.ReconciliationSummary{MatchedTransactions: 2, AmountDiscrepancyTotal: decimal.Zero}
+	}
+	unmatched := clean()
+	unmatched.UnmatchedBankTransactions = map[string][]domain.BankTransaction{"BCA": {{ID: "B1"}}}
+	discrepancy := clean()
+	discrepancy.AmountDiscrepancyTotal = decimal.RequireFromString("1500")
+	rejected := clean()
+	rejected.RejectedRows = []domain.RejectedRow{{Source: "system.csv", Line: 3}}
+
+	tests := map[string]struct {
+		summary    *domain.ReconciliationSummary
+		thresholds usecase.Thresholds
+		want       int
+	}{
+		"clean":                  {clean(), usecase.NoThresholds, exitClean},
+		"unmatched":              {unmatched, usecase.NoThresholds, exitExceptions},
+		"discrepancy":            {discrepancy, usecase.NoThresholds, exitExceptions},
+		"rejected rows":          {rejected, usecase.NoThresholds, exitExceptions},
+		"within max-unmatched":   {unmatched, usecase.Thresholds{MaxUnmatched: 1}, exitExceptions},
+		"beyond max-unmatched":   {unmatched, usecase.Thresholds{MaxUnmatched: 0}, exitThreshold},
+		"beyond max-discrepancy": {discrepancy, usecase.Thresholds{MaxUnmatched: -1, MaxDiscrepancy: decimal.NewNullDecimal(decimal.RequireFromString("1000"))}, exitThreshold},
+		"clean with thresholds":  {clean(), usecase.Thresholds{MaxUnmatched: 0, MaxDiscrepancy: decimal.NewNullDecimal(decimal.Zero)}, exitClean},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, summaryExitCode("job", tt.summary, tt.thresholds))
+		})
+	}
+}
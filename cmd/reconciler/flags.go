@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// stringList is a repeatable flag; every occurrence may also hold a
+// comma-separated list.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// pathList is a repeatable flag holding one path per occurrence. Values are
+// taken as they are, so paths may contain commas.
+type pathList []string
+
+func (l *pathList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *pathList) Set(value string) error {
+	if value == "" {
+		return errors.New("empty path")
+	}
+	*l = append(*l, value)
+	return nil
+}
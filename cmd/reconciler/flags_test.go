@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringList_Set(t *testing.T) {
+	tests := map[string]struct {
+		values []string
+		want   []string
+	}{
+		"single":          {[]string{"bca"}, []string{"bca"}},
+		"comma-separated": {[]string{"bca, bri ,hsbc"}, []string{"bca", "bri", "hsbc"}},
+		"repeated":        {[]string{"bca", "bri,hsbc"}, []string{"bca", "bri", "hsbc"}},
+		"empty items":     {[]string{",bca,,", ""}, []string{"bca"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var list stringList
+			for _, value := range tt.values {
+				assert.NoError(t, list.Set(value))
+			}
+			assert.Equal(t, tt.want, []string(list))
+		})
+	}
+}
+
+func TestPathList_Set(t *testing.T) {
+	tests := map[string]struct {
+		values []string
+		want   []string
+		err    string
+	}{
+		"single":      {values: []string{"statements/bca.csv"}, want: []string{"statements/bca.csv"}},
+		"repeated":    {values: []string{"bca.csv", "bri.csv"}, want: []string{"bca.csv", "bri.csv"}},
+		"with commas": {values: []string{"June, BCA.csv"}, want: []string{"June, BCA.csv"}},
+		"glob":        {values: []string{"statements/*.csv"}, want: []string{"statements/*.csv"}},
+		"empty":       {values: []string{"bca.csv", ""}, want: []string{"bca.csv"}, err: "empty path"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var list pathList
+			var err error
+			for _, value := range tt.values {
+				if err = list.Set(value); err != nil {
+					break
+				}
+			}
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, []string(list))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/repository"
+	"github.com/nmmugia/reconciliation-service/internal/service"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+)
+
+// buildJob opens the readers of a job. The returned closer releases the
+// database connection of a -sys-dsn ledger and must be called once the job
+// has run.
+func buildJob(config repository.JobConfig) (usecase.BatchJob, io.Closer, error) {
+	var closer io.Closer = nopCloser{}
+	if err := config.Validate(); err != nil {
+		return usecase.BatchJob{}, closer, err
+	}
+	period, err := config.Period.Parse()
+	if err != nil {
+		return usecase.BatchJob{}, closer, err
+	}
+
+	bankPaths, err := repository.ExpandPaths(config.Bank.Files, repository.ExpandOptions{
+		Recursive: config.Bank.Recursive,
+		Include:   config.Bank.Include,
+		Exclude:   config.Bank.Exclude,
+	})
+	if err != nil {
+		return usecase.BatchJob{}, closer, fmt.Errorf("invalid bank input: %w", err)
+	}
+	stdinUses := 0
+	for _, path := range append([]string{config.System.File}, bankPaths...) {
+		if path == repository.StdinPath {
+			stdinUses++
+		}
+	}
+	if stdinUses > 1 {
+		return usecase.BatchJob{}, closer, fmt.Errorf("standard input ('%s') can only be used for one input", repository.StdinPath)
+	}
+
+	aliases, err := repository.ParseTypeAliases(config.System.TypeAliases)
+	if err != nil {
+		return usecase.BatchJob{}, closer, fmt.Errorf("invalid type aliases: %w", err)
+	}
+
+	// Every reader reports the rows it skips to the same log, which the
+	// usecase lists in the summary, and the bank readers report the
+	// fingerprints of the statements for the duplicate check.
+	rejected := &domain.RejectionLog{}
+	fingerprints := &domain.FingerprintLog{}
+	var sysReader domain.TransactionDataReader = repository.NewCsvLedgerReader().WithEncoding(config.System.Encoding).WithTypeAliases(aliases).WithRejectionLog(rejected)
+	if config.System.DSN != "" {
+		columns, err := repository.ParseSQLColumnMapping(config.System.Columns)
+		if err != nil {
+			return usecase.BatchJob{}, closer, fmt.Errorf("invalid column mapping: %w", err)
+		}
+		driver := config.System.Driver
+		if driver == "" {
+			driver = "postgres"
+		}
+		db, err := sql.Open(driver, config.System.DSN)
+		if err != nil {
+			return usecase.BatchJob{}, closer, fmt.Errorf("could not open system transaction database: %w", err)
+		}
+		closer = db
+		sysReader = repository.NewSQLLedgerReader(db, repository.SQLLedgerConfig{Query: config.System.Query, Columns: columns, TypeAliases: aliases}).WithRejectionLog(rejected)
+	}
+
+	xlsxReader := repository.NewXlsxStatementReader(repository.XlsxReaderConfig{Sheet: config.Bank.Sheet}).WithRejectionLog(rejected).WithFingerprintLog(fingerprints)
+	csvReader := repository.NewCsvLedgerReader().WithEncoding(config.Bank.Encoding).WithRejectionLog(rejected).WithFingerprintLog(fingerprints)
+	var bankReader domain.BankStatementReader = repository.NewFormatRouter(csvReader, map[string]domain.BankStatementReader{".xlsx": xlsxReader})
+	var declaredBalances []domain.DeclaredBalance
+	if config.Bank.Sources != "" {
+		registry, err := repository.LoadSourceRegistry(config.Bank.Sources)
+		if err != nil {
+			closer.Close()
+			return usecase.BatchJob{}, nopCloser{}, err
+		}
+		bankReader = repository.NewRegistryStatementReader(registry, nil, bankReader).WithRejectionLog(rejected).WithFingerprintLog(fingerprints)
+		declaredBalances = registry.DeclaredBalances()
+	}
+
+	return usecase.BatchJob{
+		Name:    config.Name,
+		Usecase: usecase.NewReconciliationUsecase(sysReader, bankReader, service.NewReconciliationEngine()).WithRejectionLog(rejected).WithFingerprintLog(fingerprints),
+		Request: usecase.ReconciliationRequest{
+			SystemFile: config.System.File,
+			BankFiles:  bankPaths,
+			Period:     period,
+			Policy: usecase.Policy{
+				AllowPartial:     config.Policy.AllowPartial,
+				Stream:           config.Policy.Stream,
+				DeclaredBalances: declaredBalances,
+			},
+		},
+	}, closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -1,95 +1,437 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/report"
 	"github.com/nmmugia/reconciliation-service/internal/infrastructure/repository"
-	"github.com/nmmugia/reconciliation-service/internal/service"
 	"github.com/nmmugia/reconciliation-service/internal/usecase"
+
+	"github.com/shopspring/decimal"
+
+	_ "github.com/lib/pq"
+	_ "time/tzdata"
 )
 
 func main() {
+	defer exitOnPanic()
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		exit(runDiff(os.Args[2:], os.Stdout))
+	}
 
 	processStartTime := time.Now()
 
-	sysTxPath := flag.String("sys", "", "Path to system transactions CSV. (Required)")
-	bankStatementPaths := flag.String("bank", "", "Comma-separated paths to bank statement CSVs. (Required)")
+	sysTxPath := flag.String("sys", "", "Path to system transactions CSV, or '-' for stdin. Gzip and single-file zip inputs are accepted. (Required)")
+	var bankInputs pathList
+	var bankInclude, bankExclude stringList
+	flag.Var(&bankInputs, "bank", "Bank statement CSV or XLSX file, directory or glob pattern, or '-' for stdin. Repeat the flag for more inputs, or list them after the flags. Gzip files and zip archives of CSVs are accepted. (Required)")
+	bankListPath := flag.String("bank-list", "", "File listing bank statement inputs, one per line, in addition to -bank.")
+	bankRecursive := flag.Bool("bank-recursive", false, "Descend into subdirectories of -bank directories.")
+	flag.Var(&bankInclude, "bank-include", "Only use files from -bank directories and globs whose name matches one of these patterns, e.g. '*.csv'. Comma-separated and repeatable.")
+	flag.Var(&bankExclude, "bank-exclude", "Skip files from -bank directories and globs whose name matches one of these patterns. Comma-separated and repeatable.")
+	sysDSN := flag.String("sys-dsn", "", "Database DSN to read system transactions from instead of -sys.")
+	sysDriver := flag.String("sys-driver", "postgres", "database/sql driver used with -sys-dsn.")
+	sysQuery := flag.String("sys-query", repository.DefaultSQLLedgerQuery, "Query used with -sys-dsn. The period start and exclusive end are bound as the two parameters.")
+	sysColumns := flag.String("sys-columns", "", "Column mapping for -sys-query as field=column pairs, e.g. 'id=ref,time=created_at'. Fields: id, amount, type, time.")
+	typeAliases := flag.String("type-aliases", "", "Extra spellings of system transaction types as alias=TYPE pairs, e.g. 'DB=DEBIT,KR=CREDIT'. D, DR, DB, C and CR are always accepted.")
+	sourcesPath := flag.String("sources", "", "JSON sources config declaring the bank accounts and which statement files belong to each.")
+	sysEncoding := flag.String("sys-encoding", repository.EncodingAuto, "Text encoding of the system transaction CSV: auto, utf-8, utf-16le, utf-16be or windows-1252.")
+	bankEncoding := flag.String("bank-encoding", repository.EncodingAuto, "Text encoding of CSV bank statements not covered by a -sources encoding.")
+	bankSheet := flag.String("bank-sheet", "", "Worksheet name or 1-based position to read from XLSX bank statements. Defaults to the first sheet.")
 	startDateStr := flag.String("start", "", "Start date for reconciliation (YYYY-MM-DD). (Required)")
 	endDateStr := flag.String("end", "", "End date for reconciliation (YYYY-MM-DD). (Required)")
+	allowPartial := flag.Bool("allow-partial", false, "Continue when some bank statements cannot be read and report them as not reconciled.")
+	stream := flag.Bool("stream", false, "Match while reading instead of loading all inputs first. Inputs must be in date order.")
+	timeout := flag.Duration("timeout", 0, "Abort the reconciliation after this duration, e.g. '10m'. Zero means no limit.")
+	manifestPath := flag.String("manifest", "", "JSON job manifest listing several reconciliations to run instead of the input flags.")
+	parallel := flag.Int("parallel", 0, "Number of -manifest jobs run at once. Overrides the manifest's parallelism.")
+	closeDir := flag.String("close-dir", "", "Directory of closed period records; runs over a closed period are refused. Defaults to $"+repository.PeriodCloseDirEnv+", or reconciler/closes in the user's configuration directory.")
+	scope := flag.String("scope", "", "Books the run belongs to, such as an account; periods are closed per scope. Manifest jobs use their name.")
+	closePeriod := flag.Bool("close", false, "Close the period after a successful run. Requires -approver.")
+	approver := flag.String("approver", "", "Name recorded when closing or reopening a period.")
+	var overridesPaths pathList
+	flag.Var(&overridesPaths, "overrides", "CSV of manual decisions with system_id, bank_id and note columns, such as a reviewed -export-csv file, recorded with -close. Repeatable.")
+	reopenReason := flag.String("reopen", "", "Reopen the closed period overlapping -start to -end, giving this reason, instead of running.")
+	eventsFile := flag.String("events-file", "", "Append an NDJSON line for every match, suggested match, unmatched transaction and completed run to this file.")
+	webhookURL := flag.String("webhook", "", "POST every event as JSON to this URL.")
+	var webhookEvents stringList
+	flag.Var(&webhookEvents, "webhook-events", "Only POST these event types to -webhook: matched, suggested_match, unmatched_system, unmatched_bank, run_completed. Comma-separated and repeatable.")
+	format := flag.String("format", formatText, "Report format: text, json or html. The JSON schema is documented in docs/report-schema.json.")
+	outputPath := flag.String("output", "", "Write the report to this file instead of standard output.")
+	savePath := flag.String("save", "", "Also write the JSON report to this file, for the diff command.")
+	exportDir := flag.String("export-csv", "", "Also write matched.csv, unmatched_system.csv and unmatched_bank.csv to this directory for review.")
+	maxUnmatched := flag.Int("max-unmatched", -1, "Exit with code 4 when more system and bank transactions together are unmatched. Negative means no limit.")
+	maxDiscrepancy := flag.String("max-discrepancy", "", "Exit with code 4 when the total amount discrepancy is larger, e.g. '1000.00'.")
+	reprint := flag.Bool("reprint", false, "Print the closed record of the period from -start to -end instead of running.")
 	flag.Parse()
+	bankInputs = append(bankInputs, flag.Args()...)
+	if *bankListPath != "" {
+		listed, err := repository.ReadPathList(*bankListPath)
+		if err != nil {
+			fail(exitInputError, "%v", err)
+		}
+		bankInputs = append(bankInputs, listed...)
+	}
 
-	if *sysTxPath == "" || *bankStatementPaths == "" || *startDateStr == "" || *endDateStr == "" {
-		fmt.Println("Error: All flags are required.")
-		flag.Usage()
-		os.Exit(1)
+	// The first SIGINT/SIGTERM cancels the run; a second one kills the process.
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-sigCtx.Done()
+		stop()
+	}()
+	ctx := sigCtx
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	// Closed periods are checked whenever there is a directory for them, so
+	// that leaving out -close-dir does not get around a close. Without a
+	// configuration directory, as under cron, no period can have been closed
+	// in the default one, so only -close, -reopen and -reprint need it.
+	periodCommand := *closePeriod || *reopenReason != "" || *reprint
+	if *closeDir == "" {
+		dir, err := repository.DefaultPeriodCloseDir()
+		if err != nil && periodCommand {
+			fail(exitInputError, "%v", err)
+		}
+		*closeDir = dir
+	}
+	var closes usecase.PeriodCloseStore
+	if *closeDir != "" {
+		closes = repository.NewPeriodCloseStore(*closeDir)
+	}
+	thresholds := usecase.Thresholds{MaxUnmatched: *maxUnmatched}
+	if *maxDiscrepancy != "" {
+		limit, err := decimal.NewFromString(*maxDiscrepancy)
+		if err != nil {
+			fail(exitInputError, "Invalid -max-discrepancy '%s': %v", *maxDiscrepancy, err)
+		}
+		thresholds.MaxDiscrepancy = decimal.NewNullDecimal(limit)
 	}
 
-	startDate, err := time.Parse("2006-01-02", *startDateStr)
+	out, err := openOutput(*format, *outputPath)
 	if err != nil {
-		log.Fatalf("Invalid start date format: %v. Please use YYYY-MM-DD.", err)
+		fail(exitInputError, "%v", err)
 	}
-	endDate, err := time.Parse("2006-01-02", *endDateStr)
+	atExit = append(atExit, out.discard)
+
+	observer, closeObserver, err := buildObserver(*eventsFile, *webhookURL, webhookEvents)
 	if err != nil {
-		log.Fatalf("Invalid end date format: %v. Please use YYYY-MM-DD.", err)
+		fail(exitInputError, "%v", err)
 	}
+	// Events are delivered on every exit, including failures.
+	atExit = append(atExit, func() {
+		if err := closeObserver(); err != nil {
+			log.Printf("Event delivery failed: %v", err)
+		}
+	})
 
-	csvReader := repository.NewCsvLedgerReader()
-	recoEngine := service.NewReconciliationEngine()
-	reconciler := usecase.NewReconciliationUsecase(csvReader, csvReader, recoEngine)
+	// finish completes the report and delivers the remaining events before
+	// exiting with code.
+	finish := func(code int) {
+		if err := out.commit(); err != nil {
+			log.Printf("Could not write report: %v", err)
+			code = exitFailure
+		}
+		exit(code)
+	}
 
-	log.Println("Starting reconciliation process...")
-	summary, err := reconciler.PerformReconciliation(*sysTxPath, strings.Split(*bankStatementPaths, ","), startDate, endDate)
+	if *manifestPath != "" {
+		if periodCommand {
+			fail(exitInputError, "-close, -reopen and -reprint cannot be combined with -manifest.")
+		}
+		finish(runManifest(ctx, *manifestPath, *parallel, closes, observer, thresholds, out))
+	}
+
+	if *reopenReason != "" || *reprint {
+		period, err := repository.PeriodConfig{Start: *startDateStr, End: *endDateStr}.Parse()
+		if err != nil {
+			fail(exitInputError, "Invalid period: %v", err)
+		}
+		periods := usecase.NewPeriodService(closes)
+		if *reopenReason != "" {
+			record, err := periods.ReopenPeriod(*scope, period, *approver, *reopenReason)
+			if err != nil {
+				fail(errorExitCode(err), "Could not reopen period: %v", err)
+			}
+			log.Printf("Period %s to %s reopened.", record.Period.Start.Format("2006-01-02"), record.Period.End.Format("2006-01-02"))
+			// Nothing is reported, so -output keeps the previous report.
+			exit(exitClean)
+		}
+		record, err := periods.ClosedPeriod(*scope, period)
+		if err != nil {
+			fail(exitFailure, "Could not read closed period: %v", err)
+		}
+		if record == nil {
+			fail(exitInputError, "Period %s to %s is not closed.", *startDateStr, *endDateStr)
+		}
+		if err := out.writePeriodClose(record); err != nil {
+			fail(exitFailure, "%v", err)
+		}
+		finish(exitClean)
+	}
+
+	if (*sysTxPath == "" && *sysDSN == "") || len(bankInputs) == 0 || *startDateStr == "" || *endDateStr == "" {
+		fmt.Println("Error: All flags are required.")
+		flag.Usage()
+		exit(exitInputError)
+	}
+
+	if *closePeriod && *approver == "" {
+		fail(exitInputError, "-close requires -approver.")
+	}
+	var overrides []domain.Override
+	for _, path := range overridesPaths {
+		loaded, err := repository.LoadOverrides(path)
+		if err != nil {
+			fail(exitInputError, "%v", err)
+		}
+		overrides = append(overrides, loaded...)
+	}
+
+	config := repository.JobConfig{
+		System: repository.SystemInputConfig{
+			File:        *sysTxPath,
+			Encoding:    *sysEncoding,
+			DSN:         *sysDSN,
+			Driver:      *sysDriver,
+			Query:       *sysQuery,
+			Columns:     *sysColumns,
+			TypeAliases: *typeAliases,
+		},
+		Bank: repository.BankInputConfig{
+			Files:     bankInputs,
+			Recursive: *bankRecursive,
+			Include:   bankInclude,
+			Exclude:   bankExclude,
+			Sources:   *sourcesPath,
+			Encoding:  *bankEncoding,
+			Sheet:     *bankSheet,
+		},
+		Period: repository.PeriodConfig{Start: *startDateStr, End: *endDateStr},
+		Policy: repository.PolicyConfig{AllowPartial: *allowPartial, Stream: *stream},
+	}
+	job, closer, err := buildJob(config)
 	if err != nil {
-		log.Fatalf("Reconciliation failed: %v", err)
+		fail(exitInputError, "Invalid input: %v", err)
+	}
+	defer closer.Close()
+	job.Usecase.WithPeriodCloses(closes).WithObserver(observer)
+	job.Request.Scope = *scope
+	// The saved summary, the CSV export and the closed period all hold the
+	// matched pairs.
+	job.Request.Policy.KeepMatchedPairs = out.listsMatchedPairs() || *savePath != "" || *exportDir != "" || *closePeriod
+
+	log.Println("Starting reconciliation process...")
+	response, err := job.Usecase.Run(ctx, job.Request)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		fail(exitFailure, "Reconciliation timed out after %s.", *timeout)
+	case errors.Is(err, domain.ErrPeriodClosed):
+		fail(exitInputError, "Reconciliation refused: %v. Reopen the period with -reopen first.", err)
+	case errors.Is(err, context.Canceled):
+		fail(exitInterrupted, "Reconciliation interrupted.")
+	case err != nil:
+		fail(errorExitCode(err), "Reconciliation failed: %v", err)
 	}
 	log.Println("Reconciliation process completed successfully.")
+	summary := response.Summary
 	summary.ProcessingDurationSeconds = time.Since(processStartTime).Seconds()
 
-	printSummary(summary)
+	if err := out.writeSummary(summary, job.Request.Scope, job.Request.Period); err != nil {
+		fail(exitFailure, "%v", err)
+	}
+
+	if *savePath != "" {
+		if err := report.SaveJSON(*savePath, summary, report.Metadata{Scope: job.Request.Scope, Period: job.Request.Period, GeneratedAt: time.Now().UTC()}); err != nil {
+			fail(exitFailure, "%v", err)
+		}
+	}
+	if *exportDir != "" {
+		paths, err := report.ExportCSV(*exportDir, summary)
+		if err != nil {
+			fail(exitFailure, "Could not export CSV: %v", err)
+		}
+		log.Printf("Exported %s.", strings.Join(paths, ", "))
+	}
+
+	code := summaryExitCode("", summary, thresholds)
+	switch {
+	case *closePeriod && code == exitThreshold:
+		log.Printf("Period %s to %s was not closed.", *startDateStr, *endDateStr)
+	case *closePeriod:
+		inputs, err := closeInputs(config, job.Request)
+		if err != nil {
+			fail(exitInputError, "Could not close period: %v", err)
+		}
+		record, err := job.Usecase.ClosePeriod(usecase.CloseRequest{Response: response, Inputs: inputs, Overrides: overrides, ApprovedBy: *approver})
+		if err != nil {
+			fail(errorExitCode(err), "Could not close period: %v", err)
+		}
+		log.Printf("Period %s to %s closed by %s.", *startDateStr, *endDateStr, record.ApprovedBy)
+	}
+	finish(code)
 }
 
-func printSummary(summary *domain.ReconciliationSummary) {
-	fmt.Println("\n--- Reconciliation Report ---")
-	fmt.Printf("Processing Time: %.2f seconds\n\n", summary.ProcessingDurationSeconds)
-	fmt.Println("[Summary]")
-	fmt.Printf("Total System Transactions Processed: %d\n", summary.TotalSystemTransactions)
-	fmt.Printf("Total Bank Transactions Processed:   %d\n", summary.TotalBankTransactions)
-	fmt.Printf("Matched Transactions:                %d\n", summary.MatchedTransactions)
-	fmt.Printf("Unmatched System Transactions:       %d\n", len(summary.UnmatchedSystemTransactions))
-	unmatchedBankCount := 0
-	for _, txs := range summary.UnmatchedBankTransactions {
-		unmatchedBankCount += len(txs)
-	}
-	fmt.Printf("Unmatched Bank Transactions:         %d\n", unmatchedBankCount)
-	fmt.Printf("Total Amount Discrepancy:            %s\n", summary.AmountDiscrepancyTotal.StringFixed(2))
+func printSummary(w io.Writer, summary *domain.ReconciliationSummary) {
+	fmt.Fprintln(w, "\n--- Reconciliation Report ---")
+	fmt.Fprintf(w, "Processing Time: %.2f seconds\n\n", summary.ProcessingDurationSeconds)
+	fmt.Fprintln(w, "[Summary]")
+	fmt.Fprintf(w, "Total System Transactions Processed: %d\n", summary.TotalSystemTransactions)
+	fmt.Fprintf(w, "Total Bank Transactions Processed:   %d\n", summary.TotalBankTransactions)
+	fmt.Fprintf(w, "Matched Transactions:                %d\n", summary.MatchedTransactions)
+	fmt.Fprintf(w, "Unmatched System Transactions:       %d\n", len(summary.UnmatchedSystemTransactions))
+	fmt.Fprintf(w, "Unmatched Bank Transactions:         %d\n", summary.UnmatchedBankCount())
+	fmt.Fprintf(w, "Total Amount Discrepancy:            %s\n", summary.AmountDiscrepancyTotal.StringFixed(2))
+
+	if len(summary.BalanceGaps) > 0 {
+		fmt.Fprintln(w, "\n[Balance Gaps]")
+		for _, gap := range summary.BalanceGaps {
+			account := gap.BankName
+			if gap.AccountNumber != "" {
+				account += " (Account: " + gap.AccountNumber + ")"
+			}
+			if gap.TransactionID == "" {
+				fmt.Fprintf(w, "- %s: closing balance %s, statements add up to %s\n",
+					account, gap.Actual.StringFixed(2), gap.Expected.StringFixed(2))
+				continue
+			}
+			fmt.Fprintf(w, "- %s: %s ID: %s, balance %s, expected %s\n",
+				account, gap.Source, gap.TransactionID, gap.Actual.StringFixed(2), gap.Expected.StringFixed(2))
+		}
+	}
 
 	if len(summary.UnmatchedSystemTransactions) > 0 {
-		fmt.Println("\n[Unmatched System Transactions]")
+		fmt.Fprintln(w, "\n[Unmatched System Transactions]")
 		for _, tx := range summary.UnmatchedSystemTransactions {
-			fmt.Printf("- ID: %s, Amount: %s, Type: %s, Time: %s\n",
+			fmt.Fprintf(w, "- ID: %s, Amount: %s, Type: %s, Time: %s\n",
 				tx.ID, tx.Amount.StringFixed(2), tx.Type, tx.TransactionTime.Format(time.RFC3339))
 		}
 	}
 
 	if len(summary.UnmatchedBankTransactions) > 0 {
-		fmt.Println("\n[Unmatched Bank Transactions]")
-		for bank, txs := range summary.UnmatchedBankTransactions {
-			fmt.Printf("  Bank: %s\n", bank)
+		fmt.Fprintln(w, "\n[Unmatched Bank Transactions]")
+		for _, bank := range summary.BankNames {
+			txs := summary.UnmatchedBankTransactions[bank]
+			if len(txs) == 0 {
+				continue
+			}
+			if account := txs[0].AccountNumber; account != "" {
+				fmt.Fprintf(w, "  Bank: %s (Account: %s)\n", bank, account)
+			} else {
+				fmt.Fprintf(w, "  Bank: %s\n", bank)
+			}
 			for _, tx := range txs {
 				txType := "CREDIT"
 				if tx.Amount.IsNegative() {
 					txType = "DEBIT"
 				}
-				fmt.Printf("  - ID: %s, Amount: %s (%s), Date: %s\n",
+				fmt.Fprintf(w, "  - ID: %s, Amount: %s (%s), Date: %s\n",
 					tx.ID, tx.Amount.Abs().StringFixed(2), txType, tx.Date.Format("2006-01-02"))
 			}
 		}
 	}
-	fmt.Println("\n--- End of Report ---")
+
+	if aging := summary.Aging; aging != nil && (len(summary.UnmatchedSystemTransactions) > 0 || len(aging.Banks) > 0) {
+		fmt.Fprintf(w, "\n[Aging at %s]\n", aging.AsOf.Format("2006-01-02"))
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprint(tw, "\t")
+		for _, count := range aging.System {
+			fmt.Fprintf(tw, "%s days\t", count.Bucket)
+		}
+		fmt.Fprintln(tw)
+		printAgingRow(tw, "System", aging.System)
+		for _, bank := range aging.Banks {
+			printAgingRow(tw, bank.BankName, bank.Buckets)
+		}
+		tw.Flush()
+	}
+
+	if len(summary.BankBreakdowns) > 0 {
+		fmt.Fprintln(w, "\n[By Bank]")
+		tw := newBreakdownWriter(w)
+		for _, bank := range summary.BankBreakdowns {
+			printBreakdownRows(tw, bank.BankName, bank.Breakdown)
+		}
+		tw.Flush()
+	}
+	if len(summary.DayBreakdowns) > 0 {
+		fmt.Fprintln(w, "\n[By Day]")
+		tw := newBreakdownWriter(w)
+		for _, day := range summary.DayBreakdowns {
+			printBreakdownRows(tw, day.Date.Format("2006-01-02"), day.Breakdown)
+		}
+		tw.Flush()
+	}
+
+	if len(summary.UnreconciledSources) > 0 {
+		fmt.Fprintln(w, "\n[Not Reconciled]")
+		for _, source := range summary.UnreconciledSources {
+			fmt.Fprintf(w, "- %s: %s\n", source.Source, source.Reason)
+		}
+	}
+	if len(summary.RejectedRows) > 0 {
+		fmt.Fprintln(w, "\n[Rejected Rows]")
+		for _, row := range summary.RejectedRows {
+			fmt.Fprintf(w, "- %s:%d: %s\n", row.Source, row.Line, row.Reason)
+		}
+	}
+	if len(summary.DuplicateStatements) > 0 || len(summary.DuplicateBankTransactions) > 0 {
+		fmt.Fprintln(w, "\n[Duplicates Dropped]")
+		for _, statement := range summary.DuplicateStatements {
+			fmt.Fprintf(w, "- %s: identical to %s, %d transactions (sha256 %s)\n",
+				statement.Source, statement.DuplicateOf, statement.Transactions, statement.Fingerprint[:12])
+		}
+		for _, duplicate := range summary.DuplicateBankTransactions {
+			tx := duplicate.Transaction
+			fmt.Fprintf(w, "- %s: ID: %s, Amount: %s, Date: %s, already in %s\n",
+				tx.Source, tx.ID, tx.Amount.StringFixed(2), tx.Date.Format("2006-01-02"), duplicate.DuplicateOf)
+		}
+	}
+	fmt.Fprintln(w, "\n--- End of Report ---")
+}
+
+func newBreakdownWriter(w io.Writer) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\t\tSystem\tBank\tMatched\tUnmatched system\tUnmatched bank\tDiscrepancy\t")
+	return tw
+}
+
+// printBreakdownRows prints a row for each direction of breakdown that has
+// transactions.
+func printBreakdownRows(w io.Writer, name string, breakdown domain.Breakdown) {
+	for _, txType := range []domain.TransactionType{domain.Debit, domain.Credit} {
+		figures := breakdown.Direction(txType)
+		if figures.System.Count+figures.Bank.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t", name, txType)
+		for _, tally := range []domain.Tally{figures.System, figures.Bank, figures.Matched, figures.UnmatchedSystem, figures.UnmatchedBank} {
+			fmt.Fprintf(w, "%d (%s)\t", tally.Count, tally.Amount.StringFixed(2))
+		}
+		fmt.Fprintf(w, "%s\t\n", figures.Discrepancy.StringFixed(2))
+	}
+}
+
+func printAgingRow(w io.Writer, name string, counts []domain.AgingCount) {
+	fmt.Fprintf(w, "%s\t", name)
+	for _, count := range counts {
+		fmt.Fprintf(w, "%d (%s)\t", count.Count, count.Amount.StringFixed(2))
+	}
+	fmt.Fprintln(w)
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/report"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatHTML = "html"
+)
+
+// reportOutput is where and how reports are written, as chosen with -format
+// and -output.
+type reportOutput struct {
+	format string
+	w      io.Writer
+	// file is written in place of path until commit, so that a run that
+	// fails or is refused keeps the previous report.
+	file *os.File
+	path string
+}
+
+// openOutput validates the format and opens the -output file, or standard
+// output without one.
+func openOutput(format, path string) (*reportOutput, error) {
+	if format != formatText && format != formatJSON && format != formatHTML {
+		return nil, fmt.Errorf("unknown -format '%s', expected text, json or html", format)
+	}
+	if path == "" {
+		return &reportOutput{format: format, w: os.Stdout}, nil
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("could not create -output file: %w", err)
+	}
+	return &reportOutput{format: format, w: file, file: file, path: path}, nil
+}
+
+// commit replaces the -output file with what was written.
+func (o *reportOutput) commit() error {
+	if o.file == nil {
+		return nil
+	}
+	file := o.file
+	o.file = nil
+	// Temporary files are private; reports are read like any other file.
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	if err := os.Rename(file.Name(), o.path); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+// discard drops what was written, leaving the -output file as it was.
+func (o *reportOutput) discard() {
+	if o.file == nil {
+		return
+	}
+	o.file.Close()
+	os.Remove(o.file.Name())
+	o.file = nil
+}
+
+// listsMatchedPairs reports whether the format lists matched pairs, which
+// streamed runs only keep on request.
+func (o *reportOutput) listsMatchedPairs() bool {
+	return o.format != formatText
+}
+
+func (o *reportOutput) writeSummary(summary *domain.ReconciliationSummary, scope string, period domain.Period) error {
+	meta := report.Metadata{Scope: scope, Period: period, GeneratedAt: time.Now().UTC()}
+	switch o.format {
+	case formatJSON:
+		return report.WriteJSON(o.w, summary, meta)
+	case formatHTML:
+		return report.WriteHTML(o.w, summary, meta)
+	}
+	printSummary(o.w, summary)
+	return nil
+}
+
+func (o *reportOutput) writePeriodClose(record *domain.PeriodClose) error {
+	meta := report.Metadata{Scope: record.Scope, Period: record.Period, GeneratedAt: time.Now().UTC()}
+	switch o.format {
+	case formatJSON:
+		return report.WriteJSON(o.w, record.Summary, meta)
+	case formatHTML:
+		return report.WriteHTML(o.w, record.Summary, meta)
+	}
+	printPeriodClose(o.w, record)
+	return nil
+}
+
+func (o *reportOutput) writeBatch(result *usecase.BatchResult) error {
+	switch o.format {
+	case formatJSON:
+		return report.WriteBatchJSON(o.w, result, time.Now().UTC())
+	case formatHTML:
+		return report.WriteBatchHTML(o.w, result, time.Now().UTC())
+	}
+	for _, job := range result.Jobs {
+		fmt.Fprintf(o.w, "\n===== Job: %s =====\n", job.Name)
+		if job.Err != nil {
+			fmt.Fprintf(o.w, "Reconciliation failed: %v\n", job.Err)
+			continue
+		}
+		printSummary(o.w, job.Response.Summary)
+	}
+	printBatchOverview(o.w, result)
+	return nil
+}
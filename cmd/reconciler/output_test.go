@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportOutput(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.json")
+	require.NoError(t, os.WriteFile(path, []byte("previous"), 0o644))
+
+	write := func(content string) *reportOutput {
+		out, err := openOutput(formatJSON, path)
+		require.NoError(t, err)
+		_, err = out.w.Write([]byte(content))
+		require.NoError(t, err)
+		return out
+	}
+	read := func() string {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(content)
+	}
+	files := func() int {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		return len(entries)
+	}
+
+	t.Run("discarded", func(t *testing.T) {
+		out := write("refused")
+		assert.Equal(t, "previous", read(), "not replaced before commit")
+		out.discard()
+		assert.Equal(t, "previous", read())
+		assert.Equal(t, 1, files(), "temporary file removed")
+	})
+
+	t.Run("committed", func(t *testing.T) {
+		out := write("current")
+		require.NoError(t, out.commit())
+		out.discard()
+		assert.Equal(t, "current", read(), "discard after commit keeps the report")
+		assert.Equal(t, 1, files())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := openOutput("xml", path)
+		assert.Error(t, err)
+		assert.Equal(t, 1, files())
+	})
+}
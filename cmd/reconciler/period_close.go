@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/repository"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+)
+
+// closeInputs hashes the files a run read. A database ledger has no file and
+// is left out.
+func closeInputs(config repository.JobConfig, request usecase.ReconciliationRequest) ([]domain.InputDigest, error) {
+	var paths []string
+	if config.System.DSN == "" {
+		paths = append(paths, request.SystemFile)
+	}
+	paths = append(paths, request.BankFiles...)
+	if config.Bank.Sources != "" {
+		paths = append(paths, config.Bank.Sources)
+	}
+	return repository.DigestFiles(paths)
+}
+
+func printPeriodClose(w io.Writer, record *domain.PeriodClose) {
+	fmt.Fprintln(w, "\n--- Closed Period ---")
+	if record.Scope != "" {
+		fmt.Fprintf(w, "Scope:       %s\n", record.Scope)
+	}
+	fmt.Fprintf(w, "Period:      %s to %s\n", record.Period.Start.Format("2006-01-02"), record.Period.End.Format("2006-01-02"))
+	fmt.Fprintf(w, "Approved By: %s\n", record.ApprovedBy)
+	fmt.Fprintf(w, "Closed At:   %s\n", record.ClosedAt.Format(time.RFC3339))
+
+	if len(record.Inputs) > 0 {
+		fmt.Fprintln(w, "\n[Inputs]")
+		for _, input := range record.Inputs {
+			fmt.Fprintf(w, "- %s (sha256 %s)\n", input.Path, input.SHA256)
+		}
+	}
+	if len(record.Overrides) > 0 {
+		fmt.Fprintln(w, "\n[Overrides]")
+		for _, override := range record.Overrides {
+			switch {
+			case override.SystemTransactionID != "" && override.BankTransactionID != "":
+				fmt.Fprintf(w, "- System ID: %s paired with Bank ID: %s", override.SystemTransactionID, override.BankTransactionID)
+			case override.SystemTransactionID != "":
+				fmt.Fprintf(w, "- System ID: %s accepted as unmatched", override.SystemTransactionID)
+			default:
+				fmt.Fprintf(w, "- Bank ID: %s accepted as unmatched", override.BankTransactionID)
+			}
+			if override.Note != "" {
+				fmt.Fprintf(w, ": %s", override.Note)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	printSummary(w, record.Summary)
+}
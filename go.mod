@@ -3,6 +3,7 @@ module github.com/nmmugia/reconciliation-service
 go 1.23.9
 
 require (
+	github.com/lib/pq v1.10.9
 	github.com/shopspring/decimal v1.4.0
 	github.com/stretchr/testify v1.10.0
 )
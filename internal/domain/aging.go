@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AgingBucket is a range of ages in days. MaxDays is -1 for the open-ended
+// last bucket.
+type AgingBucket struct {
+	Label   string
+	MinDays int
+	MaxDays int
+}
+
+// AgingBuckets are the ranges unmatched transactions are aged into. Both
+// ends of a range are included, so "30+" means more than 30 days: it starts
+// at 31, as 8-30 covers day 30.
+var AgingBuckets = []AgingBucket{
+	{Label: "0-3", MinDays: 0, MaxDays: 3},
+	{Label: "4-7", MinDays: 4, MaxDays: 7},
+	{Label: "8-30", MinDays: 8, MaxDays: 30},
+	{Label: "30+", MinDays: 31, MaxDays: -1},
+}
+
+// Contains reports whether an age in days falls in the bucket.
+func (b AgingBucket) Contains(days int) bool {
+	return days >= b.MinDays && (b.MaxDays < 0 || days <= b.MaxDays)
+}
+
+// Aging groups the unmatched transactions by how many days before AsOf, the
+// period end, they happened.
+type Aging struct {
+	AsOf   time.Time
+	System []AgingCount
+	// Banks are in the order of ReconciliationSummary.BankNames and only list
+	// banks with unmatched transactions.
+	Banks []BankAging
+}
+
+type BankAging struct {
+	BankName string
+	Buckets  []AgingCount
+}
+
+// AgingCount is the number of unmatched transactions in a bucket and their
+// absolute amounts added up.
+type AgingCount struct {
+	Bucket string
+	Count  int
+	Amount decimal.Decimal
+}
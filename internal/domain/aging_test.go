@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgingBuckets(t *testing.T) {
+	for days := 0; days <= 400; days++ {
+		var labels []string
+		for _, bucket := range AgingBuckets {
+			if bucket.Contains(days) {
+				labels = append(labels, bucket.Label)
+			}
+		}
+		assert.Len(t, labels, 1, "%d days old falls in exactly one bucket", days)
+	}
+
+	tests := map[int]string{0: "0-3", 3: "0-3", 4: "4-7", 7: "4-7", 8: "8-30", 30: "8-30", 31: "30+"}
+	for days, label := range tests {
+		for _, bucket := range AgingBuckets {
+			if bucket.Contains(days) {
+				assert.Equal(t, label, bucket.Label, "%d days old", days)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tally counts transactions and adds up their absolute amounts.
+type Tally struct {
+	Count  int
+	Amount decimal.Decimal
+}
+
+func (t *Tally) Add(amount decimal.Decimal) {
+	t.Count++
+	t.Amount = t.Amount.Add(amount.Abs())
+}
+
+// BreakdownFigures are the figures of one direction within a breakdown.
+// Matched counts the pairs by their system amount.
+type BreakdownFigures struct {
+	System          Tally
+	Bank            Tally
+	Matched         Tally
+	UnmatchedSystem Tally
+	UnmatchedBank   Tally
+	Discrepancy     decimal.Decimal
+}
+
+// Breakdown splits the figures of a bank or a day by direction.
+type Breakdown struct {
+	Debit  BreakdownFigures
+	Credit BreakdownFigures
+}
+
+// Direction returns the figures of txType.
+func (b *Breakdown) Direction(txType TransactionType) *BreakdownFigures {
+	if txType == Debit {
+		return &b.Debit
+	}
+	return &b.Credit
+}
+
+// BankBreakdown holds the figures of a bank. Unmatched system transactions
+// have no bank and are left out.
+type BankBreakdown struct {
+	BankName string
+	Breakdown
+}
+
+// DayBreakdown holds the figures of a calendar day. Date is midnight UTC of
+// that day.
+type DayBreakdown struct {
+	Date time.Time
+	Breakdown
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakdown(t *testing.T) {
+	var breakdown Breakdown
+	breakdown.Direction(Debit).Bank.Add(decimal.RequireFromString("-250.50"))
+	breakdown.Direction(Debit).Bank.Add(decimal.RequireFromString("-49.50"))
+	breakdown.Direction(Credit).System.Add(decimal.RequireFromString("100"))
+
+	assert.Equal(t, 2, breakdown.Debit.Bank.Count)
+	assert.Equal(t, "300", breakdown.Debit.Bank.Amount.String(), "amounts are absolute")
+	assert.Equal(t, 1, breakdown.Credit.System.Count)
+	assert.Equal(t, 0, breakdown.Debit.System.Count)
+}
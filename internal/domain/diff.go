@@ -0,0 +1,48 @@
+package domain
+
+import "github.com/shopspring/decimal"
+
+// SummaryDiff is what changed between two reconciliations of the same
+// inputs, such as before and after a corrected statement arrived. Deltas are
+// the new value minus the old one.
+type SummaryDiff struct {
+	// NewlyMatched are pairs whose system transaction was not matched before.
+	NewlyMatched []MatchedPair
+	// NewlyUnmatchedSystem and NewlyUnmatchedBank are unmatched transactions
+	// that were matched before or did not exist.
+	NewlyUnmatchedSystem []SystemTransaction
+	NewlyUnmatchedBank   []BankTransaction
+	// ChangedPairings are system transactions matched in both runs, but to
+	// a different bank transaction.
+	ChangedPairings []PairingChange
+	// DiscrepancyChanges are pairs present in both runs whose amounts moved.
+	DiscrepancyChanges []DiscrepancyChange
+
+	TotalSystemTransactionsDelta     int
+	TotalBankTransactionsDelta       int
+	MatchedTransactionsDelta         int
+	UnmatchedSystemTransactionsDelta int
+	UnmatchedBankTransactionsDelta   int
+	AmountDiscrepancyDelta           decimal.Decimal
+}
+
+type PairingChange struct {
+	System         SystemTransaction
+	OldBank        BankTransaction
+	NewBank        BankTransaction
+	OldDiscrepancy decimal.Decimal
+	NewDiscrepancy decimal.Decimal
+}
+
+type DiscrepancyChange struct {
+	Pair           MatchedPair
+	OldDiscrepancy decimal.Decimal
+}
+
+// Empty reports whether the two runs had the same outcome.
+func (d *SummaryDiff) Empty() bool {
+	return len(d.NewlyMatched) == 0 && len(d.NewlyUnmatchedSystem) == 0 && len(d.NewlyUnmatchedBank) == 0 &&
+		len(d.ChangedPairings) == 0 && len(d.DiscrepancyChanges) == 0 &&
+		d.TotalSystemTransactionsDelta == 0 && d.TotalBankTransactionsDelta == 0 && d.MatchedTransactionsDelta == 0 &&
+		d.UnmatchedSystemTransactionsDelta == 0 && d.UnmatchedBankTransactionsDelta == 0 && d.AmountDiscrepancyDelta.IsZero()
+}
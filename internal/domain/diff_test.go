@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryDiff_Empty(t *testing.T) {
+	assert.True(t, (&SummaryDiff{}).Empty())
+
+	changes := map[string]SummaryDiff{
+		"newly matched":        {NewlyMatched: []MatchedPair{{}}},
+		"newly unmatched bank": {NewlyUnmatchedBank: []BankTransaction{{}}},
+		"changed pairing":      {ChangedPairings: []PairingChange{{}}},
+		"total delta":          {TotalBankTransactionsDelta: -1},
+		"discrepancy delta":    {AmountDiscrepancyDelta: decimal.RequireFromString("0.01")},
+	}
+	for name, diff := range changes {
+		assert.False(t, diff.Empty(), name)
+	}
+}
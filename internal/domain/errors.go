@@ -0,0 +1,53 @@
+package domain
+
+// SourceError ties a read failure to the input file or stream it came from.
+// Reader messages already name the file, so Error does not repeat it.
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// SourceErrors flattens an error, including errors.Join trees, into the
+// per-source failures it contains.
+func SourceErrors(err error) []*SourceError {
+	switch e := err.(type) {
+	case *SourceError:
+		return []*SourceError{e}
+	case interface{ Unwrap() []error }:
+		var failures []*SourceError
+		for _, inner := range e.Unwrap() {
+			failures = append(failures, SourceErrors(inner)...)
+		}
+		return failures
+	case interface{ Unwrap() error }:
+		return SourceErrors(e.Unwrap())
+	}
+	return nil
+}
+
+// OnlySourceErrors reports whether every failure in err is attributed to a
+// source, i.e. the remaining sources were read completely.
+func OnlySourceErrors(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *SourceError:
+		return true
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			if !OnlySourceErrors(inner) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
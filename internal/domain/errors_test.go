@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceErrors(t *testing.T) {
+	bca := &SourceError{Source: "bca.csv", Err: errors.New("bad header")}
+	bri := &SourceError{Source: "bri.csv", Err: errors.New("truncated")}
+	other := errors.New("database down")
+
+	tests := map[string]struct {
+		err     error
+		sources []*SourceError
+		only    bool
+	}{
+		"nil":            {err: nil},
+		"single":         {err: bca, sources: []*SourceError{bca}, only: true},
+		"joined":         {err: errors.Join(bca, bri), sources: []*SourceError{bca, bri}, only: true},
+		"wrapped":        {err: fmt.Errorf("reading: %w", bca), sources: []*SourceError{bca}},
+		"with a failure": {err: errors.Join(bca, other), sources: []*SourceError{bca}},
+		"other":          {err: other},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.sources, SourceErrors(tt.err))
+			assert.Equal(t, tt.only, OnlySourceErrors(tt.err))
+		})
+	}
+	assert.Equal(t, "bad header", bca.Error(), "the source is not repeated")
+}
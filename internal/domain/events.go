@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventMatched EventType = "matched"
+	// EventSuggestedMatch is sent for a system transaction left unmatched
+	// when a bank transaction of the same day and type remains, but differs
+	// by more than the matching threshold.
+	EventSuggestedMatch  EventType = "suggested_match"
+	EventUnmatchedSystem EventType = "unmatched_system"
+	EventUnmatchedBank   EventType = "unmatched_bank"
+	EventRunCompleted    EventType = "run_completed"
+)
+
+// Event is one outcome of a reconciliation. Which fields are set depends on
+// Type: Pair for matches and suggestions, one transaction for unmatched
+// events and Summary when the run completed.
+type Event struct {
+	Type EventType
+	// Scope is the scope of the run, see PeriodClose.
+	Scope             string `json:",omitempty"`
+	Time              time.Time
+	Pair              *MatchedPair           `json:",omitempty"`
+	SystemTransaction *SystemTransaction     `json:",omitempty"`
+	BankTransaction   *BankTransaction       `json:",omitempty"`
+	Summary           *ReconciliationSummary `json:",omitempty"`
+}
+
+// Observer receives the events of a reconciliation. It is called from the
+// matching loop, possibly from several runs at once, so it must be safe for
+// concurrent use and should not block for long. Observers keep their own
+// errors; a failing observer does not stop the run.
+type Observer interface {
+	OnEvent(ctx context.Context, event Event)
+}
+
+// Notify sends an event to observer, if any. Time is set when it is zero.
+func Notify(ctx context.Context, observer Observer, event Event) {
+	if observer == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	observer.OnEvent(ctx, event)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	events []Event
+}
+
+func (o *recordingObserver) OnEvent(ctx context.Context, event Event) {
+	o.events = append(o.events, event)
+}
+
+func TestNotify(t *testing.T) {
+	Notify(context.Background(), nil, Event{Type: EventMatched})
+
+	observer := &recordingObserver{}
+	at := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	Notify(context.Background(), observer, Event{Type: EventMatched})
+	Notify(context.Background(), observer, Event{Type: EventRunCompleted, Time: at})
+
+	require.Len(t, observer.events, 2)
+	assert.False(t, observer.events[0].Time.IsZero(), "time is set when missing")
+	assert.Equal(t, time.UTC, observer.events[0].Time.Location())
+	assert.Equal(t, at, observer.events[1].Time)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "sync"
+
+// FingerprintLog collects the content fingerprints of input sources from
+// readers running concurrently. A nil log drops them.
+type FingerprintLog struct {
+	mu   sync.Mutex
+	sums map[string]string
+}
+
+func (l *FingerprintLog) Add(source, fingerprint string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sums == nil {
+		l.sums = make(map[string]string)
+	}
+	l.sums[source] = fingerprint
+}
+
+// Fingerprints returns the fingerprints by source.
+func (l *FingerprintLog) Fingerprints() map[string]string {
+	if l == nil {
+		return map[string]string{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sums := make(map[string]string, len(l.sums))
+	for source, fingerprint := range l.sums {
+		sums[source] = fingerprint
+	}
+	return sums
+}
+
+// Reset forgets the fingerprints collected so far.
+func (l *FingerprintLog) Reset() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sums = nil
+}
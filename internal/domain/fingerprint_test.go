@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprintLog(t *testing.T) {
+	log := &FingerprintLog{}
+	assert.Empty(t, log.Fingerprints())
+
+	log.Add("bca.csv", "abc")
+	log.Add("bri.csv", "def")
+	log.Add("bca.csv", "123")
+
+	fingerprints := log.Fingerprints()
+	assert.Equal(t, map[string]string{"bca.csv": "123", "bri.csv": "def"}, fingerprints, "the last read of a source wins")
+	fingerprints["bca.csv"] = "changed"
+	assert.Equal(t, "123", log.Fingerprints()["bca.csv"], "callers get a copy")
+
+	log.Reset()
+	assert.Empty(t, log.Fingerprints())
+
+	var none *FingerprintLog
+	none.Add("ignored.csv", "000")
+	assert.Empty(t, none.Fingerprints())
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPeriodClosed is returned for runs over a period that has been closed
+// and not reopened.
+var ErrPeriodClosed = errors.New("period is closed")
+
+// PeriodClose is the signed-off result of reconciling a period. It keeps
+// what the sign-off was based on so the result can be reprinted and the
+// inputs checked later.
+type PeriodClose struct {
+	// Scope names the books that were reconciled, such as a batch job; periods
+	// are closed per scope.
+	Scope      string
+	Period     Period
+	Inputs     []InputDigest
+	Summary    *ReconciliationSummary
+	Overrides  []Override
+	ApprovedBy string
+	ClosedAt   time.Time
+	// Reopened is set on records that were reopened; they are kept for the
+	// audit trail but no longer lock the period.
+	Reopened *PeriodReopen `json:",omitempty"`
+}
+
+// Overlaps reports whether the record's period shares a day with period.
+func (c *PeriodClose) Overlaps(period Period) bool {
+	return !c.Period.End.Before(period.Start) && !period.End.Before(c.Period.Start)
+}
+
+// InputDigest is the SHA-256 of an input file, hex encoded.
+type InputDigest struct {
+	Path   string
+	SHA256 string
+}
+
+// Override is a manual decision recorded at sign-off. With both IDs set it
+// pairs a system and a bank transaction the engine left unmatched; with one
+// of them set it accepts that transaction as unmatched.
+type Override struct {
+	SystemTransactionID string
+	BankTransactionID   string
+	Note                string
+}
+
+type PeriodReopen struct {
+	ReopenedBy string
+	Reason     string
+	ReopenedAt time.Time
+}
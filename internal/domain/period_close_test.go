@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodClose_Overlaps(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+	june := &PeriodClose{Period: Period{Start: day(6, 1), End: day(6, 30)}}
+
+	tests := map[string]struct {
+		period Period
+		want   bool
+	}{
+		"same":           {Period{Start: day(6, 1), End: day(6, 30)}, true},
+		"inside":         {Period{Start: day(6, 15), End: day(6, 20)}, true},
+		"last day":       {Period{Start: day(6, 30), End: day(7, 31)}, true},
+		"first day":      {Period{Start: day(5, 1), End: day(6, 1)}, true},
+		"around":         {Period{Start: day(5, 1), End: day(7, 31)}, true},
+		"next month":     {Period{Start: day(7, 1), End: day(7, 31)}, false},
+		"previous month": {Period{Start: day(5, 1), End: day(5, 31)}, false},
+	}
+	for name, tt := range tests {
+		assert.Equal(t, tt.want, june.Overlaps(tt.period), name)
+	}
+}
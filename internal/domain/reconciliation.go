@@ -1,25 +1,159 @@
 package domain
 
 import (
+	"context"
+	"io"
+	"iter"
 	"time"
 
 	"github.com/shopspring/decimal"
 )
 
 type ReconciliationSummary struct {
-	TotalSystemTransactions     int
-	TotalBankTransactions       int
-	MatchedTransactions         int
+	TotalSystemTransactions int
+	TotalBankTransactions   int
+	MatchedTransactions     int
+	// MatchedPairs lists what each matched system transaction was paired with,
+	// in system transaction order.
+	MatchedPairs                []MatchedPair
 	UnmatchedSystemTransactions []SystemTransaction
 	UnmatchedBankTransactions   map[string][]BankTransaction
 	AmountDiscrepancyTotal      decimal.Decimal
 	ProcessingDurationSeconds   float64
+
+	// BankNames lists every bank in the order its transactions were loaded;
+	// use it to iterate UnmatchedBankTransactions deterministically.
+	BankNames []string
+
+	// UnreconciledSources lists bank inputs that could not be read when the
+	// run was allowed to continue without them; their transactions are missing
+	// from every total above.
+	UnreconciledSources []UnreconciledSource
+
+	// RejectedRows lists input rows that were skipped because they could not
+	// be read as transactions, such as unknown transaction types.
+	RejectedRows []RejectedRow
+
+	// DuplicateStatements and DuplicateBankTransactions list the bank input
+	// that was left out because an earlier statement already contained it.
+	DuplicateStatements       []DuplicateStatement
+	DuplicateBankTransactions []DuplicateBankTransaction
+
+	// BalanceGaps lists where the running balance of a bank account does not
+	// add up, which usually means statement rows are missing.
+	BalanceGaps []BalanceGap
+
+	// BankBreakdowns and DayBreakdowns add up the run by bank, in BankNames
+	// order, and by calendar day, in date order. A system transaction only
+	// counts towards a bank once matched to one of its transactions.
+	BankBreakdowns []BankBreakdown
+	DayBreakdowns  []DayBreakdown
+
+	// Aging ages the unmatched transactions at the period end. It is nil for
+	// summaries of the engine alone, which does not know the period.
+	Aging *Aging
+}
+
+// UnmatchedBankCount is the number of unmatched bank transactions of all
+// banks together.
+func (s *ReconciliationSummary) UnmatchedBankCount() int {
+	count := 0
+	for _, txs := range s.UnmatchedBankTransactions {
+		count += len(txs)
+	}
+	return count
+}
+
+// MatchedPair is a system transaction and the bank transaction it matched.
+// Discrepancy is the absolute difference of their amounts.
+type MatchedPair struct {
+	System      SystemTransaction
+	Bank        BankTransaction
+	Discrepancy decimal.Decimal
+}
+
+// Period is the span of a reconciliation. Both dates are calendar days and
+// End is included.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+type UnreconciledSource struct {
+	Source string
+	Reason string
+}
+
+// DeclaredBalance is the known opening and closing balance of a bank account
+// for the reconciliation period.
+type DeclaredBalance struct {
+	BankName      string
+	AccountNumber string
+	Opening       decimal.NullDecimal
+	Closing       decimal.NullDecimal
+}
+
+// BalanceGap is a balance that does not equal the previous balance plus the
+// transactions in between. Expected is that computed balance and Actual the
+// one stated by the statement row TransactionID, or the declared closing
+// balance when TransactionID is empty.
+type BalanceGap struct {
+	BankName      string
+	AccountNumber string
+	Source        string
+	TransactionID string
+	Expected      decimal.Decimal
+	Actual        decimal.Decimal
+}
+
+// DuplicateStatement is a statement whose file content is identical to that
+// of an earlier one, such as the same download saved twice. Fingerprint is
+// the SHA-256 of the content, after decompression.
+type DuplicateStatement struct {
+	Source       string
+	DuplicateOf  string
+	Fingerprint  string
+	Transactions int
+}
+
+// DuplicateBankTransaction is a transaction dropped because an earlier
+// statement of the same account, DuplicateOf, has the same transaction.
+type DuplicateBankTransaction struct {
+	Transaction BankTransaction
+	DuplicateOf string
 }
 
 type TransactionDataReader interface {
-	ReadSystemTransactions(filePath string, startDate, endDate time.Time) ([]SystemTransaction, error)
+	ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]SystemTransaction, error)
 }
 
 type BankStatementReader interface {
-	ReadBankTransactions(filePaths []string, startDate, endDate time.Time) ([]BankTransaction, error)
+	ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]BankTransaction, error)
+}
+
+// TransactionDataIterator and BankStatementIterator yield transactions one at
+// a time instead of loading whole files. Failures are yielded as errors; a
+// consumer that keeps iterating after a bank statement error continues with
+// the next file.
+type TransactionDataIterator interface {
+	SystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) iter.Seq2[SystemTransaction, error]
+}
+
+type BankStatementIterator interface {
+	BankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) iter.Seq2[BankTransaction, error]
+}
+
+// InputSource is an already opened stream of transactions. Name identifies it in
+// errors and is used as the bank name for statements.
+type InputSource struct {
+	Name   string
+	Reader io.Reader
+}
+
+type TransactionStreamReader interface {
+	ReadSystemTransactionsFrom(ctx context.Context, source InputSource, startDate, endDate time.Time) ([]SystemTransaction, error)
+}
+
+type BankStatementStreamReader interface {
+	ReadBankTransactionsFrom(ctx context.Context, sources []InputSource, startDate, endDate time.Time) ([]BankTransaction, error)
 }
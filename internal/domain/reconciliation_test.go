@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconciliationSummary_UnmatchedBankCount(t *testing.T) {
+	assert.Equal(t, 0, (&ReconciliationSummary{}).UnmatchedBankCount())
+
+	summary := &ReconciliationSummary{
+		UnmatchedSystemTransactions: []SystemTransaction{{ID: "S1"}},
+		UnmatchedBankTransactions: map[string][]BankTransaction{
+			"BCA":  {{ID: "B1"}, {ID: "B2"}},
+			"BRI":  {{ID: "B3"}},
+			"HSBC": {},
+		},
+	}
+	assert.Equal(t, 3, summary.UnmatchedBankCount(), "system transactions are not counted")
+}
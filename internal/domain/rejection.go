@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"sort"
+	"sync"
+)
+
+// RejectedRow is an input row that was skipped because it could not be read
+// as a transaction. Line is 1-based and counts the header.
+type RejectedRow struct {
+	Source string
+	Line   int
+	Reason string
+}
+
+// RejectionLog collects rejected rows from readers running concurrently. A
+// nil log drops them.
+type RejectionLog struct {
+	mu   sync.Mutex
+	rows []RejectedRow
+}
+
+func (l *RejectionLog) Add(row RejectedRow) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rows = append(l.rows, row)
+}
+
+// Rows returns the rejected rows ordered by source and line.
+func (l *RejectionLog) Rows() []RejectedRow {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rows := append([]RejectedRow(nil), l.rows...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Source != rows[j].Source {
+			return rows[i].Source < rows[j].Source
+		}
+		return rows[i].Line < rows[j].Line
+	})
+	return rows
+}
+
+// Reset forgets the rows collected so far.
+func (l *RejectionLog) Reset() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rows = nil
+}
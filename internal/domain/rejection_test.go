@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectionLog(t *testing.T) {
+	log := &RejectionLog{}
+	log.Add(RejectedRow{Source: "b.csv", Line: 2})
+	log.Add(RejectedRow{Source: "a.csv", Line: 9})
+	log.Add(RejectedRow{Source: "a.csv", Line: 3})
+	assert.Equal(t, []RejectedRow{{Source: "a.csv", Line: 3}, {Source: "a.csv", Line: 9}, {Source: "b.csv", Line: 2}}, log.Rows())
+
+	log.Reset()
+	assert.Empty(t, log.Rows())
+
+	var none *RejectionLog
+	none.Add(RejectedRow{Source: "ignored.csv", Line: 1})
+	assert.Nil(t, none.Rows())
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -13,6 +15,41 @@ const (
 	Credit TransactionType = "CREDIT"
 )
 
+// Valid reports whether t is one of the known transaction types.
+func (t TransactionType) Valid() bool {
+	return t == Debit || t == Credit
+}
+
+// TransactionTypeAliases maps spellings used by ledgers to known types. Keys
+// are compared case-insensitively.
+type TransactionTypeAliases map[string]TransactionType
+
+var DefaultTransactionTypeAliases = TransactionTypeAliases{
+	"D":  Debit,
+	"DR": Debit,
+	"DB": Debit,
+	"C":  Credit,
+	"CR": Credit,
+}
+
+// Normalize resolves value to a known type. The type names themselves are
+// always accepted; anything else must be an alias.
+func (a TransactionTypeAliases) Normalize(value string) (TransactionType, error) {
+	key := strings.ToUpper(strings.TrimSpace(value))
+	if txType := TransactionType(key); txType.Valid() {
+		return txType, nil
+	}
+	if txType, ok := a[key]; ok {
+		return txType, nil
+	}
+	for alias, txType := range a {
+		if strings.EqualFold(alias, key) {
+			return txType, nil
+		}
+	}
+	return "", fmt.Errorf("unknown transaction type '%s'", value)
+}
+
 type SystemTransaction struct {
 	ID              string
 	Amount          decimal.Decimal
@@ -21,8 +58,14 @@ type SystemTransaction struct {
 }
 
 type BankTransaction struct {
-	ID       string
-	Amount   decimal.Decimal
-	Date     time.Time
-	BankName string
+	ID            string
+	Amount        decimal.Decimal
+	Date          time.Time
+	BankName      string
+	AccountNumber string
+	// Source is the statement file the transaction was read from.
+	Source string
+	// Balance is the account balance after the transaction, for statements
+	// with a balance column.
+	Balance decimal.NullDecimal
 }
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionTypeAliases_Normalize(t *testing.T) {
+	tests := map[string]struct {
+		aliases TransactionTypeAliases
+		value   string
+		want    TransactionType
+		err     string
+	}{
+		"type name":          {aliases: DefaultTransactionTypeAliases, value: "credit", want: Credit},
+		"padded":             {aliases: DefaultTransactionTypeAliases, value: " DEBIT ", want: Debit},
+		"default alias":      {aliases: DefaultTransactionTypeAliases, value: "dr", want: Debit},
+		"custom alias":       {aliases: TransactionTypeAliases{"Masuk": Credit}, value: "MASUK", want: Credit},
+		"names without map":  {value: "Debit", want: Debit},
+		"unknown":            {aliases: DefaultTransactionTypeAliases, value: "X", err: "unknown transaction type 'X'"},
+		"alias of other map": {aliases: TransactionTypeAliases{"K": Credit}, value: "CR", err: "unknown transaction type 'CR'"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.aliases.Normalize(tt.value)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
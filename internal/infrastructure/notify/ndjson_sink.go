@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// NDJSONSink writes every event as one line of JSON.
+type NDJSONSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+	err     error
+}
+
+func NewNDJSONSink(w io.Writer) *NDJSONSink {
+	return &NDJSONSink{encoder: json.NewEncoder(w)}
+}
+
+// OpenNDJSONFile appends events to the file at filePath, creating it if
+// needed.
+func OpenNDJSONFile(filePath string) (*NDJSONSink, error) {
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open event file '%s': %w", filePath, err)
+	}
+	sink := NewNDJSONSink(file)
+	sink.closer = file
+	return sink, nil
+}
+
+// OnEvent implements domain.Observer. After the first write error further
+// events are dropped; Close reports the error.
+func (s *NDJSONSink) OnEvent(_ context.Context, event domain.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	if err := s.encoder.Encode(event); err != nil {
+		s.err = fmt.Errorf("could not write event: %w", err)
+	}
+}
+
+// Close closes the file opened by OpenNDJSONFile and returns the first error
+// the sink ran into.
+func (s *NDJSONSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closer != nil {
+		if err := s.closer.Close(); err != nil && s.err == nil {
+			s.err = fmt.Errorf("could not close event file: %w", err)
+		}
+		s.closer = nil
+	}
+	return s.err
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matchedEvent(id string) domain.Event {
+	return domain.Event{Type: domain.EventMatched, Pair: &domain.MatchedPair{
+		System:      domain.SystemTransaction{ID: id, Amount: decimal.RequireFromString("100"), Type: domain.Credit},
+		Bank:        domain.BankTransaction{ID: "B-" + id, Amount: decimal.RequireFromString("99"), BankName: "BCA"},
+		Discrepancy: decimal.RequireFromString("1"),
+	}}
+}
+
+func TestNDJSONSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewNDJSONSink(&buf)
+	sink.OnEvent(context.Background(), matchedEvent("S1"))
+	sink.OnEvent(context.Background(), domain.Event{Type: domain.EventUnmatchedBank, BankTransaction: &domain.BankTransaction{ID: "B9"}})
+	require.NoError(t, sink.Close())
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "matched", lines[0]["Type"])
+	assert.Equal(t, "1", lines[0]["Pair"].(map[string]any)["Discrepancy"])
+	assert.NotContains(t, lines[0], "BankTransaction")
+	assert.Equal(t, "unmatched_bank", lines[1]["Type"])
+}
+
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("disk full")
+}
+
+func TestNDJSONSink_Errors(t *testing.T) {
+	writer := &failingWriter{}
+	sink := NewNDJSONSink(writer)
+	sink.OnEvent(context.Background(), matchedEvent("S1"))
+	sink.OnEvent(context.Background(), matchedEvent("S2"))
+	assert.ErrorContains(t, sink.Close(), "disk full")
+	assert.Equal(t, 1, writer.writes, "events after an error are dropped")
+}
+
+func TestOpenNDJSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	for _, id := range []string{"S1", "S2"} {
+		sink, err := OpenNDJSONFile(path)
+		require.NoError(t, err)
+		sink.OnEvent(context.Background(), matchedEvent(id))
+		require.NoError(t, sink.Close())
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte("\n")), "runs append to the file")
+
+	_, err = OpenNDJSONFile(filepath.Join(t.TempDir(), "missing", "events.ndjson"))
+	assert.ErrorContains(t, err, "could not open event file")
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+var eventTypes = []domain.EventType{
+	domain.EventMatched,
+	domain.EventSuggestedMatch,
+	domain.EventUnmatchedSystem,
+	domain.EventUnmatchedBank,
+	domain.EventRunCompleted,
+}
+
+// Observers sends every event to each observer in turn.
+type Observers []domain.Observer
+
+func (o Observers) OnEvent(ctx context.Context, event domain.Event) {
+	for _, observer := range o {
+		observer.OnEvent(ctx, event)
+	}
+}
+
+// Filter passes only events of the given types on to observer. Without types
+// every event is passed.
+func Filter(observer domain.Observer, types ...domain.EventType) domain.Observer {
+	if len(types) == 0 {
+		return observer
+	}
+	allowed := make(map[domain.EventType]bool, len(types))
+	for _, eventType := range types {
+		allowed[eventType] = true
+	}
+	return filtered{observer: observer, allowed: allowed}
+}
+
+type filtered struct {
+	observer domain.Observer
+	allowed  map[domain.EventType]bool
+}
+
+func (f filtered) OnEvent(ctx context.Context, event domain.Event) {
+	if f.allowed[event.Type] {
+		f.observer.OnEvent(ctx, event)
+	}
+}
+
+// ParseEventTypes reads event type names such as "matched,unmatched_bank".
+func ParseEventTypes(names []string) ([]domain.EventType, error) {
+	var types []domain.EventType
+	for _, name := range names {
+		eventType := domain.EventType(strings.ToLower(strings.TrimSpace(name)))
+		if !validEventType(eventType) {
+			return nil, fmt.Errorf("unknown event type '%s'", name)
+		}
+		types = append(types, eventType)
+	}
+	return types, nil
+}
+
+func validEventType(eventType domain.EventType) bool {
+	for _, known := range eventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	types []domain.EventType
+}
+
+func (r *recorder) OnEvent(_ context.Context, event domain.Event) {
+	r.types = append(r.types, event.Type)
+}
+
+func TestObservers(t *testing.T) {
+	all, fees := &recorder{}, &recorder{}
+	observer := Observers{all, Filter(fees, domain.EventUnmatchedBank)}
+	for _, eventType := range []domain.EventType{domain.EventMatched, domain.EventUnmatchedBank, domain.EventRunCompleted} {
+		observer.OnEvent(context.Background(), domain.Event{Type: eventType})
+	}
+	assert.Equal(t, []domain.EventType{domain.EventMatched, domain.EventUnmatchedBank, domain.EventRunCompleted}, all.types)
+	assert.Equal(t, []domain.EventType{domain.EventUnmatchedBank}, fees.types)
+	assert.Same(t, all, Filter(all), "no types means no filter")
+}
+
+func TestParseEventTypes(t *testing.T) {
+	types, err := ParseEventTypes([]string{"matched", " Unmatched_Bank "})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.EventType{domain.EventMatched, domain.EventUnmatchedBank}, types)
+
+	_, err = ParseEventTypes([]string{"fee"})
+	assert.EqualError(t, err, "unknown event type 'fee'")
+}
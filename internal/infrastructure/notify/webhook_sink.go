@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// DefaultWebhookTimeout bounds each delivery when no client is given.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// DefaultWebhookQueueSize is how many events wait for delivery before
+// further events are dropped.
+const DefaultWebhookQueueSize = 10000
+
+// maxWebhookBatch caps how many events are POSTed at once.
+const maxWebhookBatch = 100
+
+// maxWebhookErrors caps how many delivery failures are kept for Close.
+const maxWebhookErrors = 10
+
+// WebhookSink POSTs events as JSON arrays to a URL. Events are queued and
+// delivered by a worker in batches of whatever is waiting, so that OnEvent
+// never waits for the network; when the queue is full, events are dropped.
+// Deliveries are not retried; a response outside 2xx counts as a failure.
+type WebhookSink struct {
+	url    string
+	client *http.Client
+	queue  chan json.RawMessage
+	done   chan struct{}
+
+	mu       sync.Mutex
+	closed   bool
+	failures int
+	dropped  int
+	errs     []error
+}
+
+// NewWebhookSink sends events to url with client, or with a client using
+// DefaultWebhookTimeout when client is nil. Close must be called to deliver
+// the queued events.
+func NewWebhookSink(url string, client *http.Client) *WebhookSink {
+	return newWebhookSink(url, client, DefaultWebhookQueueSize)
+}
+
+func newWebhookSink(url string, client *http.Client, queueSize int) *WebhookSink {
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	s := &WebhookSink{url: url, client: client, queue: make(chan json.RawMessage, queueSize), done: make(chan struct{})}
+	go s.run()
+	return s
+}
+
+// OnEvent implements domain.Observer. Events are encoded right away, as the
+// run may change what they point to, and queued for delivery. Failures are
+// kept for Close and do not stop the run.
+func (s *WebhookSink) OnEvent(ctx context.Context, event domain.Event) {
+	body, err := json.Marshal(event)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case err != nil:
+		s.fail(1, fmt.Errorf("could not encode %s event: %w", event.Type, err))
+	case s.closed:
+		s.fail(1, fmt.Errorf("%s event arrived after the webhook was closed", event.Type))
+	default:
+		select {
+		case s.queue <- body:
+		default:
+			s.dropped++
+		}
+	}
+}
+
+// run delivers the queue until Close.
+func (s *WebhookSink) run() {
+	defer close(s.done)
+	for body := range s.queue {
+		batch := []json.RawMessage{body}
+	fill:
+		for len(batch) < maxWebhookBatch {
+			select {
+			case body, ok := <-s.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, body)
+			default:
+				break fill
+			}
+		}
+		if err := s.deliver(batch); err != nil {
+			s.mu.Lock()
+			s.fail(len(batch), err)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// fail records that events were not delivered; s.mu must be held.
+func (s *WebhookSink) fail(events int, err error) {
+	s.failures += events
+	if len(s.errs) < maxWebhookErrors {
+		s.errs = append(s.errs, err)
+	}
+}
+
+func (s *WebhookSink) deliver(batch []json.RawMessage) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("could not encode %d events: %w", len(batch), err)
+	}
+	// Deliveries are not tied to the run, so events still go out while a
+	// cancelled run winds down.
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook url '%s': %w", s.url, err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("could not deliver %d events: %w", len(batch), err)
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook rejected %d events with status %s", len(batch), response.Status)
+	}
+	return nil
+}
+
+// Close delivers the queued events and reports the events that were not
+// delivered, if any. Events arriving afterwards count as failures.
+func (s *WebhookSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := append([]error{}, s.errs...)
+	if s.dropped > 0 {
+		errs = append(errs, fmt.Errorf("%d events dropped while the webhook queue was full", s.dropped))
+	}
+	if s.failures+s.dropped == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d webhook events were not delivered: %w", s.failures+s.dropped, errors.Join(errs...))
+}
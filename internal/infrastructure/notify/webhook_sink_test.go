@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var mu sync.Mutex
+	var received []domain.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var batch []domain.Event
+		require.NoError(t, json.Unmarshal(body, &batch))
+		assert.LessOrEqual(t, len(batch), maxWebhookBatch)
+
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, batch...)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, server.Client())
+	for _, id := range []string{"S1", "S2", "S3"} {
+		sink.OnEvent(context.Background(), matchedEvent(id))
+	}
+	summary := &domain.ReconciliationSummary{MatchedTransactions: 1}
+	sink.OnEvent(context.Background(), domain.Event{Type: domain.EventRunCompleted, Scope: "BCA", Summary: summary})
+	summary.MatchedTransactions = 2
+	require.NoError(t, sink.Close())
+
+	require.Len(t, received, 4)
+	assert.Equal(t, "S1", received[0].Pair.System.ID)
+	assert.Equal(t, "1", received[0].Pair.Discrepancy.String())
+	assert.Equal(t, "S3", received[2].Pair.System.ID)
+	assert.Equal(t, domain.EventRunCompleted, received[3].Type)
+	assert.Equal(t, "BCA", received[3].Scope)
+	assert.Equal(t, 1, received[3].Summary.MatchedTransactions, "events are sent as they were when they happened")
+}
+
+func TestWebhookSink_Failures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, server.Client())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sink.OnEvent(ctx, matchedEvent("S1"))
+	sink.OnEvent(ctx, matchedEvent("S2"))
+
+	err := sink.Close()
+	assert.ErrorContains(t, err, "2 webhook events were not delivered")
+	assert.ErrorContains(t, err, "503 Service Unavailable", "cancelled runs still deliver")
+
+	sink.OnEvent(context.Background(), matchedEvent("S3"))
+	assert.ErrorContains(t, sink.Close(), "3 webhook events were not delivered", "events after Close are failures")
+
+	unreachable := NewWebhookSink("http://127.0.0.1:1", nil)
+	unreachable.OnEvent(context.Background(), matchedEvent("S1"))
+	assert.ErrorContains(t, unreachable.Close(), "could not deliver 1 events")
+}
+
+func TestWebhookSink_FullQueue(t *testing.T) {
+	release := make(chan struct{})
+	arrived := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-release
+	}))
+	defer server.Close()
+
+	sink := newWebhookSink(server.URL, server.Client(), 2)
+	sink.OnEvent(context.Background(), matchedEvent("S1"))
+	<-arrived // S1 is being delivered and no longer queued.
+	for _, id := range []string{"S2", "S3", "S4", "S5"} {
+		sink.OnEvent(context.Background(), matchedEvent(id))
+	}
+	close(release)
+
+	err := sink.Close()
+	assert.ErrorContains(t, err, "2 webhook events were not delivered")
+	assert.ErrorContains(t, err, "2 events dropped while the webhook queue was full")
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"io"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+)
+
+// JSONBatchReport is the JSON report of a manifest run. Jobs are in manifest
+// order.
+type JSONBatchReport struct {
+	SchemaVersion string          `json:"schema_version"`
+	GeneratedAt   string          `json:"generated_at"`
+	Jobs          []JSONBatchJob  `json:"jobs"`
+	Totals        JSONBatchTotals `json:"totals"`
+}
+
+type JSONBatchJob struct {
+	Name            string      `json:"name"`
+	Status          string      `json:"status"`
+	Error           string      `json:"error,omitempty"`
+	DurationSeconds float64     `json:"duration_seconds"`
+	Report          *JSONReport `json:"report,omitempty"`
+}
+
+type JSONBatchTotals struct {
+	Jobs               int    `json:"jobs"`
+	Succeeded          int    `json:"succeeded"`
+	Failed             int    `json:"failed"`
+	SystemTransactions int    `json:"system_transactions"`
+	BankTransactions   int    `json:"bank_transactions"`
+	Matched            int    `json:"matched"`
+	UnmatchedSystem    int    `json:"unmatched_system"`
+	UnmatchedBank      int    `json:"unmatched_bank"`
+	AmountDiscrepancy  string `json:"amount_discrepancy"`
+}
+
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+func NewJSONBatchReport(result *usecase.BatchResult, generatedAt time.Time) *JSONBatchReport {
+	overview := result.Overview
+	report := &JSONBatchReport{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   generatedAt.Format(time.RFC3339),
+		Jobs:          make([]JSONBatchJob, 0, len(result.Jobs)),
+		Totals: JSONBatchTotals{
+			Jobs:               overview.Jobs,
+			Succeeded:          overview.Succeeded,
+			Failed:             overview.Failed,
+			SystemTransactions: overview.TotalSystemTransactions,
+			BankTransactions:   overview.TotalBankTransactions,
+			Matched:            overview.MatchedTransactions,
+			UnmatchedSystem:    overview.UnmatchedSystemTransactions,
+			UnmatchedBank:      overview.UnmatchedBankTransactions,
+			AmountDiscrepancy:  overview.AmountDiscrepancyTotal.String(),
+		},
+	}
+	for _, job := range result.Jobs {
+		entry := JSONBatchJob{Name: job.Name, Status: StatusOK, DurationSeconds: job.Duration.Seconds()}
+		if job.Err != nil {
+			entry.Status = StatusFailed
+			entry.Error = job.Err.Error()
+		} else {
+			request := job.Response.Request
+			entry.Report = NewJSONReport(job.Response.Summary, Metadata{Scope: request.Scope, Period: request.Period, GeneratedAt: generatedAt})
+		}
+		report.Jobs = append(report.Jobs, entry)
+	}
+	return report
+}
+
+// WriteBatchJSON writes the report of a manifest run as indented JSON.
+func WriteBatchJSON(w io.Writer, result *usecase.BatchResult, generatedAt time.Time) error {
+	return writeJSON(w, NewJSONBatchReport(result, generatedAt))
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONBatchReport(t *testing.T) {
+	result := &usecase.BatchResult{
+		Jobs: []usecase.BatchJobResult{
+			{Name: "BCA", Response: &usecase.ReconciliationResponse{Request: usecase.ReconciliationRequest{Scope: "BCA"}, Summary: testSummary()}, Duration: 2 * time.Second},
+			{Name: "BRI", Err: errors.New("no statement files")},
+		},
+		Overview: usecase.BatchOverview{Jobs: 2, Succeeded: 1, Failed: 1, MatchedTransactions: 1, AmountDiscrepancyTotal: decimal.RequireFromString("500.50")},
+	}
+
+	report := NewJSONBatchReport(result, time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC))
+	require.Len(t, report.Jobs, 2)
+	assert.Equal(t, StatusOK, report.Jobs[0].Status)
+	assert.Equal(t, 2.0, report.Jobs[0].DurationSeconds)
+	assert.Equal(t, "BCA", report.Jobs[0].Report.Scope)
+	assert.Equal(t, StatusFailed, report.Jobs[1].Status)
+	assert.Equal(t, "no statement files", report.Jobs[1].Error)
+	assert.Nil(t, report.Jobs[1].Report)
+	assert.Equal(t, JSONBatchTotals{Jobs: 2, Succeeded: 1, Failed: 1, Matched: 1, AmountDiscrepancy: "500.5"}, report.Totals)
+}
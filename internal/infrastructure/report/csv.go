@@ -0,0 +1,155 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// File names written by ExportCSV.
+const (
+	MatchedCSV         = "matched.csv"
+	UnmatchedSystemCSV = "unmatched_system.csv"
+	UnmatchedBankCSV   = "unmatched_bank.csv"
+	AgingCSV           = "aging.csv"
+)
+
+// CSVHeader is shared by the files of matched and unmatched transactions, so
+// that a reviewed file can be
+// read back with repository.LoadOverrides. Columns a row has no value for,
+// such as the bank side of an unmatched system transaction, are left empty,
+// and note is left for the reviewer. Text cells are escaped with csvText.
+var CSVHeader = []string{
+	"system_id", "bank_id", "bank", "account_number", "date", "type",
+	"system_amount", "bank_amount", "difference", "note",
+}
+
+// ExportCSV writes the matched pairs, the unmatched system transactions, the
+// unmatched bank transactions and their aging to separate files in dir,
+// creating it when needed. It returns the paths written.
+func ExportCSV(dir string, summary *domain.ReconciliationSummary) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create export directory '%s': %w", dir, err)
+	}
+	files := []struct {
+		name  string
+		write func(io.Writer, *domain.ReconciliationSummary) error
+	}{
+		{MatchedCSV, WriteMatchedCSV},
+		{UnmatchedSystemCSV, WriteUnmatchedSystemCSV},
+		{UnmatchedBankCSV, WriteUnmatchedBankCSV},
+		{AgingCSV, WriteAgingCSV},
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := writeCSVFile(path, summary, file.write); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeCSVFile(path string, summary *domain.ReconciliationSummary, write func(io.Writer, *domain.ReconciliationSummary) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create '%s': %w", path, err)
+	}
+	if err := write(file, summary); err != nil {
+		file.Close()
+		return fmt.Errorf("could not write '%s': %w", path, err)
+	}
+	return file.Close()
+}
+
+// WriteMatchedCSV writes one row per matched pair, dated and typed after the
+// system transaction.
+func WriteMatchedCSV(w io.Writer, summary *domain.ReconciliationSummary) error {
+	return writeCSV(w, func(writer *csv.Writer) {
+		for _, pair := range summary.MatchedPairs {
+			writer.Write([]string{
+				csvText(pair.System.ID), csvText(pair.Bank.ID), csvText(pair.Bank.BankName), csvText(pair.Bank.AccountNumber),
+				pair.System.TransactionTime.Format("2006-01-02"), string(pair.System.Type),
+				pair.System.Amount.String(), pair.Bank.Amount.String(), pair.Discrepancy.String(), "",
+			})
+		}
+	})
+}
+
+func WriteUnmatchedSystemCSV(w io.Writer, summary *domain.ReconciliationSummary) error {
+	return writeCSV(w, func(writer *csv.Writer) {
+		for _, tx := range summary.UnmatchedSystemTransactions {
+			writer.Write([]string{
+				csvText(tx.ID), "", "", "",
+				tx.TransactionTime.Format("2006-01-02"), string(tx.Type),
+				tx.Amount.String(), "", "", "",
+			})
+		}
+	})
+}
+
+// WriteUnmatchedBankCSV writes the unmatched bank transactions bank by bank,
+// typed by the sign of their amount.
+func WriteUnmatchedBankCSV(w io.Writer, summary *domain.ReconciliationSummary) error {
+	return writeCSV(w, func(writer *csv.Writer) {
+		for _, bank := range summary.BankNames {
+			for _, tx := range summary.UnmatchedBankTransactions[bank] {
+				txType := domain.Credit
+				if tx.Amount.IsNegative() {
+					txType = domain.Debit
+				}
+				writer.Write([]string{
+					"", csvText(tx.ID), csvText(tx.BankName), csvText(tx.AccountNumber),
+					tx.Date.Format("2006-01-02"), string(txType),
+					"", tx.Amount.String(), "", "",
+				})
+			}
+		}
+	})
+}
+
+// WriteAgingCSV writes one row per aging bucket of the unmatched system
+// transactions and of every bank with unmatched transactions. It is not an
+// overrides file and has columns of its own.
+func WriteAgingCSV(w io.Writer, summary *domain.ReconciliationSummary) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"side", "bank", "bucket", "count", "amount"})
+	if aging := summary.Aging; aging != nil {
+		for _, count := range aging.System {
+			writer.Write([]string{"system", "", count.Bucket, strconv.Itoa(count.Count), count.Amount.String()})
+		}
+		for _, bank := range aging.Banks {
+			for _, count := range bank.Buckets {
+				writer.Write([]string{"bank", csvText(bank.BankName), count.Bucket, strconv.Itoa(count.Count), count.Amount.String()})
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText keeps spreadsheets from running a text cell, such as an ID taken
+// from a statement, as a formula: values starting with =, +, -, @, a tab or a
+// carriage return get a leading apostrophe, which repository.ReadOverrides
+// removes again. Amounts are written as they are.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func writeCSV(w io.Writer, rows func(*csv.Writer)) error {
+	writer := csv.NewWriter(w)
+	writer.Write(CSVHeader)
+	rows(writer)
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,99 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readCSV(t *testing.T, data []byte) [][]string {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	return records
+}
+
+func TestWriteCSV(t *testing.T) {
+	summary := testSummary()
+	cases := map[string]struct {
+		write func(*bytes.Buffer) error
+		rows  [][]string
+	}{
+		"matched": {
+			write: func(buf *bytes.Buffer) error { return WriteMatchedCSV(buf, summary) },
+			rows:  [][]string{{"SYS-1", "BNK-1", "BCA", "123", "2025-06-02", "DEBIT", "75500.5", "-75000", "500.5", ""}},
+		},
+		"unmatched system": {
+			write: func(buf *bytes.Buffer) error { return WriteUnmatchedSystemCSV(buf, summary) },
+			rows:  [][]string{{"SYS-2", "", "", "", "2025-06-05", "CREDIT", "200000", "", "", ""}},
+		},
+		"unmatched bank": {
+			write: func(buf *bytes.Buffer) error { return WriteUnmatchedBankCSV(buf, summary) },
+			rows: [][]string{
+				{"", "BRI-9", "BRI", "", "2025-06-23", "CREDIT", "", "80000", "", ""},
+				{"", "BNK-FEE", "BCA", "123", "2025-06-24", "DEBIT", "", "-15000", "", ""},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tc.write(&buf))
+			records := readCSV(t, buf.Bytes())
+			assert.Equal(t, CSVHeader, records[0])
+			assert.Equal(t, tc.rows, records[1:])
+		})
+	}
+}
+
+func TestWriteCSV_Formulas(t *testing.T) {
+	summary := testSummary()
+	summary.MatchedPairs[0].System.ID = "=HYPERLINK(\"http://evil\")"
+	summary.MatchedPairs[0].Bank.ID = "-42"
+	summary.MatchedPairs[0].Bank.BankName = "+BCA"
+	summary.MatchedPairs[0].Bank.AccountNumber = "@123"
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMatchedCSV(&buf, summary))
+	records := readCSV(t, buf.Bytes())
+	assert.Equal(t, []string{"'=HYPERLINK(\"http://evil\")", "'-42", "'+BCA", "'@123", "2025-06-02", "DEBIT", "75500.5", "-75000", "500.5", ""}, records[1], "negative amounts are left alone")
+
+	overrides, err := repository.ReadOverrides(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Override{{SystemTransactionID: "=HYPERLINK(\"http://evil\")", BankTransactionID: "-42"}}, overrides, "IDs read back unescaped")
+}
+
+func TestExportCSV(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "export")
+	paths, err := ExportCSV(dir, testSummary())
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, MatchedCSV), filepath.Join(dir, UnmatchedSystemCSV), filepath.Join(dir, UnmatchedBankCSV), filepath.Join(dir, AgingCSV)}, paths)
+
+	var overrides []domain.Override
+	for _, path := range paths[:3] {
+		loaded, err := repository.LoadOverrides(path)
+		require.NoError(t, err, "exports read back as overrides")
+		overrides = append(overrides, loaded...)
+	}
+	assert.Equal(t, []domain.Override{
+		{SystemTransactionID: "SYS-1", BankTransactionID: "BNK-1"},
+		{SystemTransactionID: "SYS-2"},
+		{BankTransactionID: "BRI-9"},
+		{BankTransactionID: "BNK-FEE"},
+	}, overrides)
+
+	data, err := os.ReadFile(paths[3])
+	require.NoError(t, err)
+	aging := readCSV(t, data)
+	assert.Equal(t, []string{"side", "bank", "bucket", "count", "amount"}, aging[0])
+	assert.Len(t, aging, 1+4*3, "system, BRI and BCA by bucket")
+	assert.Equal(t, []string{"system", "", "8-30", "1", "200000"}, aging[3])
+	assert.Equal(t, []string{"bank", "BCA", "4-7", "1", "15000"}, aging[10])
+}
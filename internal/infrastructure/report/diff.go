@@ -0,0 +1,100 @@
+package report
+
+import (
+	"io"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// JSONDiff is the JSON form of a SummaryDiff, as printed by
+// "reconciler diff -format=json". Deltas are the new value minus the old one.
+type JSONDiff struct {
+	SchemaVersion        string                  `json:"schema_version"`
+	GeneratedAt          string                  `json:"generated_at"`
+	Old                  string                  `json:"old"`
+	New                  string                  `json:"new"`
+	Deltas               JSONDiffDeltas          `json:"deltas"`
+	NewlyMatched         []JSONMatchedPair       `json:"newly_matched"`
+	NewlyUnmatchedSystem []JSONSystemTransaction `json:"newly_unmatched_system_transactions"`
+	NewlyUnmatchedBank   []JSONBankTransaction   `json:"newly_unmatched_bank_transactions"`
+	ChangedPairings      []JSONPairingChange     `json:"changed_pairings"`
+	DiscrepancyChanges   []JSONDiscrepancyChange `json:"discrepancy_changes"`
+}
+
+type JSONDiffDeltas struct {
+	SystemTransactions int    `json:"system_transactions"`
+	BankTransactions   int    `json:"bank_transactions"`
+	Matched            int    `json:"matched"`
+	UnmatchedSystem    int    `json:"unmatched_system"`
+	UnmatchedBank      int    `json:"unmatched_bank"`
+	AmountDiscrepancy  string `json:"amount_discrepancy"`
+}
+
+type JSONPairingChange struct {
+	System         JSONSystemTransaction `json:"system"`
+	OldBank        JSONBankTransaction   `json:"old_bank"`
+	NewBank        JSONBankTransaction   `json:"new_bank"`
+	OldDiscrepancy string                `json:"old_discrepancy"`
+	NewDiscrepancy string                `json:"new_discrepancy"`
+}
+
+type JSONDiscrepancyChange struct {
+	Pair           JSONMatchedPair `json:"pair"`
+	OldDiscrepancy string          `json:"old_discrepancy"`
+}
+
+// NewJSONDiff converts the diff of the reports at oldPath and newPath. Lists
+// are never nil, so they encode as empty arrays.
+func NewJSONDiff(diff *domain.SummaryDiff, oldPath, newPath string, generatedAt time.Time) *JSONDiff {
+	converted := &JSONDiff{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   generatedAt.Format(time.RFC3339),
+		Old:           oldPath,
+		New:           newPath,
+		Deltas: JSONDiffDeltas{
+			SystemTransactions: diff.TotalSystemTransactionsDelta,
+			BankTransactions:   diff.TotalBankTransactionsDelta,
+			Matched:            diff.MatchedTransactionsDelta,
+			UnmatchedSystem:    diff.UnmatchedSystemTransactionsDelta,
+			UnmatchedBank:      diff.UnmatchedBankTransactionsDelta,
+			AmountDiscrepancy:  diff.AmountDiscrepancyDelta.String(),
+		},
+		NewlyMatched:         make([]JSONMatchedPair, 0, len(diff.NewlyMatched)),
+		NewlyUnmatchedSystem: make([]JSONSystemTransaction, 0, len(diff.NewlyUnmatchedSystem)),
+		NewlyUnmatchedBank:   make([]JSONBankTransaction, 0, len(diff.NewlyUnmatchedBank)),
+		ChangedPairings:      make([]JSONPairingChange, 0, len(diff.ChangedPairings)),
+		DiscrepancyChanges:   make([]JSONDiscrepancyChange, 0, len(diff.DiscrepancyChanges)),
+	}
+	for _, pair := range diff.NewlyMatched {
+		converted.NewlyMatched = append(converted.NewlyMatched, jsonMatchedPair(pair))
+	}
+	for _, tx := range diff.NewlyUnmatchedSystem {
+		converted.NewlyUnmatchedSystem = append(converted.NewlyUnmatchedSystem, jsonSystemTransaction(tx))
+	}
+	for _, tx := range diff.NewlyUnmatchedBank {
+		converted.NewlyUnmatchedBank = append(converted.NewlyUnmatchedBank, jsonBankTransaction(tx))
+	}
+	for _, change := range diff.ChangedPairings {
+		converted.ChangedPairings = append(converted.ChangedPairings, JSONPairingChange{
+			System:         jsonSystemTransaction(change.System),
+			OldBank:        jsonBankTransaction(change.OldBank),
+			NewBank:        jsonBankTransaction(change.NewBank),
+			OldDiscrepancy: change.OldDiscrepancy.String(),
+			NewDiscrepancy: change.NewDiscrepancy.String(),
+		})
+	}
+	for _, change := range diff.DiscrepancyChanges {
+		converted.DiscrepancyChanges = append(converted.DiscrepancyChanges, JSONDiscrepancyChange{
+			Pair:           jsonMatchedPair(change.Pair),
+			OldDiscrepancy: change.OldDiscrepancy.String(),
+		})
+	}
+	return converted
+}
+
+// WriteDiffJSON writes the diff of the reports at oldPath and newPath as
+// indented JSON.
+func WriteDiffJSON(w io.Writer, diff *domain.SummaryDiff, oldPath, newPath string, generatedAt time.Time) error {
+	return writeJSON(w, NewJSONDiff(diff, oldPath, newPath, generatedAt))
+}
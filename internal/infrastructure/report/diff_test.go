@@ -0,0 +1,64 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONDiff(t *testing.T) {
+	summary := testSummary()
+	pair := summary.MatchedPairs[0]
+	fee := summary.UnmatchedBankTransactions["BCA"][0]
+	diff := &domain.SummaryDiff{
+		NewlyMatched:                   []domain.MatchedPair{pair},
+		NewlyUnmatchedSystem:           summary.UnmatchedSystemTransactions,
+		NewlyUnmatchedBank:             []domain.BankTransaction{fee},
+		ChangedPairings:                []domain.PairingChange{{System: pair.System, OldBank: fee, NewBank: pair.Bank, OldDiscrepancy: decimal.RequireFromString("90500.5"), NewDiscrepancy: pair.Discrepancy}},
+		DiscrepancyChanges:             []domain.DiscrepancyChange{{Pair: pair, OldDiscrepancy: decimal.RequireFromString("1")}},
+		MatchedTransactionsDelta:       1,
+		UnmatchedBankTransactionsDelta: -2,
+		AmountDiscrepancyDelta:         decimal.RequireFromString("-0.50"),
+	}
+	converted := NewJSONDiff(diff, "old.json", "new.json", time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, SchemaVersion, converted.SchemaVersion)
+	assert.Equal(t, "2025-07-01T08:00:00Z", converted.GeneratedAt)
+	assert.Equal(t, JSONDiffDeltas{Matched: 1, UnmatchedBank: -2, AmountDiscrepancy: "-0.5"}, converted.Deltas)
+	assert.Equal(t, []JSONMatchedPair{jsonMatchedPair(pair)}, converted.NewlyMatched)
+	assert.Equal(t, "SYS-2", converted.NewlyUnmatchedSystem[0].ID)
+	assert.Equal(t, "-15000", converted.NewlyUnmatchedBank[0].Amount)
+	assert.Equal(t, JSONPairingChange{
+		System:         jsonSystemTransaction(pair.System),
+		OldBank:        jsonBankTransaction(fee),
+		NewBank:        jsonBankTransaction(pair.Bank),
+		OldDiscrepancy: "90500.5",
+		NewDiscrepancy: "500.5",
+	}, converted.ChangedPairings[0])
+	assert.Equal(t, "1", converted.DiscrepancyChanges[0].OldDiscrepancy)
+}
+
+func TestWriteDiffJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteDiffJSON(&buf, &domain.SummaryDiff{}, "old.json", "new.json", time.Now()))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, SchemaVersion, decoded["schema_version"])
+	assert.Equal(t, "old.json", decoded["old"])
+	assert.Equal(t, "new.json", decoded["new"])
+	assert.Equal(t, map[string]any{
+		"system_transactions": 0.0, "bank_transactions": 0.0, "matched": 0.0,
+		"unmatched_system": 0.0, "unmatched_bank": 0.0, "amount_discrepancy": "0",
+	}, decoded["deltas"])
+	for _, list := range []string{"newly_matched", "newly_unmatched_system_transactions", "newly_unmatched_bank_transactions", "changed_pairings", "discrepancy_changes"} {
+		assert.Equal(t, []any{}, decoded[list], list)
+	}
+}
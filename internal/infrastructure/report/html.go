@@ -0,0 +1,247 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+
+	"github.com/shopspring/decimal"
+)
+
+// The page is self-contained: styles and scripts are inlined, so the file
+// can be mailed or opened offline.
+//
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	htmlTemplate = template.Must(template.New("report.html.tmpl").Funcs(template.FuncMap{
+		"money": func(amount decimal.Decimal) string { return amount.StringFixed(2) },
+		"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+		"time":  func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	}).ParseFS(templateFS, "templates/report.html.tmpl"))
+	htmlStyle  = template.CSS(mustReadTemplateFile("templates/report.css"))
+	htmlScript = template.JS(mustReadTemplateFile("templates/report.js"))
+)
+
+func mustReadTemplateFile(name string) string {
+	data, err := templateFS.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+type htmlPage struct {
+	Title       string
+	GeneratedAt string
+	Style       template.CSS
+	Script      template.JS
+	// Jobs is the overview of a manifest run, empty for a single report.
+	Jobs    []htmlJob
+	Reports []htmlReport
+}
+
+type htmlJob struct {
+	Name     string
+	Anchor   string
+	Failed   bool
+	Duration string
+	// Summary is nil for a failed job.
+	Summary *domain.ReconciliationSummary
+}
+
+type htmlReport struct {
+	Anchor string
+	Name   string
+	Scope  string
+	Period string
+	Error  string
+	Cards  []htmlCard
+	Banks  []htmlBank
+	// Discrepancies are the matched pairs whose amounts differ, largest
+	// difference first.
+	Discrepancies   []domain.MatchedPair
+	Summary         *domain.ReconciliationSummary
+	HasDataProblems bool
+	// Aging is nil when nothing is unmatched.
+	Aging  *domain.Aging
+	ByBank []htmlBreakdownRow
+	ByDay  []htmlBreakdownRow
+}
+
+// htmlBreakdownRow is one direction of a bank or a day.
+type htmlBreakdownRow struct {
+	Label   string
+	Type    domain.TransactionType
+	Figures domain.BreakdownFigures
+}
+
+type htmlCard struct {
+	Label string
+	Value string
+	Alert bool
+}
+
+type htmlBank struct {
+	Name             string
+	AccountNumber    string
+	Matched          int
+	Discrepancies    int
+	DiscrepancyTotal decimal.Decimal
+	// DiscrepancyShare is the bank's part of the total discrepancy, such as
+	// "25.0%".
+	DiscrepancyShare string
+	Unmatched        []domain.BankTransaction
+	UnmatchedAmount  decimal.Decimal
+}
+
+// WriteHTML writes the report of summary as a standalone HTML page.
+func WriteHTML(w io.Writer, summary *domain.ReconciliationSummary, meta Metadata) error {
+	report := newHTMLReport(summary, meta)
+	return writeHTML(w, htmlPage{
+		Title:       pageTitle(meta),
+		GeneratedAt: meta.GeneratedAt.Format(time.RFC3339),
+		Reports:     []htmlReport{report},
+	})
+}
+
+// WriteBatchHTML writes the reports of a manifest run as one HTML page with an
+// overview of the jobs.
+func WriteBatchHTML(w io.Writer, result *usecase.BatchResult, generatedAt time.Time) error {
+	page := htmlPage{Title: "Reconciliation Report", GeneratedAt: generatedAt.Format(time.RFC3339)}
+	for i, job := range result.Jobs {
+		anchor := fmt.Sprintf("job-%d", i+1)
+		entry := htmlJob{Name: job.Name, Anchor: anchor, Failed: job.Err != nil, Duration: job.Duration.Round(time.Millisecond).String()}
+		report := htmlReport{Anchor: anchor, Name: job.Name}
+		if job.Err != nil {
+			report.Error = job.Err.Error()
+		} else {
+			request := job.Response.Request
+			report = newHTMLReport(job.Response.Summary, Metadata{Scope: request.Scope, Period: request.Period, GeneratedAt: generatedAt})
+			report.Anchor, report.Name = anchor, job.Name
+			entry.Summary = job.Response.Summary
+		}
+		page.Jobs = append(page.Jobs, entry)
+		page.Reports = append(page.Reports, report)
+	}
+	return writeHTML(w, page)
+}
+
+func writeHTML(w io.Writer, page htmlPage) error {
+	page.Style, page.Script = htmlStyle, htmlScript
+	if err := htmlTemplate.Execute(w, page); err != nil {
+		return fmt.Errorf("could not write HTML report: %w", err)
+	}
+	return nil
+}
+
+func pageTitle(meta Metadata) string {
+	title := "Reconciliation Report"
+	if meta.Scope != "" {
+		title += " – " + meta.Scope
+	}
+	if !meta.Period.Start.IsZero() {
+		title += fmt.Sprintf(" (%s to %s)", meta.Period.Start.Format("2006-01-02"), meta.Period.End.Format("2006-01-02"))
+	}
+	return title
+}
+
+func newHTMLReport(summary *domain.ReconciliationSummary, meta Metadata) htmlReport {
+	report := htmlReport{Scope: meta.Scope, Summary: summary}
+	if !meta.Period.Start.IsZero() {
+		report.Period = meta.Period.Start.Format("2006-01-02") + " to " + meta.Period.End.Format("2006-01-02")
+	}
+	report.HasDataProblems = len(summary.BalanceGaps) > 0 || len(summary.UnreconciledSources) > 0 ||
+		len(summary.RejectedRows) > 0 || len(summary.DuplicateStatements) > 0 || len(summary.DuplicateBankTransactions) > 0
+
+	unmatchedBank := summary.UnmatchedBankCount()
+	if unmatchedBank > 0 || len(summary.UnmatchedSystemTransactions) > 0 {
+		report.Aging = summary.Aging
+	}
+	report.Cards = []htmlCard{
+		{Label: "System transactions", Value: fmt.Sprint(summary.TotalSystemTransactions)},
+		{Label: "Bank transactions", Value: fmt.Sprint(summary.TotalBankTransactions)},
+		{Label: "Matched", Value: fmt.Sprint(summary.MatchedTransactions)},
+		{Label: "Match rate", Value: matchRate(summary)},
+		{Label: "Unmatched system", Value: fmt.Sprint(len(summary.UnmatchedSystemTransactions)), Alert: len(summary.UnmatchedSystemTransactions) > 0},
+		{Label: "Unmatched bank", Value: fmt.Sprint(unmatchedBank), Alert: unmatchedBank > 0},
+		{Label: "Total discrepancy", Value: summary.AmountDiscrepancyTotal.StringFixed(2), Alert: !summary.AmountDiscrepancyTotal.IsZero()},
+	}
+
+	banks := make(map[string]*htmlBank, len(summary.BankNames))
+	for _, name := range summary.BankNames {
+		banks[name] = &htmlBank{Name: name}
+	}
+	for _, pair := range summary.MatchedPairs {
+		bank := banks[pair.Bank.BankName]
+		if bank == nil {
+			continue
+		}
+		bank.Matched++
+		if bank.AccountNumber == "" {
+			bank.AccountNumber = pair.Bank.AccountNumber
+		}
+		if !pair.Discrepancy.IsZero() {
+			bank.Discrepancies++
+			bank.DiscrepancyTotal = bank.DiscrepancyTotal.Add(pair.Discrepancy)
+			report.Discrepancies = append(report.Discrepancies, pair)
+		}
+	}
+	for name, txs := range summary.UnmatchedBankTransactions {
+		bank := banks[name]
+		if bank == nil {
+			continue
+		}
+		bank.Unmatched = txs
+		for _, tx := range txs {
+			bank.UnmatchedAmount = bank.UnmatchedAmount.Add(tx.Amount)
+			if bank.AccountNumber == "" {
+				bank.AccountNumber = tx.AccountNumber
+			}
+		}
+	}
+	for _, name := range summary.BankNames {
+		bank := banks[name]
+		if !summary.AmountDiscrepancyTotal.IsZero() {
+			bank.DiscrepancyShare = bank.DiscrepancyTotal.Div(summary.AmountDiscrepancyTotal).Mul(decimal.NewFromInt(100)).StringFixed(1) + "%"
+		}
+		report.Banks = append(report.Banks, *bank)
+	}
+	sort.SliceStable(report.Discrepancies, func(i, j int) bool {
+		return report.Discrepancies[i].Discrepancy.Abs().GreaterThan(report.Discrepancies[j].Discrepancy.Abs())
+	})
+
+	for _, bank := range summary.BankBreakdowns {
+		report.ByBank = appendBreakdownRows(report.ByBank, bank.BankName, bank.Breakdown)
+	}
+	for _, day := range summary.DayBreakdowns {
+		report.ByDay = appendBreakdownRows(report.ByDay, day.Date.Format("2006-01-02"), day.Breakdown)
+	}
+	return report
+}
+
+// appendBreakdownRows appends the directions of breakdown that have
+// transactions.
+func appendBreakdownRows(rows []htmlBreakdownRow, label string, breakdown domain.Breakdown) []htmlBreakdownRow {
+	for _, txType := range []domain.TransactionType{domain.Debit, domain.Credit} {
+		figures := *breakdown.Direction(txType)
+		if figures.System.Count+figures.Bank.Count > 0 {
+			rows = append(rows, htmlBreakdownRow{Label: label, Type: txType, Figures: figures})
+		}
+	}
+	return rows
+}
+
+func matchRate(summary *domain.ReconciliationSummary) string {
+	if summary.TotalSystemTransactions == 0 {
+		return "–"
+	}
+	return fmt.Sprintf("%.1f%%", float64(summary.MatchedTransactions)*100/float64(summary.TotalSystemTransactions))
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/usecase"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTMLReport(t *testing.T) {
+	summary := testSummary()
+	summary.MatchedPairs = append(summary.MatchedPairs, domain.MatchedPair{
+		System:      domain.SystemTransaction{ID: "SYS-3", Amount: decimal.RequireFromString("10000")},
+		Bank:        domain.BankTransaction{ID: "BRI-1", Amount: decimal.RequireFromString("9000"), BankName: "BRI"},
+		Discrepancy: decimal.RequireFromString("1000"),
+	})
+	summary.AmountDiscrepancyTotal = decimal.RequireFromString("1500.50")
+
+	report := newHTMLReport(summary, Metadata{})
+
+	require.Len(t, report.Banks, 3)
+	bri, bca, hsbc := report.Banks[0], report.Banks[1], report.Banks[2]
+	assert.Equal(t, "BRI", bri.Name)
+	assert.Equal(t, 1, bri.Discrepancies)
+	assert.Equal(t, "66.6%", bri.DiscrepancyShare)
+	assert.Equal(t, "123", bca.AccountNumber)
+	assert.Equal(t, "-15000", bca.UnmatchedAmount.String())
+	assert.Equal(t, 0, hsbc.Matched)
+	assert.Empty(t, hsbc.Unmatched)
+
+	require.Len(t, report.Discrepancies, 2)
+	assert.Equal(t, "SYS-3", report.Discrepancies[0].System.ID, "largest difference first")
+	assert.True(t, report.HasDataProblems)
+	assert.Contains(t, report.Cards, htmlCard{Label: "Match rate", Value: "33.3%"})
+
+	require.Len(t, report.ByBank, 2, "directions without transactions are left out")
+	assert.Equal(t, "BRI", report.ByBank[0].Label)
+	assert.Equal(t, domain.Credit, report.ByBank[0].Type)
+	assert.Equal(t, domain.Debit, report.ByBank[1].Type)
+	require.Len(t, report.ByDay, 4)
+	assert.Equal(t, "2025-06-05", report.ByDay[1].Label)
+}
+
+func TestWriteHTML(t *testing.T) {
+	summary := testSummary()
+	summary.UnmatchedSystemTransactions[0].ID = "<script>alert(1)</script>"
+	period := domain.Period{Start: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, summary, Metadata{Scope: "Operating", Period: period, GeneratedAt: time.Now()}))
+	page := buf.String()
+
+	assert.Contains(t, page, "<title>Reconciliation Report – Operating (2025-06-01 to 2025-06-30)</title>")
+	assert.Contains(t, page, "75500.50")
+	assert.Contains(t, page, "BNK-FEE")
+	assert.Contains(t, page, "broken.csv: unreadable")
+	assert.Contains(t, page, "<h3>Aging at 2025-06-30</h3>")
+	assert.Contains(t, page, "<td>BRI</td><td class=\"num\">0 · 0.00</td><td class=\"num\">1 · 80000.00</td>")
+	assert.Contains(t, page, "<h4>By day</h4>")
+	assert.Contains(t, page, "<td>2025-06-24</td><td>DEBIT</td>")
+	assert.Contains(t, page, "table.sortable", "styles are inlined")
+	assert.Contains(t, page, "aria-sort", "scripts are inlined")
+	assert.Contains(t, page, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, page, "<script>alert(1)")
+	assert.NotContains(t, page, "<link")
+	assert.NotContains(t, page, "src=")
+
+	buf.Reset()
+	require.NoError(t, WriteHTML(&buf, &domain.ReconciliationSummary{}, Metadata{GeneratedAt: time.Now()}))
+	assert.Contains(t, buf.String(), "Every system transaction was matched.")
+	assert.NotContains(t, buf.String(), "Aging at")
+	assert.NotContains(t, buf.String(), "Breakdown")
+}
+
+func TestWriteBatchHTML(t *testing.T) {
+	result := &usecase.BatchResult{Jobs: []usecase.BatchJobResult{
+		{Name: "Operating", Response: &usecase.ReconciliationResponse{Summary: testSummary()}, Duration: time.Second},
+		{Name: "Payroll", Err: errors.New("bank file missing")},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteBatchHTML(&buf, result, time.Now()))
+	page := buf.String()
+
+	assert.Contains(t, page, `<a href="#job-1">Operating</a>`)
+	assert.Contains(t, page, `id="job-2"`)
+	assert.Contains(t, page, "Reconciliation failed: bank file missing")
+}
@@ -0,0 +1,346 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// SchemaVersion is the version of the JSON report schema, documented in
+// docs/report-schema.json. The minor version grows when fields are added;
+// the major version only when fields change meaning or are removed.
+const SchemaVersion = "1.3"
+
+// Metadata describes the run a report belongs to.
+type Metadata struct {
+	Scope       string
+	Period      domain.Period
+	GeneratedAt time.Time
+}
+
+// JSONReport is the machine readable form of a ReconciliationSummary.
+// Amounts are exact decimal strings and times RFC3339.
+type JSONReport struct {
+	SchemaVersion             string                         `json:"schema_version"`
+	GeneratedAt               string                         `json:"generated_at"`
+	Scope                     string                         `json:"scope,omitempty"`
+	Period                    *JSONPeriod                    `json:"period,omitempty"`
+	Totals                    JSONTotals                     `json:"totals"`
+	Banks                     []string                       `json:"banks"`
+	MatchedPairs              []JSONMatchedPair              `json:"matched_pairs"`
+	UnmatchedSystem           []JSONSystemTransaction        `json:"unmatched_system_transactions"`
+	UnmatchedBank             []JSONBankGroup                `json:"unmatched_bank_transactions"`
+	BalanceGaps               []JSONBalanceGap               `json:"balance_gaps"`
+	UnreconciledSources       []JSONUnreconciledSource       `json:"unreconciled_sources"`
+	RejectedRows              []JSONRejectedRow              `json:"rejected_rows"`
+	DuplicateStatements       []JSONDuplicateStatement       `json:"duplicate_statements"`
+	DuplicateBankTransactions []JSONDuplicateBankTransaction `json:"duplicate_bank_transactions"`
+	Aging                     *JSONAging                     `json:"aging,omitempty"`
+	ByBank                    []JSONBankBreakdown            `json:"by_bank"`
+	ByDay                     []JSONDayBreakdown             `json:"by_day"`
+}
+
+type JSONPeriod struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type JSONTotals struct {
+	SystemTransactions        int     `json:"system_transactions"`
+	BankTransactions          int     `json:"bank_transactions"`
+	Matched                   int     `json:"matched"`
+	UnmatchedSystem           int     `json:"unmatched_system"`
+	UnmatchedBank             int     `json:"unmatched_bank"`
+	AmountDiscrepancy         string  `json:"amount_discrepancy"`
+	ProcessingDurationSeconds float64 `json:"processing_duration_seconds"`
+}
+
+type JSONSystemTransaction struct {
+	ID              string `json:"id"`
+	Amount          string `json:"amount"`
+	Type            string `json:"type"`
+	TransactionTime string `json:"transaction_time"`
+}
+
+type JSONBankTransaction struct {
+	ID            string  `json:"id"`
+	Amount        string  `json:"amount"`
+	Date          string  `json:"date"`
+	Bank          string  `json:"bank"`
+	AccountNumber string  `json:"account_number,omitempty"`
+	Source        string  `json:"source,omitempty"`
+	Balance       *string `json:"balance,omitempty"`
+}
+
+type JSONMatchedPair struct {
+	System      JSONSystemTransaction `json:"system"`
+	Bank        JSONBankTransaction   `json:"bank"`
+	Discrepancy string                `json:"discrepancy"`
+}
+
+type JSONBankGroup struct {
+	Bank          string                `json:"bank"`
+	AccountNumber string                `json:"account_number,omitempty"`
+	Transactions  []JSONBankTransaction `json:"transactions"`
+}
+
+type JSONBalanceGap struct {
+	Bank          string `json:"bank"`
+	AccountNumber string `json:"account_number,omitempty"`
+	Source        string `json:"source,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+	Expected      string `json:"expected"`
+	Actual        string `json:"actual"`
+}
+
+type JSONUnreconciledSource struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+type JSONRejectedRow struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+type JSONDuplicateStatement struct {
+	Source       string `json:"source"`
+	DuplicateOf  string `json:"duplicate_of"`
+	Fingerprint  string `json:"fingerprint"`
+	Transactions int    `json:"transactions"`
+}
+
+type JSONDuplicateBankTransaction struct {
+	Transaction JSONBankTransaction `json:"transaction"`
+	DuplicateOf string              `json:"duplicate_of"`
+}
+
+type JSONAging struct {
+	AsOf    string           `json:"as_of"`
+	Buckets []string         `json:"buckets"`
+	System  []JSONAgingCount `json:"system"`
+	Banks   []JSONBankAging  `json:"banks"`
+}
+
+type JSONAgingCount struct {
+	Bucket string `json:"bucket"`
+	Count  int    `json:"count"`
+	Amount string `json:"amount"`
+}
+
+type JSONBankAging struct {
+	Bank    string           `json:"bank"`
+	Buckets []JSONAgingCount `json:"buckets"`
+}
+
+type JSONBankBreakdown struct {
+	Bank   string               `json:"bank"`
+	Debit  JSONBreakdownFigures `json:"debit"`
+	Credit JSONBreakdownFigures `json:"credit"`
+}
+
+type JSONDayBreakdown struct {
+	Date   string               `json:"date"`
+	Debit  JSONBreakdownFigures `json:"debit"`
+	Credit JSONBreakdownFigures `json:"credit"`
+}
+
+type JSONBreakdownFigures struct {
+	System          JSONTally `json:"system"`
+	Bank            JSONTally `json:"bank"`
+	Matched         JSONTally `json:"matched"`
+	UnmatchedSystem JSONTally `json:"unmatched_system"`
+	UnmatchedBank   JSONTally `json:"unmatched_bank"`
+	Discrepancy     string    `json:"discrepancy"`
+}
+
+type JSONTally struct {
+	Count  int    `json:"count"`
+	Amount string `json:"amount"`
+}
+
+// NewJSONReport converts a summary. Lists are never nil, so they encode as
+// empty arrays.
+func NewJSONReport(summary *domain.ReconciliationSummary, meta Metadata) *JSONReport {
+	report := &JSONReport{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   meta.GeneratedAt.Format(time.RFC3339),
+		Scope:         meta.Scope,
+		Totals: JSONTotals{
+			SystemTransactions:        summary.TotalSystemTransactions,
+			BankTransactions:          summary.TotalBankTransactions,
+			Matched:                   summary.MatchedTransactions,
+			UnmatchedSystem:           len(summary.UnmatchedSystemTransactions),
+			UnmatchedBank:             summary.UnmatchedBankCount(),
+			AmountDiscrepancy:         summary.AmountDiscrepancyTotal.String(),
+			ProcessingDurationSeconds: summary.ProcessingDurationSeconds,
+		},
+		Banks:                     append([]string{}, summary.BankNames...),
+		MatchedPairs:              make([]JSONMatchedPair, 0, len(summary.MatchedPairs)),
+		UnmatchedSystem:           make([]JSONSystemTransaction, 0, len(summary.UnmatchedSystemTransactions)),
+		UnmatchedBank:             []JSONBankGroup{},
+		BalanceGaps:               make([]JSONBalanceGap, 0, len(summary.BalanceGaps)),
+		UnreconciledSources:       make([]JSONUnreconciledSource, 0, len(summary.UnreconciledSources)),
+		RejectedRows:              make([]JSONRejectedRow, 0, len(summary.RejectedRows)),
+		DuplicateStatements:       make([]JSONDuplicateStatement, 0, len(summary.DuplicateStatements)),
+		DuplicateBankTransactions: make([]JSONDuplicateBankTransaction, 0, len(summary.DuplicateBankTransactions)),
+		ByBank:                    make([]JSONBankBreakdown, 0, len(summary.BankBreakdowns)),
+		ByDay:                     make([]JSONDayBreakdown, 0, len(summary.DayBreakdowns)),
+	}
+	if !meta.Period.Start.IsZero() {
+		report.Period = &JSONPeriod{Start: meta.Period.Start.Format("2006-01-02"), End: meta.Period.End.Format("2006-01-02")}
+	}
+
+	for _, pair := range summary.MatchedPairs {
+		report.MatchedPairs = append(report.MatchedPairs, jsonMatchedPair(pair))
+	}
+	for _, tx := range summary.UnmatchedSystemTransactions {
+		report.UnmatchedSystem = append(report.UnmatchedSystem, jsonSystemTransaction(tx))
+	}
+	for _, bank := range summary.BankNames {
+		txs := summary.UnmatchedBankTransactions[bank]
+		if len(txs) == 0 {
+			continue
+		}
+		group := JSONBankGroup{Bank: bank, AccountNumber: txs[0].AccountNumber, Transactions: make([]JSONBankTransaction, 0, len(txs))}
+		for _, tx := range txs {
+			group.Transactions = append(group.Transactions, jsonBankTransaction(tx))
+		}
+		report.UnmatchedBank = append(report.UnmatchedBank, group)
+	}
+	for _, gap := range summary.BalanceGaps {
+		report.BalanceGaps = append(report.BalanceGaps, JSONBalanceGap{
+			Bank:          gap.BankName,
+			AccountNumber: gap.AccountNumber,
+			Source:        gap.Source,
+			TransactionID: gap.TransactionID,
+			Expected:      gap.Expected.String(),
+			Actual:        gap.Actual.String(),
+		})
+	}
+	for _, source := range summary.UnreconciledSources {
+		report.UnreconciledSources = append(report.UnreconciledSources, JSONUnreconciledSource{Source: source.Source, Reason: source.Reason})
+	}
+	for _, row := range summary.RejectedRows {
+		report.RejectedRows = append(report.RejectedRows, JSONRejectedRow{Source: row.Source, Line: row.Line, Reason: row.Reason})
+	}
+	for _, statement := range summary.DuplicateStatements {
+		report.DuplicateStatements = append(report.DuplicateStatements, JSONDuplicateStatement{
+			Source:       statement.Source,
+			DuplicateOf:  statement.DuplicateOf,
+			Fingerprint:  statement.Fingerprint,
+			Transactions: statement.Transactions,
+		})
+	}
+	for _, duplicate := range summary.DuplicateBankTransactions {
+		report.DuplicateBankTransactions = append(report.DuplicateBankTransactions, JSONDuplicateBankTransaction{
+			Transaction: jsonBankTransaction(duplicate.Transaction),
+			DuplicateOf: duplicate.DuplicateOf,
+		})
+	}
+	if summary.Aging != nil {
+		report.Aging = jsonAging(summary.Aging)
+	}
+	for _, bank := range summary.BankBreakdowns {
+		report.ByBank = append(report.ByBank, JSONBankBreakdown{
+			Bank:   bank.BankName,
+			Debit:  jsonBreakdownFigures(bank.Debit),
+			Credit: jsonBreakdownFigures(bank.Credit),
+		})
+	}
+	for _, day := range summary.DayBreakdowns {
+		report.ByDay = append(report.ByDay, JSONDayBreakdown{
+			Date:   day.Date.Format("2006-01-02"),
+			Debit:  jsonBreakdownFigures(day.Debit),
+			Credit: jsonBreakdownFigures(day.Credit),
+		})
+	}
+	return report
+}
+
+func jsonBreakdownFigures(figures domain.BreakdownFigures) JSONBreakdownFigures {
+	tally := func(t domain.Tally) JSONTally { return JSONTally{Count: t.Count, Amount: t.Amount.String()} }
+	return JSONBreakdownFigures{
+		System:          tally(figures.System),
+		Bank:            tally(figures.Bank),
+		Matched:         tally(figures.Matched),
+		UnmatchedSystem: tally(figures.UnmatchedSystem),
+		UnmatchedBank:   tally(figures.UnmatchedBank),
+		Discrepancy:     figures.Discrepancy.String(),
+	}
+}
+
+func jsonAging(aging *domain.Aging) *JSONAging {
+	converted := &JSONAging{
+		AsOf:    aging.AsOf.Format("2006-01-02"),
+		Buckets: make([]string, 0, len(domain.AgingBuckets)),
+		System:  jsonAgingCounts(aging.System),
+		Banks:   make([]JSONBankAging, 0, len(aging.Banks)),
+	}
+	for _, bucket := range domain.AgingBuckets {
+		converted.Buckets = append(converted.Buckets, bucket.Label)
+	}
+	for _, bank := range aging.Banks {
+		converted.Banks = append(converted.Banks, JSONBankAging{Bank: bank.BankName, Buckets: jsonAgingCounts(bank.Buckets)})
+	}
+	return converted
+}
+
+func jsonAgingCounts(counts []domain.AgingCount) []JSONAgingCount {
+	converted := make([]JSONAgingCount, 0, len(counts))
+	for _, count := range counts {
+		converted = append(converted, JSONAgingCount{Bucket: count.Bucket, Count: count.Count, Amount: count.Amount.String()})
+	}
+	return converted
+}
+
+func jsonMatchedPair(pair domain.MatchedPair) JSONMatchedPair {
+	return JSONMatchedPair{
+		System:      jsonSystemTransaction(pair.System),
+		Bank:        jsonBankTransaction(pair.Bank),
+		Discrepancy: pair.Discrepancy.String(),
+	}
+}
+
+func jsonSystemTransaction(tx domain.SystemTransaction) JSONSystemTransaction {
+	return JSONSystemTransaction{
+		ID:              tx.ID,
+		Amount:          tx.Amount.String(),
+		Type:            string(tx.Type),
+		TransactionTime: tx.TransactionTime.Format(time.RFC3339),
+	}
+}
+
+func jsonBankTransaction(tx domain.BankTransaction) JSONBankTransaction {
+	converted := JSONBankTransaction{
+		ID:            tx.ID,
+		Amount:        tx.Amount.String(),
+		Date:          tx.Date.Format(time.RFC3339),
+		Bank:          tx.BankName,
+		AccountNumber: tx.AccountNumber,
+		Source:        tx.Source,
+	}
+	if tx.Balance.Valid {
+		balance := tx.Balance.Decimal.String()
+		converted.Balance = &balance
+	}
+	return converted
+}
+
+// WriteJSON writes the report of summary as indented JSON.
+func WriteJSON(w io.Writer, summary *domain.ReconciliationSummary, meta Metadata) error {
+	return writeJSON(w, NewJSONReport(summary, meta))
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("could not write JSON report: %w", err)
+	}
+	return nil
+}
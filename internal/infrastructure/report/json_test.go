@@ -0,0 +1,124 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/service"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var jakarta = time.FixedZone("Asia/Jakarta", 7*60*60)
+
+func testSummary() *domain.ReconciliationSummary {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+	fee := domain.BankTransaction{ID: "BNK-FEE", Amount: decimal.RequireFromString("-15000"), Date: time.Date(2025, 6, 24, 0, 0, 0, 0, jakarta), BankName: "BCA", AccountNumber: "123", Source: "bca.csv", Balance: decimal.NewNullDecimal(decimal.RequireFromString("985000.10"))}
+	summary := &domain.ReconciliationSummary{
+		TotalSystemTransactions: 3,
+		TotalBankTransactions:   3,
+		MatchedTransactions:     1,
+		MatchedPairs: []domain.MatchedPair{{
+			System:      domain.SystemTransaction{ID: "SYS-1", Amount: decimal.RequireFromString("75500.50"), Type: domain.Debit, TransactionTime: time.Date(2025, 6, 2, 9, 30, 0, 0, jakarta)},
+			Bank:        domain.BankTransaction{ID: "BNK-1", Amount: decimal.RequireFromString("-75000"), Date: day(2), BankName: "BCA", AccountNumber: "123"},
+			Discrepancy: decimal.RequireFromString("500.50"),
+		}},
+		UnmatchedSystemTransactions: []domain.SystemTransaction{{ID: "SYS-2", Amount: decimal.RequireFromString("200000"), Type: domain.Credit, TransactionTime: day(5)}},
+		UnmatchedBankTransactions: map[string][]domain.BankTransaction{
+			"BCA": {fee},
+			"BRI": {{ID: "BRI-9", Amount: decimal.RequireFromString("80000"), Date: day(23), BankName: "BRI"}},
+		},
+		AmountDiscrepancyTotal:    decimal.RequireFromString("500.50"),
+		ProcessingDurationSeconds: 0.25,
+		BankNames:                 []string{"BRI", "BCA", "HSBC"},
+		UnreconciledSources:       []domain.UnreconciledSource{{Source: "broken.csv", Reason: "unreadable"}},
+		RejectedRows:              []domain.RejectedRow{{Source: "system.csv", Line: 4, Reason: "unknown type 'X'"}},
+		DuplicateStatements:       []domain.DuplicateStatement{{Source: "copy.csv", DuplicateOf: "bca.csv", Fingerprint: "abc", Transactions: 2}},
+		DuplicateBankTransactions: []domain.DuplicateBankTransaction{{Transaction: fee, DuplicateOf: "bca-old.csv"}},
+		BalanceGaps:               []domain.BalanceGap{{BankName: "BCA", AccountNumber: "123", Source: "bca.csv", TransactionID: "BNK-FEE", Expected: decimal.RequireFromString("1000000"), Actual: decimal.RequireFromString("985000.10")}},
+		Aging: &domain.Aging{
+			AsOf:   day(30),
+			System: agingCounts(0, "0", 0, "0", 1, "200000", 0, "0"),
+			Banks: []domain.BankAging{
+				{BankName: "BRI", Buckets: agingCounts(0, "0", 1, "80000", 0, "0", 0, "0")},
+				{BankName: "BCA", Buckets: agingCounts(0, "0", 1, "15000", 0, "0", 0, "0")},
+			},
+		},
+	}
+	summary.BankBreakdowns, summary.DayBreakdowns = service.Breakdowns(summary)
+	return summary
+}
+
+// agingCounts takes a count and an amount for every bucket.
+func agingCounts(values ...any) []domain.AgingCount {
+	counts := make([]domain.AgingCount, 0, len(domain.AgingBuckets))
+	for i, bucket := range domain.AgingBuckets {
+		counts = append(counts, domain.AgingCount{Bucket: bucket.Label, Count: values[2*i].(int), Amount: decimal.RequireFromString(values[2*i+1].(string))})
+	}
+	return counts
+}
+
+func TestNewJSONReport(t *testing.T) {
+	generated := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	period := domain.Period{Start: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)}
+	report := NewJSONReport(testSummary(), Metadata{Scope: "Operating", Period: period, GeneratedAt: generated})
+
+	assert.Equal(t, SchemaVersion, report.SchemaVersion)
+	assert.Equal(t, "2025-07-01T08:00:00Z", report.GeneratedAt)
+	assert.Equal(t, &JSONPeriod{Start: "2025-06-01", End: "2025-06-30"}, report.Period)
+	assert.Equal(t, JSONTotals{SystemTransactions: 3, BankTransactions: 3, Matched: 1, UnmatchedSystem: 1, UnmatchedBank: 2, AmountDiscrepancy: "500.5", ProcessingDurationSeconds: 0.25}, report.Totals)
+
+	require.Len(t, report.MatchedPairs, 1)
+	assert.Equal(t, "75500.5", report.MatchedPairs[0].System.Amount)
+	assert.Equal(t, "2025-06-02T09:30:00+07:00", report.MatchedPairs[0].System.TransactionTime)
+	assert.Equal(t, "-75000", report.MatchedPairs[0].Bank.Amount)
+	assert.Equal(t, "500.5", report.MatchedPairs[0].Discrepancy)
+
+	require.Len(t, report.UnmatchedBank, 2)
+	assert.Equal(t, "BRI", report.UnmatchedBank[0].Bank, "banks follow the order they were loaded in")
+	fee := report.UnmatchedBank[1].Transactions[0]
+	assert.Equal(t, "2025-06-24T00:00:00+07:00", fee.Date)
+	require.NotNil(t, fee.Balance)
+	assert.Equal(t, "985000.1", *fee.Balance)
+	assert.Nil(t, report.UnmatchedBank[0].Transactions[0].Balance)
+
+	assert.Equal(t, "985000.1", report.BalanceGaps[0].Actual)
+	assert.Equal(t, "BNK-FEE", report.DuplicateBankTransactions[0].Transaction.ID)
+	assert.Equal(t, 4, report.RejectedRows[0].Line)
+
+	require.NotNil(t, report.Aging)
+	assert.Equal(t, "2025-06-30", report.Aging.AsOf)
+	assert.Equal(t, []string{"0-3", "4-7", "8-30", "30+"}, report.Aging.Buckets)
+	assert.Equal(t, JSONAgingCount{Bucket: "8-30", Count: 1, Amount: "200000"}, report.Aging.System[2])
+	require.Len(t, report.Aging.Banks, 2)
+	assert.Equal(t, JSONAgingCount{Bucket: "4-7", Count: 1, Amount: "15000"}, report.Aging.Banks[1].Buckets[1])
+
+	require.Len(t, report.ByBank, 3)
+	bca := report.ByBank[1]
+	assert.Equal(t, "BCA", bca.Bank)
+	assert.Equal(t, JSONTally{Count: 2, Amount: "90000"}, bca.Debit.Bank)
+	assert.Equal(t, JSONTally{Count: 1, Amount: "75500.5"}, bca.Debit.Matched)
+	assert.Equal(t, "500.5", bca.Debit.Discrepancy)
+	assert.Equal(t, JSONTally{Count: 0, Amount: "0"}, report.ByBank[2].Credit.Bank)
+	require.Len(t, report.ByDay, 4)
+	assert.Equal(t, "2025-06-05", report.ByDay[1].Date)
+	assert.Equal(t, JSONTally{Count: 1, Amount: "200000"}, report.ByDay[1].Credit.UnmatchedSystem)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, &domain.ReconciliationSummary{}, Metadata{GeneratedAt: time.Now()}))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, SchemaVersion, decoded["schema_version"])
+	assert.NotContains(t, decoded, "period")
+	for _, list := range []string{"banks", "matched_pairs", "unmatched_system_transactions", "unmatched_bank_transactions", "balance_gaps", "unreconciled_sources", "rejected_rows", "duplicate_statements", "duplicate_bank_transactions", "by_bank", "by_day"} {
+		assert.Equal(t, []any{}, decoded[list], list)
+	}
+}
@@ -0,0 +1,199 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+// SaveJSON writes the JSON report of summary to filePath, for the diff
+// command.
+func SaveJSON(filePath string, summary *domain.ReconciliationSummary, meta Metadata) error {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, summary, meta); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("could not write JSON report '%s': %w", filePath, err)
+	}
+	return nil
+}
+
+// LoadSummary reads a report written with -format=json or SaveJSON back into
+// a summary. Period close records are accepted too and yield their closed
+// summary. Aging and breakdowns are left out, as they are derived from the
+// transactions. Reports of another major schema version, batch reports and
+// other JSON are rejected.
+func LoadSummary(filePath string) (*domain.ReconciliationSummary, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read JSON report '%s': %w", filePath, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON report '%s': %w", filePath, err)
+	}
+
+	if fields["ClosedAt"] != nil {
+		var record domain.PeriodClose
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("invalid period close record '%s': %w", filePath, err)
+		}
+		if record.Summary == nil {
+			return nil, fmt.Errorf("period close record '%s' has no summary", filePath)
+		}
+		return record.Summary, nil
+	}
+
+	var version string
+	if err := json.Unmarshal(fields["schema_version"], &version); err != nil || version == "" {
+		return nil, fmt.Errorf("'%s' is not a JSON report: it has no schema_version", filePath)
+	}
+	major, _, _ := strings.Cut(version, ".")
+	if supported, _, _ := strings.Cut(SchemaVersion, "."); major != supported {
+		return nil, fmt.Errorf("JSON report '%s' has schema version %s, expected %s or a compatible version", filePath, version, SchemaVersion)
+	}
+	if fields["jobs"] != nil {
+		return nil, fmt.Errorf("'%s' is a batch report; compare the reports of single runs", filePath)
+	}
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid JSON report '%s': %w", filePath, err)
+	}
+	summary, err := report.Summary()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON report '%s': %w", filePath, err)
+	}
+	return summary, nil
+}
+
+// Summary converts the report back, as described at LoadSummary.
+func (r *JSONReport) Summary() (*domain.ReconciliationSummary, error) {
+	discrepancy, err := decimal.NewFromString(r.Totals.AmountDiscrepancy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount discrepancy '%s': %w", r.Totals.AmountDiscrepancy, err)
+	}
+	summary := &domain.ReconciliationSummary{
+		TotalSystemTransactions:     r.Totals.SystemTransactions,
+		TotalBankTransactions:       r.Totals.BankTransactions,
+		MatchedTransactions:         r.Totals.Matched,
+		UnmatchedSystemTransactions: make([]domain.SystemTransaction, 0, len(r.UnmatchedSystem)),
+		UnmatchedBankTransactions:   make(map[string][]domain.BankTransaction),
+		AmountDiscrepancyTotal:      discrepancy,
+		ProcessingDurationSeconds:   r.Totals.ProcessingDurationSeconds,
+		BankNames:                   append([]string{}, r.Banks...),
+	}
+
+	for _, pair := range r.MatchedPairs {
+		system, err := pair.System.transaction()
+		if err != nil {
+			return nil, err
+		}
+		bank, err := pair.Bank.transaction()
+		if err != nil {
+			return nil, err
+		}
+		discrepancy, err := decimal.NewFromString(pair.Discrepancy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid discrepancy '%s' of system transaction '%s': %w", pair.Discrepancy, system.ID, err)
+		}
+		summary.MatchedPairs = append(summary.MatchedPairs, domain.MatchedPair{System: system, Bank: bank, Discrepancy: discrepancy})
+	}
+	for _, tx := range r.UnmatchedSystem {
+		system, err := tx.transaction()
+		if err != nil {
+			return nil, err
+		}
+		summary.UnmatchedSystemTransactions = append(summary.UnmatchedSystemTransactions, system)
+	}
+	for _, group := range r.UnmatchedBank {
+		for _, tx := range group.Transactions {
+			bank, err := tx.transaction()
+			if err != nil {
+				return nil, err
+			}
+			summary.UnmatchedBankTransactions[group.Bank] = append(summary.UnmatchedBankTransactions[group.Bank], bank)
+		}
+	}
+	for _, source := range r.UnreconciledSources {
+		summary.UnreconciledSources = append(summary.UnreconciledSources, domain.UnreconciledSource{Source: source.Source, Reason: source.Reason})
+	}
+	for _, row := range r.RejectedRows {
+		summary.RejectedRows = append(summary.RejectedRows, domain.RejectedRow{Source: row.Source, Line: row.Line, Reason: row.Reason})
+	}
+	for _, statement := range r.DuplicateStatements {
+		summary.DuplicateStatements = append(summary.DuplicateStatements, domain.DuplicateStatement{
+			Source:       statement.Source,
+			DuplicateOf:  statement.DuplicateOf,
+			Fingerprint:  statement.Fingerprint,
+			Transactions: statement.Transactions,
+		})
+	}
+	for _, duplicate := range r.DuplicateBankTransactions {
+		bank, err := duplicate.Transaction.transaction()
+		if err != nil {
+			return nil, err
+		}
+		summary.DuplicateBankTransactions = append(summary.DuplicateBankTransactions, domain.DuplicateBankTransaction{Transaction: bank, DuplicateOf: duplicate.DuplicateOf})
+	}
+	for _, gap := range r.BalanceGaps {
+		expected, err := decimal.NewFromString(gap.Expected)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expected balance '%s': %w", gap.Expected, err)
+		}
+		actual, err := decimal.NewFromString(gap.Actual)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stated balance '%s': %w", gap.Actual, err)
+		}
+		summary.BalanceGaps = append(summary.BalanceGaps, domain.BalanceGap{
+			BankName:      gap.Bank,
+			AccountNumber: gap.AccountNumber,
+			Source:        gap.Source,
+			TransactionID: gap.TransactionID,
+			Expected:      expected,
+			Actual:        actual,
+		})
+	}
+	return summary, nil
+}
+
+func (j JSONSystemTransaction) transaction() (domain.SystemTransaction, error) {
+	amount, err := decimal.NewFromString(j.Amount)
+	if err != nil {
+		return domain.SystemTransaction{}, fmt.Errorf("invalid amount '%s' of system transaction '%s': %w", j.Amount, j.ID, err)
+	}
+	transactionTime, err := time.Parse(time.RFC3339, j.TransactionTime)
+	if err != nil {
+		return domain.SystemTransaction{}, fmt.Errorf("invalid time '%s' of system transaction '%s': %w", j.TransactionTime, j.ID, err)
+	}
+	return domain.SystemTransaction{ID: j.ID, Amount: amount, Type: domain.TransactionType(j.Type), TransactionTime: transactionTime}, nil
+}
+
+func (j JSONBankTransaction) transaction() (domain.BankTransaction, error) {
+	amount, err := decimal.NewFromString(j.Amount)
+	if err != nil {
+		return domain.BankTransaction{}, fmt.Errorf("invalid amount '%s' of bank transaction '%s': %w", j.Amount, j.ID, err)
+	}
+	date, err := time.Parse(time.RFC3339, j.Date)
+	if err != nil {
+		return domain.BankTransaction{}, fmt.Errorf("invalid date '%s' of bank transaction '%s': %w", j.Date, j.ID, err)
+	}
+	tx := domain.BankTransaction{ID: j.ID, Amount: amount, Date: date, BankName: j.Bank, AccountNumber: j.AccountNumber, Source: j.Source}
+	if j.Balance != nil {
+		balance, err := decimal.NewFromString(*j.Balance)
+		if err != nil {
+			return domain.BankTransaction{}, fmt.Errorf("invalid balance '%s' of bank transaction '%s': %w", *j.Balance, j.ID, err)
+		}
+		tx.Balance = decimal.NewNullDecimal(balance)
+	}
+	return tx, nil
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/infrastructure/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryFile(t *testing.T) {
+	dir := t.TempDir()
+	summary := testSummary()
+	meta := Metadata{Scope: "treasury", GeneratedAt: time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)}
+
+	path := filepath.Join(dir, "june.json")
+	require.NoError(t, SaveJSON(path, summary, meta))
+	loaded, err := LoadSummary(path)
+	require.NoError(t, err)
+
+	expected, actual := NewJSONReport(summary, meta), NewJSONReport(loaded, meta)
+	expected.Aging, expected.ByBank, expected.ByDay = nil, nil, nil
+	actual.ByBank, actual.ByDay = nil, nil
+	assert.Equal(t, expected, actual, "everything but the derived figures reads back")
+	assert.Nil(t, loaded.Aging)
+
+	t.Run("period close record", func(t *testing.T) {
+		store := repository.NewPeriodCloseStore(dir)
+		june := domain.Period{Start: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)}
+		require.NoError(t, store.Save(&domain.PeriodClose{Period: june, Summary: summary, ApprovedBy: "Controller", ClosedAt: june.End}))
+		loaded, err := LoadSummary(filepath.Join(dir, "default", "2025-06-01_2025-06-30.json"))
+		require.NoError(t, err)
+		assert.Equal(t, summary.BankNames, loaded.BankNames)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		tests := map[string]struct {
+			content string
+			err     string
+		}{
+			"not an object":          {`[1]`, "invalid JSON report"},
+			"no schema version":      {`{"TotalSystemTransactions": 1, "MatchedTransactions": 1}`, "has no schema_version"},
+			"other major version":    {`{"schema_version": "2.0", "totals": {}}`, "has schema version 2.0"},
+			"batch report":           {`{"schema_version": "1.2", "jobs": [], "totals": {}}`, "is a batch report"},
+			"bad amount":             {`{"schema_version": "1.2", "totals": {"amount_discrepancy": "x"}}`, "invalid amount discrepancy"},
+			"unknown close field":    {`{"ClosedAt": "2025-06-30T00:00:00Z", "Summary": {}, "Extra": 1}`, "invalid period close record"},
+			"close without summary":  {`{"ClosedAt": "2025-06-30T00:00:00Z"}`, "has no summary"},
+			"unreadable transaction": {`{"schema_version": "1.2", "totals": {"amount_discrepancy": "0"}, "unmatched_system_transactions": [{"id": "S1", "amount": "1", "transaction_time": "June"}]}`, "invalid time 'June' of system transaction 'S1'"},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				path := filepath.Join(dir, "bad.json")
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+				_, err := LoadSummary(path)
+				assert.ErrorContains(t, err, tt.err)
+			})
+		}
+
+		_, err := LoadSummary(filepath.Join(dir, "missing.json"))
+		assert.ErrorContains(t, err, "could not read JSON report")
+	})
+}
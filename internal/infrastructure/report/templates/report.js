@@ -0,0 +1,33 @@
+// Clicking the heading of a sortable table sorts its rows by that column,
+// toggling between ascending and descending. Cells sort by their data-sort
+// attribute when present, and numerically under a data-type="number" heading.
+(function () {
+  function sortKey(cell) {
+    return cell.dataset.sort !== undefined ? cell.dataset.sort : cell.textContent.trim();
+  }
+
+  document.querySelectorAll("table.sortable th").forEach(function (heading) {
+    heading.addEventListener("click", function () {
+      var body = heading.closest("table").tBodies[0];
+      var column = Array.prototype.indexOf.call(heading.parentNode.children, heading);
+      var numeric = heading.dataset.type === "number";
+      var ascending = heading.getAttribute("aria-sort") !== "ascending";
+
+      heading.parentNode.querySelectorAll("th").forEach(function (other) {
+        other.removeAttribute("aria-sort");
+      });
+      heading.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var x = sortKey(a.cells[column]);
+        var y = sortKey(b.cells[column]);
+        var order = numeric ? (parseFloat(x) || 0) - (parseFloat(y) || 0) : x.localeCompare(y);
+        return ascending ? order : -order;
+      });
+      rows.forEach(function (row) {
+        body.appendChild(row);
+      });
+    });
+  });
+})();
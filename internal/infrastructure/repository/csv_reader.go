@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
-	"os"
-	"path/filepath"
-	"sync"
+	"io/fs"
+	"iter"
 	"time"
 
 	"github.com/nmmugia/reconciliation-service/internal/domain"
@@ -14,37 +18,131 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-type CsvLedgerReader struct{}
+type CsvLedgerReader struct {
+	fsys         fs.FS
+	encoding     string
+	typeAliases  domain.TransactionTypeAliases
+	rejected     *domain.RejectionLog
+	fingerprints *domain.FingerprintLog
+}
 
 func NewCsvLedgerReader() *CsvLedgerReader {
-	return &CsvLedgerReader{}
+	return NewCsvLedgerReaderFS(nil)
+}
+
+// NewCsvLedgerReaderFS resolves file paths against fsys instead of the OS filesystem.
+func NewCsvLedgerReaderFS(fsys fs.FS) *CsvLedgerReader {
+	return &CsvLedgerReader{fsys: fsys, typeAliases: domain.DefaultTransactionTypeAliases}
+}
+
+// WithTypeAliases replaces the spellings accepted for transaction types in
+// system transaction files. Rows with other types are rejected.
+func (r *CsvLedgerReader) WithTypeAliases(aliases domain.TransactionTypeAliases) *CsvLedgerReader {
+	r.typeAliases = aliases
+	return r
+}
+
+// WithEncoding overrides encoding detection for every file the reader opens;
+// see ParseEncoding for the accepted names.
+func (r *CsvLedgerReader) WithEncoding(encoding string) *CsvLedgerReader {
+	r.encoding = encoding
+	return r
+}
+
+// WithRejectionLog reports the rows the reader skips to log.
+func (r *CsvLedgerReader) WithRejectionLog(log *domain.RejectionLog) *CsvLedgerReader {
+	r.rejected = log
+	return r
 }
 
-func (r *CsvLedgerReader) ReadSystemTransactions(filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
-	file, err := os.Open(filePath)
+// WithFingerprintLog reports the SHA-256 of every bank statement the reader
+// reads to the end to log, for the duplicate statement check.
+func (r *CsvLedgerReader) WithFingerprintLog(log *domain.FingerprintLog) *CsvLedgerReader {
+	r.fingerprints = log
+	return r
+}
+
+func (r *CsvLedgerReader) newCsvReader(file io.Reader, filePath string) (*csv.Reader, error) {
+	text, err := decodeText(file, r.encoding)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode '%s': %w", filePath, err)
+	}
+	reader := csv.NewReader(text)
+	reader.TrimLeadingSpace = true
+	return reader, nil
+}
+
+func (r *CsvLedgerReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	file, err := openSingleInput(r.fsys, filePath)
 	if err != nil {
 		return nil, fmt.Errorf("could not open system transaction file '%s': %w", filePath, err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.TrimLeadingSpace = true
+	return collect(func(yield func(domain.SystemTransaction) bool) error {
+		return r.parseSystemTransactions(ctx, file, filePath, startDate, endDate, yield)
+	})
+}
+
+// SystemTransactions streams the file record by record instead of loading it.
+func (r *CsvLedgerReader) SystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) iter.Seq2[domain.SystemTransaction, error] {
+	return func(yield func(domain.SystemTransaction, error) bool) {
+		file, err := openSingleInput(r.fsys, filePath)
+		if err != nil {
+			yield(domain.SystemTransaction{}, fmt.Errorf("could not open system transaction file '%s': %w", filePath, err))
+			return
+		}
+		defer file.Close()
+
+		err = r.parseSystemTransactions(ctx, file, filePath, startDate, endDate, func(tx domain.SystemTransaction) bool {
+			return yield(tx, nil)
+		})
+		if err != nil {
+			yield(domain.SystemTransaction{}, err)
+		}
+	}
+}
+
+func (r *CsvLedgerReader) ReadSystemTransactionsFrom(ctx context.Context, source domain.InputSource, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	file, err := decompress(source.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not open system transactions '%s': %w", source.Name, err)
+	}
+	defer file.Close()
+
+	return collect(func(yield func(domain.SystemTransaction) bool) error {
+		return r.parseSystemTransactions(ctx, file, source.Name, startDate, endDate, yield)
+	})
+}
+
+// parseSystemTransactions hands every transaction to yield and stops without
+// an error once yield returns false.
+func (r *CsvLedgerReader) parseSystemTransactions(ctx context.Context, file io.Reader, filePath string, startDate, endDate time.Time, yield func(domain.SystemTransaction) bool) error {
+	reader, err := r.newCsvReader(file, filePath)
+	if err != nil {
+		return err
+	}
 	if _, err := reader.Read(); err != nil {
-		return nil, fmt.Errorf("could not read header from '%s': %w", filePath, err)
+		return fmt.Errorf("could not read header from '%s': %w", filePath, err)
 	}
 
-	var transactions []domain.SystemTransaction
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		record, err := reader.Read()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error reading record from '%s': %w", filePath, err)
+			return fmt.Errorf("error reading record from '%s': %w", filePath, err)
 		}
 
+		line, _ := reader.FieldPos(0)
+
 		txTime, err := time.Parse(time.RFC3339, record[3])
 		if err != nil {
+			rejectRow(r.rejected, filePath, line, "invalid transaction time '%s'", record[3])
 			continue
 		}
 
@@ -52,80 +150,155 @@ func (r *CsvLedgerReader) ReadSystemTransactions(filePath string, startDate, end
 			continue
 		}
 
+		if record[0] == "" {
+			rejectRow(r.rejected, filePath, line, "missing transaction ID")
+			continue
+		}
+
 		amount, err := decimal.NewFromString(record[1])
 		if err != nil {
+			rejectRow(r.rejected, filePath, line, "invalid amount '%s'", record[1])
+			continue
+		}
+
+		txType, err := r.typeAliases.Normalize(record[2])
+		if err != nil {
+			rejectRow(r.rejected, filePath, line, "%v", err)
 			continue
 		}
 
-		transactions = append(transactions, domain.SystemTransaction{
+		tx := domain.SystemTransaction{
 			ID:              record[0],
 			Amount:          amount,
-			Type:            domain.TransactionType(record[2]),
+			Type:            txType,
 			TransactionTime: txTime,
-		})
+		}
+		if !yield(tx) {
+			return nil
+		}
 	}
-	return transactions, nil
 }
 
-func (r *CsvLedgerReader) ReadBankTransactions(filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
-	var allBankTransactions []domain.BankTransaction
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	errChan := make(chan error, len(filePaths))
-
+func (r *CsvLedgerReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	var sources []inputSource
+	var openErrs []error
 	for _, path := range filePaths {
-		wg.Add(1)
-		go func(filePath string) {
-			defer wg.Done()
-			bankName := filepath.Base(filePath)
-			transactions, err := r.parseSingleBankStatement(filePath, bankName, startDate, endDate)
-			if err != nil {
-				errChan <- err
+		fileSources, closer, err := openInputSources(r.fsys, path)
+		if err != nil {
+			openErrs = append(openErrs, &domain.SourceError{Source: path, Err: fmt.Errorf("could not open bank statement file '%s': %w", path, err)})
+			continue
+		}
+		defer closer.Close()
+		sources = append(sources, fileSources...)
+	}
+
+	transactions, err := r.readBankSources(ctx, sources, startDate, endDate)
+	if ctx.Err() != nil {
+		return nil, err
+	}
+	return transactions, errors.Join(append(openErrs, err)...)
+}
+
+// BankTransactions streams the statements one file after another, in the
+// order given.
+func (r *CsvLedgerReader) BankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		for _, filePath := range filePaths {
+			if !r.yieldBankFile(ctx, filePath, startDate, endDate, yield) {
 				return
 			}
-			mu.Lock()
-			allBankTransactions = append(allBankTransactions, transactions...)
-			mu.Unlock()
-		}(path)
+		}
 	}
+}
 
-	wg.Wait()
-	close(errChan)
+// yieldBankFile reports failures as source errors and returns false once the
+// consumer stops.
+func (r *CsvLedgerReader) yieldBankFile(ctx context.Context, filePath string, startDate, endDate time.Time, yield func(domain.BankTransaction, error) bool) bool {
+	sources, closer, err := openInputSources(r.fsys, filePath)
+	if err != nil {
+		return yield(domain.BankTransaction{}, &domain.SourceError{Source: filePath, Err: fmt.Errorf("could not open bank statement file '%s': %w", filePath, err)})
+	}
+	defer closer.Close()
 
-	for err := range errChan {
-		if err != nil {
-			return nil, err
+	for _, source := range sources {
+		stopped := false
+		err := r.parseSingleBankStatement(ctx, source, startDate, endDate, func(tx domain.BankTransaction) bool {
+			stopped = !yield(tx, nil)
+			return !stopped
+		})
+		if stopped {
+			return false
+		}
+		if err != nil && !yield(domain.BankTransaction{}, &domain.SourceError{Source: source.path, Err: err}) {
+			return false
 		}
 	}
+	return true
+}
+
+func (r *CsvLedgerReader) ReadBankTransactionsFrom(ctx context.Context, sources []domain.InputSource, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	inputs := make([]inputSource, len(sources))
+	for i, source := range sources {
+		inputs[i] = streamSource(source.Name, source.Name, source.Reader)
+	}
+	return r.readBankSources(ctx, inputs, startDate, endDate)
+}
 
-	return allBankTransactions, nil
+func (r *CsvLedgerReader) readBankSources(ctx context.Context, sources []inputSource, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.path
+	}
+	return readConcurrently(ctx, names, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+		return collect(func(yield func(domain.BankTransaction) bool) error {
+			return r.parseSingleBankStatement(ctx, sources[i], startDate, endDate, yield)
+		})
+	})
 }
 
-func (r *CsvLedgerReader) parseSingleBankStatement(filePath, bankName string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
-	file, err := os.Open(filePath)
+func (r *CsvLedgerReader) parseSingleBankStatement(ctx context.Context, source inputSource, startDate, endDate time.Time, yield func(domain.BankTransaction) bool) error {
+	filePath, bankName := source.path, source.name
+	file, err := source.open()
 	if err != nil {
-		return nil, fmt.Errorf("could not open bank statement file '%s': %w", filePath, err)
+		return fmt.Errorf("could not open bank statement file '%s': %w", filePath, err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.TrimLeadingSpace = true
-	if _, err := reader.Read(); err != nil {
-		return nil, fmt.Errorf("could not read header from '%s': %w", filePath, err)
+	// The content is hashed as it is read, for the duplicate statement check.
+	content := sha256.New()
+	var input io.Reader = file
+	if r.fingerprints != nil {
+		input = io.TeeReader(file, content)
 	}
+	reader, err := r.newCsvReader(input, filePath)
+	if err != nil {
+		return err
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("could not read header from '%s': %w", filePath, err)
+	}
+	// Statements may add a running balance column after the required ones.
+	balanceCol := findColumn(header, bankBalanceHeaders)
 
-	var transactions []domain.BankTransaction
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		record, err := reader.Read()
 		if err == io.EOF {
-			break
+			reportFingerprint(r.fingerprints, filePath, content)
+			return nil
 		}
 		if err != nil {
-			return nil, fmt.Errorf("error reading record from '%s': %w", filePath, err)
+			return fmt.Errorf("error reading record from '%s': %w", filePath, err)
 		}
 
+		line, _ := reader.FieldPos(0)
+
 		date, err := time.Parse("2006-01-02", record[2])
 		if err != nil {
+			rejectRow(r.rejected, filePath, line, "invalid date '%s'", record[2])
 			continue
 		}
 
@@ -135,15 +308,37 @@ func (r *CsvLedgerReader) parseSingleBankStatement(filePath, bankName string, st
 
 		amount, err := decimal.NewFromString(record[1])
 		if err != nil {
+			rejectRow(r.rejected, filePath, line, "invalid amount '%s'", record[1])
 			continue
 		}
 
-		transactions = append(transactions, domain.BankTransaction{
+		balance, err := parseBalance(cellAt(record, balanceCol))
+		if err != nil {
+			rejectRow(r.rejected, filePath, line, "invalid balance '%s'", cellAt(record, balanceCol))
+			continue
+		}
+
+		tx := domain.BankTransaction{
 			ID:       record[0],
 			Amount:   amount,
 			Date:     date,
 			BankName: bankName,
-		})
+			Source:   filePath,
+			Balance:  balance,
+		}
+		if !yield(tx) {
+			return nil
+		}
 	}
-	return transactions, nil
+}
+
+// reportFingerprint reports the SHA-256 of a source that was read to the
+// end to log.
+func reportFingerprint(log *domain.FingerprintLog, source string, content hash.Hash) {
+	log.Add(source, hex.EncodeToString(content.Sum(nil)))
+}
+
+// rejectRow reports a skipped row to log.
+func rejectRow(log *domain.RejectionLog, source string, line int, format string, args ...any) {
+	log.Add(domain.RejectedRow{Source: source, Line: line, Reason: fmt.Sprintf(format, args...)})
 }
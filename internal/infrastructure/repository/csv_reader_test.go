@@ -1,11 +1,18 @@
 package repository
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,20 +38,20 @@ func TestCsvLedgerReader_ReadSystemTransactions(t *testing.T) {
 sys001,100,CREDIT,2023-01-15T10:00:00Z
 sys002,200,DEBIT,2023-02-01T10:00:00Z`
 		filePath := createTempCsv(t, content)
-		txs, err := reader.ReadSystemTransactions(filePath, startDate, endDate)
+		txs, err := reader.ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
 		require.NoError(t, err)
 		assert.Len(t, txs, 1)
 		assert.Equal(t, "sys001", txs[0].ID)
 	})
 
 	t.Run("file not found", func(t *testing.T) {
-		_, err := reader.ReadSystemTransactions("non_existent_file.csv", startDate, endDate)
+		_, err := reader.ReadSystemTransactions(context.Background(), "non_existent_file.csv", startDate, endDate)
 		assert.Error(t, err)
 	})
 
 	t.Run("bad header read", func(t *testing.T) {
 		filePath := createTempCsv(t, "")
-		_, err := reader.ReadSystemTransactions(filePath, startDate, endDate)
+		_, err := reader.ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
 		assert.Error(t, err)
 	})
 
@@ -52,7 +59,7 @@ sys002,200,DEBIT,2023-02-01T10:00:00Z`
 		content := `trxID,amount,type,transactionTime
 "sys001,100,CREDIT,2023-01-15T10:00:00Z`
 		filePath := createTempCsv(t, content)
-		_, err := reader.ReadSystemTransactions(filePath, startDate, endDate)
+		_, err := reader.ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
 		assert.Error(t, err)
 	})
 
@@ -60,7 +67,7 @@ sys002,200,DEBIT,2023-02-01T10:00:00Z`
 		content := `trxID,amount,type,transactionTime
 sys001,100,CREDIT,2023/01/15`
 		filePath := createTempCsv(t, content)
-		txs, err := reader.ReadSystemTransactions(filePath, startDate, endDate)
+		txs, err := reader.ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
 		require.NoError(t, err)
 		assert.Len(t, txs, 0)
 	})
@@ -69,10 +76,53 @@ sys001,100,CREDIT,2023/01/15`
 		content := `trxID,amount,type,transactionTime
 sys001,abc,CREDIT,2023-01-15T10:00:00Z`
 		filePath := createTempCsv(t, content)
-		txs, err := reader.ReadSystemTransactions(filePath, startDate, endDate)
+		txs, err := reader.ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
 		require.NoError(t, err)
 		assert.Len(t, txs, 0)
 	})
+
+	t.Run("transaction types are normalised", func(t *testing.T) {
+		content := `trxID,amount,type,transactionTime
+sys001,100,credit,2023-01-15T10:00:00Z
+sys002,100, DR ,2023-01-15T10:00:00Z
+sys003,100,REFUND,2023-01-15T10:00:00Z
+sys004,100,KR,2023-01-15T10:00:00Z`
+		filePath := createTempCsv(t, content)
+		log := &domain.RejectionLog{}
+		txs, err := NewCsvLedgerReader().WithRejectionLog(log).ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+		assert.Equal(t, domain.Credit, txs[0].Type)
+		assert.Equal(t, domain.Debit, txs[1].Type)
+		assert.Equal(t, []domain.RejectedRow{
+			{Source: filePath, Line: 4, Reason: "unknown transaction type 'REFUND'"},
+			{Source: filePath, Line: 5, Reason: "unknown transaction type 'KR'"},
+		}, log.Rows())
+
+		aliases, err := ParseTypeAliases("KR=CREDIT")
+		require.NoError(t, err)
+		txs, err = NewCsvLedgerReader().WithTypeAliases(aliases).ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 3)
+		assert.Equal(t, domain.Credit, txs[2].Type)
+	})
+
+	t.Run("rejected rows are reported", func(t *testing.T) {
+		content := `trxID,amount,type,transactionTime
+sys001,abc,CREDIT,2023-01-15T10:00:00Z
+sys002,100,CREDIT,2023/01/15
+sys003,100,CREDIT,2023-02-15T10:00:00Z
+,100,CREDIT,2023-01-16T10:00:00Z`
+		filePath := createTempCsv(t, content)
+		log := &domain.RejectionLog{}
+		_, err := NewCsvLedgerReader().WithRejectionLog(log).ReadSystemTransactions(context.Background(), filePath, startDate, endDate)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.RejectedRow{
+			{Source: filePath, Line: 2, Reason: "invalid amount 'abc'"},
+			{Source: filePath, Line: 3, Reason: "invalid transaction time '2023/01/15'"},
+			{Source: filePath, Line: 5, Reason: "missing transaction ID"},
+		}, log.Rows())
+	})
 }
 
 func TestCsvLedgerReader_ReadBankTransactions(t *testing.T) {
@@ -89,7 +139,7 @@ bank003,300,2023-02-10`
 		file1 := createTempCsv(t, content1)
 		file2 := createTempCsv(t, content2)
 
-		txs, err := reader.ReadBankTransactions([]string{file1, file2}, startDate, endDate)
+		txs, err := reader.ReadBankTransactions(context.Background(), []string{file1, file2}, startDate, endDate)
 		require.NoError(t, err)
 		assert.Len(t, txs, 2)
 		var bankNames []string
@@ -104,13 +154,30 @@ bank003,300,2023-02-10`
 		content1 := `unique_identifier,amount,date
 bank001,100,2023-01-15`
 		file1 := createTempCsv(t, content1)
-		_, err := reader.ReadBankTransactions([]string{file1, "non_existent_file.csv"}, startDate, endDate)
+		_, err := reader.ReadBankTransactions(context.Background(), []string{file1, "non_existent_file.csv"}, startDate, endDate)
 		assert.Error(t, err)
 	})
 
+	t.Run("all failing files are reported with the readable transactions", func(t *testing.T) {
+		good := createTempCsv(t, `unique_identifier,amount,date
+bank001,100,2023-01-15`)
+		badRecord := createTempCsv(t, `unique_identifier,amount,date
+"bank002,100,2023-01-15`)
+		txs, err := reader.ReadBankTransactions(context.Background(), []string{"missing.csv", good, badRecord}, startDate, endDate)
+		require.Len(t, txs, 1)
+		assert.Equal(t, "bank001", txs[0].ID)
+
+		failures := domain.SourceErrors(err)
+		require.Len(t, failures, 2)
+		assert.Equal(t, "missing.csv", failures[0].Source)
+		assert.Equal(t, badRecord, failures[1].Source)
+		assert.ErrorContains(t, err, "missing.csv")
+		assert.ErrorContains(t, err, badRecord)
+	})
+
 	t.Run("one file has bad header", func(t *testing.T) {
 		file1 := createTempCsv(t, "")
-		_, err := reader.ReadBankTransactions([]string{file1}, startDate, endDate)
+		_, err := reader.ReadBankTransactions(context.Background(), []string{file1}, startDate, endDate)
 		assert.Error(t, err)
 	})
 
@@ -118,7 +185,7 @@ bank001,100,2023-01-15`
 		content := `unique_identifier,amount,date
 "bank001,100,2023-01-15`
 		file1 := createTempCsv(t, content)
-		_, err := reader.ReadBankTransactions([]string{file1}, startDate, endDate)
+		_, err := reader.ReadBankTransactions(context.Background(), []string{file1}, startDate, endDate)
 		assert.Error(t, err)
 	})
 
@@ -126,7 +193,7 @@ bank001,100,2023-01-15`
 		content := `unique_identifier,amount,date
 bank001,100,not-a-date`
 		filePath := createTempCsv(t, content)
-		txs, err := reader.ReadBankTransactions([]string{filePath}, startDate, endDate)
+		txs, err := reader.ReadBankTransactions(context.Background(), []string{filePath}, startDate, endDate)
 		require.NoError(t, err)
 		assert.Empty(t, txs)
 	})
@@ -135,8 +202,246 @@ bank001,100,not-a-date`
 		content := `unique_identifier,amount,date
 bank001,not-an-amount,2023-01-15`
 		filePath := createTempCsv(t, content)
-		txs, err := reader.ReadBankTransactions([]string{filePath}, startDate, endDate)
+		txs, err := reader.ReadBankTransactions(context.Background(), []string{filePath}, startDate, endDate)
 		require.NoError(t, err)
 		assert.Empty(t, txs)
 	})
+
+	t.Run("content fingerprints", func(t *testing.T) {
+		content := `unique_identifier,amount,date
+bank001,100,2023-01-15
+bank002,200,2023-02-10`
+		original := createTempCsv(t, content)
+		copied := createTempCsv(t, content)
+		trimmed := createTempCsv(t, `unique_identifier,amount,date
+bank001,100,2023-01-15`)
+		log := &domain.FingerprintLog{}
+		_, err := NewCsvLedgerReader().WithFingerprintLog(log).ReadBankTransactions(context.Background(), []string{original, copied, trimmed}, startDate, endDate)
+		require.NoError(t, err)
+
+		fingerprints := log.Fingerprints()
+		require.Len(t, fingerprints, 3)
+		assert.Len(t, fingerprints[original], 64)
+		assert.Equal(t, fingerprints[original], fingerprints[copied])
+		assert.NotEqual(t, fingerprints[original], fingerprints[trimmed], "rows outside the period are part of the content")
+	})
+
+	t.Run("balance column", func(t *testing.T) {
+		content := `unique_identifier,amount,date,Balance
+bank001,100,2023-01-15,1100.00
+bank002,-50,2023-01-16,
+bank003,25,2023-01-17,lots`
+		filePath := createTempCsv(t, content)
+		log := &domain.RejectionLog{}
+		txs, err := NewCsvLedgerReader().WithRejectionLog(log).ReadBankTransactions(context.Background(), []string{filePath}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+		assert.True(t, txs[0].Balance.Valid)
+		assert.Equal(t, "1100", txs[0].Balance.Decimal.String())
+		assert.False(t, txs[1].Balance.Valid)
+		assert.Equal(t, []domain.RejectedRow{{Source: filePath, Line: 4, Reason: "invalid balance 'lots'"}}, log.Rows())
+	})
+
+	t.Run("rejected rows are reported", func(t *testing.T) {
+		content := `unique_identifier,amount,date
+bank001,100,2023-01-15
+bank002,100,not-a-date
+bank003,not-an-amount,2023-01-15`
+		filePath := createTempCsv(t, content)
+		log := &domain.RejectionLog{}
+		txs, err := NewCsvLedgerReader().WithRejectionLog(log).ReadBankTransactions(context.Background(), []string{filePath}, startDate, endDate)
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+		assert.Equal(t, []domain.RejectedRow{
+			{Source: filePath, Line: 3, Reason: "invalid date 'not-a-date'"},
+			{Source: filePath, Line: 4, Reason: "invalid amount 'not-an-amount'"},
+		}, log.Rows())
+	})
+}
+
+func TestCsvLedgerReader_Encodings(t *testing.T) {
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	statement := "unique_identifier,amount,date\nbank\u00e9,100,2023-01-15\n"
+
+	inputs := map[string][]byte{
+		"utf-8 bom":    append([]byte{0xEF, 0xBB, 0xBF}, statement...),
+		"utf-16le":     append([]byte{0xFF, 0xFE}, utf16Bytes(statement, false)...),
+		"windows-1252": []byte("unique_identifier,amount,date\nbank\xe9,100,2023-01-15\n"),
+	}
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			source := domain.InputSource{Name: "bank.csv", Reader: bytes.NewReader(input)}
+			txs, err := NewCsvLedgerReader().ReadBankTransactionsFrom(context.Background(), []domain.InputSource{source}, startDate, endDate)
+			require.NoError(t, err)
+			require.Len(t, txs, 1)
+			assert.Equal(t, "bank\u00e9", txs[0].ID)
+		})
+	}
+
+	t.Run("system transactions with bom", func(t *testing.T) {
+		source := domain.InputSource{Name: "system.csv", Reader: strings.NewReader("\ufefftrxID,amount,type,transactionTime\nsys001,100,CREDIT,2023-01-15T10:00:00Z")}
+		txs, err := NewCsvLedgerReader().ReadSystemTransactionsFrom(context.Background(), source, startDate, endDate)
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+	})
+
+	t.Run("explicit encoding", func(t *testing.T) {
+		source := domain.InputSource{Name: "bank.csv", Reader: strings.NewReader("unique_identifier,amount,date\nbank\u00e9,100,2023-01-15\n")}
+		txs, err := NewCsvLedgerReader().WithEncoding(EncodingWindows1252).ReadBankTransactionsFrom(context.Background(), []domain.InputSource{source}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Equal(t, "bank\u00c3\u00a9", txs[0].ID)
+	})
+}
+
+func TestCsvLedgerReader_Iterators(t *testing.T) {
+	reader := NewCsvLedgerReader()
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	system := createTempCsv(t, `trxID,amount,type,transactionTime
+sys001,100,CREDIT,2023-01-15T10:00:00Z
+sys002,200,DEBIT,2023-01-16T10:00:00Z
+sys003,300,DEBIT,2023-03-16T10:00:00Z`)
+	bca := createTempCsv(t, `unique_identifier,amount,date
+bank001,100,2023-01-15
+bank002,200,2023-01-16`)
+	bri := createTempCsv(t, `unique_identifier,amount,date
+bank003,300,2023-01-17`)
+
+	t.Run("same transactions as the slice API", func(t *testing.T) {
+		expectedSys, err := reader.ReadSystemTransactions(context.Background(), system, startDate, endDate)
+		require.NoError(t, err)
+		sysTxs, errs := drain(reader.SystemTransactions(context.Background(), system, startDate, endDate))
+		assert.Empty(t, errs)
+		assert.Equal(t, expectedSys, sysTxs)
+
+		expectedBank, err := reader.ReadBankTransactions(context.Background(), []string{bca, bri}, startDate, endDate)
+		require.NoError(t, err)
+		bankTxs, errs := drain(reader.BankTransactions(context.Background(), []string{bca, bri}, startDate, endDate))
+		assert.Empty(t, errs)
+		assert.Equal(t, expectedBank, bankTxs)
+	})
+
+	t.Run("stops when the consumer stops", func(t *testing.T) {
+		var ids []string
+		for tx, err := range reader.BankTransactions(context.Background(), []string{bca, bri}, startDate, endDate) {
+			require.NoError(t, err)
+			ids = append(ids, tx.ID)
+			break
+		}
+		assert.Equal(t, []string{"bank001"}, ids)
+	})
+
+	t.Run("continues with the next file after an error", func(t *testing.T) {
+		bankTxs, errs := drain(reader.BankTransactions(context.Background(), []string{"missing.csv", bri}, startDate, endDate))
+		require.Len(t, errs, 1)
+		assert.Equal(t, "missing.csv", domain.SourceErrors(errs[0])[0].Source)
+		assert.Len(t, bankTxs, 1)
+
+		_, errs = drain(reader.SystemTransactions(context.Background(), "missing.csv", startDate, endDate))
+		assert.Len(t, errs, 1)
+	})
+}
+
+func TestCsvLedgerReader_FS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fixtures/system.csv": {Data: []byte("trxID,amount,type,transactionTime\nsys001,100,CREDIT,2023-01-15T10:00:00Z")},
+		"fixtures/bank.csv":   {Data: []byte("unique_identifier,amount,date\nbank001,100,2023-01-15")},
+		"fixtures/bundle.zip": {Data: zipBytes(t, map[string][]byte{"bri.csv": []byte("unique_identifier,amount,date\nbank002,200,2023-01-16")})},
+	}
+	reader := NewCsvLedgerReaderFS(fsys)
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	sysTxs, err := reader.ReadSystemTransactions(context.Background(), "fixtures/system.csv", startDate, endDate)
+	require.NoError(t, err)
+	assert.Len(t, sysTxs, 1)
+
+	bankTxs, err := reader.ReadBankTransactions(context.Background(), []string{"fixtures/bank.csv", "fixtures/bundle.zip"}, startDate, endDate)
+	require.NoError(t, err)
+	banks := make(map[string]string)
+	for _, tx := range bankTxs {
+		banks[tx.ID] = tx.BankName
+	}
+	assert.Equal(t, map[string]string{"bank001": "bank.csv", "bank002": "bundle.zip/bri.csv"}, banks)
+
+	_, err = reader.ReadSystemTransactions(context.Background(), "fixtures/missing.csv", startDate, endDate)
+	assert.Error(t, err)
+}
+
+func TestCsvLedgerReader_Streams(t *testing.T) {
+	reader := NewCsvLedgerReader()
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("system transactions", func(t *testing.T) {
+		source := domain.InputSource{Name: "upload", Reader: strings.NewReader("trxID,amount,type,transactionTime\nsys001,100,CREDIT,2023-01-15T10:00:00Z")}
+		txs, err := reader.ReadSystemTransactionsFrom(context.Background(), source, startDate, endDate)
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+	})
+
+	t.Run("bank statements", func(t *testing.T) {
+		sources := []domain.InputSource{
+			{Name: "bca", Reader: strings.NewReader("unique_identifier,amount,date\nbank001,100,2023-01-15")},
+			{Name: "bri", Reader: bytes.NewReader(gzipBytes(t, "unique_identifier,amount,date\nbank002,200,2023-01-16"))},
+		}
+		txs, err := reader.ReadBankTransactionsFrom(context.Background(), sources, startDate, endDate)
+		require.NoError(t, err)
+		banks := make(map[string]string)
+		for _, tx := range txs {
+			banks[tx.ID] = tx.BankName
+		}
+		assert.Equal(t, map[string]string{"bank001": "bca", "bank002": "bri"}, banks)
+	})
+
+	t.Run("bad header", func(t *testing.T) {
+		_, err := reader.ReadBankTransactionsFrom(context.Background(), []domain.InputSource{{Name: "empty", Reader: strings.NewReader("")}}, startDate, endDate)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "empty")
+	})
+}
+
+func TestCsvLedgerReader_ReadBankTransactions_Order(t *testing.T) {
+	reader := NewCsvLedgerReader()
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	var files, expected []string
+	for i := 0; i < 8; i++ {
+		content := "unique_identifier,amount,date\n"
+		for line := 0; line < 3; line++ {
+			id := fmt.Sprintf("file%d-line%d", i, line)
+			content += id + ",100,2023-01-15\n"
+			expected = append(expected, id)
+		}
+		files = append(files, createTempCsv(t, content))
+	}
+
+	for run := 0; run < 5; run++ {
+		txs, err := reader.ReadBankTransactions(context.Background(), files, startDate, endDate)
+		require.NoError(t, err)
+		var ids []string
+		for _, tx := range txs {
+			ids = append(ids, tx.ID)
+		}
+		require.Equal(t, expected, ids)
+	}
+}
+
+func TestCsvLedgerReader_Cancelled(t *testing.T) {
+	reader := NewCsvLedgerReader()
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sysFile := createTempCsv(t, "trxID,amount,type,transactionTime\nsys001,100,CREDIT,2023-01-15T10:00:00Z")
+	_, err := reader.ReadSystemTransactions(ctx, sysFile, startDate, endDate)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	bankFile := createTempCsv(t, "unique_identifier,amount,date\nbank001,100,2023-01-15")
+	_, err = reader.ReadBankTransactions(ctx, []string{bankFile, bankFile}, startDate, endDate)
+	assert.ErrorIs(t, err, context.Canceled)
 }
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings accepted for CSV inputs. EncodingAuto sniffs byte order marks
+// and UTF-16 null bytes, and otherwise reads UTF-8, decoding bytes that are
+// not valid UTF-8 as Windows-1252.
+const (
+	EncodingAuto        = "auto"
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+)
+
+var encodingAliases = map[string]string{
+	"":             EncodingAuto,
+	"auto":         EncodingAuto,
+	"utf-8":        EncodingUTF8,
+	"utf8":         EncodingUTF8,
+	"utf-16le":     EncodingUTF16LE,
+	"utf16le":      EncodingUTF16LE,
+	"utf-16be":     EncodingUTF16BE,
+	"utf16be":      EncodingUTF16BE,
+	"windows-1252": EncodingWindows1252,
+	"cp1252":       EncodingWindows1252,
+}
+
+// ParseEncoding normalises an encoding name such as "UTF8" or "cp1252".
+func ParseEncoding(name string) (string, error) {
+	encoding, ok := encodingAliases[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("unknown encoding '%s', expected auto, utf-8, utf-16le, utf-16be or windows-1252", name)
+	}
+	return encoding, nil
+}
+
+// windows1252 maps the bytes 0x80-0x9F, which differ from Latin-1; undefined
+// positions decode to U+FFFD.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+func decodeWindows1252(b byte) rune {
+	if b >= 0x80 && b < 0xA0 {
+		return windows1252[b-0x80]
+	}
+	return rune(b)
+}
+
+// decodeText returns r as UTF-8 without a byte order mark. A BOM always wins
+// over the requested encoding, since it cannot be there by accident.
+func decodeText(r io.Reader, encoding string) (io.Reader, error) {
+	encoding, err := ParseEncoding(encoding)
+	if err != nil {
+		return nil, err
+	}
+	src := bufio.NewReader(r)
+	head, _ := src.Peek(3)
+
+	switch {
+	case len(head) >= 3 && head[0] == 0xEF && head[1] == 0xBB && head[2] == 0xBF:
+		src.Discard(3)
+		encoding = EncodingUTF8
+	case len(head) >= 2 && head[0] == 0xFF && head[1] == 0xFE:
+		src.Discard(2)
+		encoding = EncodingUTF16LE
+	case len(head) >= 2 && head[0] == 0xFE && head[1] == 0xFF:
+		src.Discard(2)
+		encoding = EncodingUTF16BE
+	case encoding == EncodingAuto && len(head) >= 2 && head[0] != 0 && head[1] == 0:
+		encoding = EncodingUTF16LE
+	case encoding == EncodingAuto && len(head) >= 2 && head[0] == 0 && head[1] != 0:
+		encoding = EncodingUTF16BE
+	}
+
+	switch encoding {
+	case EncodingUTF8:
+		return src, nil
+	case EncodingUTF16LE:
+		return &decodingReader{src: src, next: func(src *bufio.Reader) (rune, error) { return readUTF16(src, false) }}, nil
+	case EncodingUTF16BE:
+		return &decodingReader{src: src, next: func(src *bufio.Reader) (rune, error) { return readUTF16(src, true) }}, nil
+	case EncodingWindows1252:
+		return &decodingReader{src: src, next: func(src *bufio.Reader) (rune, error) {
+			b, err := src.ReadByte()
+			return decodeWindows1252(b), err
+		}}, nil
+	default:
+		return &autoReader{src: src}, nil
+	}
+}
+
+// autoReader passes UTF-8 on as it is read and only decodes rune by rune,
+// with readUTF8OrWindows1252, from the first byte that is not valid UTF-8.
+type autoReader struct {
+	src      *bufio.Reader
+	fallback *decodingReader
+}
+
+func (a *autoReader) Read(p []byte) (int, error) {
+	if a.fallback != nil {
+		return a.fallback.Read(p)
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if _, err := a.src.Peek(1); err != nil {
+		return 0, err
+	}
+	buffered, _ := a.src.Peek(min(a.src.Buffered(), len(p)))
+	n := validUTF8Prefix(buffered)
+	if n == 0 {
+		// An invalid byte, or a rune cut off at the end of the buffer.
+		head, _ := a.src.Peek(utf8.UTFMax)
+		r, size := utf8.DecodeRune(head)
+		if (r == utf8.RuneError && size == 1) || size > len(p) {
+			a.fallback = &decodingReader{src: a.src, next: readUTF8OrWindows1252}
+			return a.fallback.Read(p)
+		}
+		buffered, n = head, size
+	}
+	copy(p, buffered[:n])
+	a.src.Discard(n)
+	return n, nil
+}
+
+// validUTF8Prefix returns the length of the longest prefix of b made of
+// complete, valid UTF-8 runes.
+func validUTF8Prefix(b []byte) int {
+	if utf8.Valid(b) {
+		return len(b)
+	}
+	i := 0
+	for i < len(b) {
+		if b[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// decodingReader re-encodes the runes produced by next as UTF-8.
+type decodingReader struct {
+	src     *bufio.Reader
+	next    func(src *bufio.Reader) (rune, error)
+	pending []byte
+	err     error
+}
+
+func (d *decodingReader) Read(p []byte) (int, error) {
+	for len(d.pending) < len(p) && d.err == nil {
+		r, err := d.next(d.src)
+		if err != nil {
+			d.err = err
+			break
+		}
+		d.pending = utf8.AppendRune(d.pending, r)
+	}
+	if len(d.pending) == 0 {
+		return 0, d.err
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[:copy(d.pending, d.pending[n:])]
+	return n, nil
+}
+
+func readUTF8OrWindows1252(src *bufio.Reader) (rune, error) {
+	r, size, err := src.ReadRune()
+	if err != nil || r != utf8.RuneError || size != 1 {
+		return r, err
+	}
+	src.UnreadRune()
+	b, err := src.ReadByte()
+	return decodeWindows1252(b), err
+}
+
+func readUTF16(src *bufio.Reader, bigEndian bool) (rune, error) {
+	unit, err := readUTF16Unit(src, bigEndian)
+	if err != nil {
+		return 0, err
+	}
+	r := rune(unit)
+	if !utf16.IsSurrogate(r) {
+		return r, nil
+	}
+	low, err := readUTF16Unit(src, bigEndian)
+	if err != nil {
+		return 0, err
+	}
+	return utf16.DecodeRune(r, rune(low)), nil
+}
+
+func readUTF16Unit(src *bufio.Reader, bigEndian bool) (uint16, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(src, b[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, fmt.Errorf("truncated UTF-16 input: %w", err)
+		}
+		return 0, err
+	}
+	if bigEndian {
+		return uint16(b[0])<<8 | uint16(b[1]), nil
+	}
+	return uint16(b[1])<<8 | uint16(b[0]), nil
+}
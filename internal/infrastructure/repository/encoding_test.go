@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+	var out []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(unit>>8), byte(unit))
+		} else {
+			out = append(out, byte(unit), byte(unit>>8))
+		}
+	}
+	return out
+}
+
+func TestDecodeText(t *testing.T) {
+	text := "id,name\nb001,Café 😀\n"
+
+	testCases := []struct {
+		name     string
+		input    []byte
+		encoding string
+		expected string
+	}{
+		{"plain utf-8", []byte(text), "", text},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, text...), "", text},
+		{"utf-16le bom", append([]byte{0xFF, 0xFE}, utf16Bytes(text, false)...), "", text},
+		{"utf-16be bom", append([]byte{0xFE, 0xFF}, utf16Bytes(text, true)...), "", text},
+		{"utf-16le without bom", utf16Bytes(text, false), "", text},
+		{"bom wins over override", append([]byte{0xFF, 0xFE}, utf16Bytes(text, false)...), EncodingWindows1252, text},
+		{"windows-1252 detected", []byte("id,name\nb001,Caf\xe9 \x80\n"), "", "id,name\nb001,Café €\n"},
+		{"windows-1252 override", []byte("Caf\xc3\xa9"), "cp1252", "CafÃ©"},
+		{"windows-1252 after a long utf-8 prefix", []byte(strings.Repeat("Café 😀\n", 1000) + "Caf\xe9 Café"), "", strings.Repeat("Café 😀\n", 1000) + "Café Café"},
+		{"utf-16le override", utf16Bytes("id", false), "UTF16LE", "id"},
+		{"empty", nil, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := decodeText(strings.NewReader(string(tc.input)), tc.encoding)
+			require.NoError(t, err)
+			decoded, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(decoded))
+		})
+	}
+
+	t.Run("small reads", func(t *testing.T) {
+		input := "Café 😀 \x80 Café 😀"
+		for _, size := range []int{1, 2, 3, 5} {
+			r, err := decodeText(iotest.OneByteReader(strings.NewReader(input)), "")
+			require.NoError(t, err)
+			var decoded []byte
+			buf := make([]byte, size)
+			for {
+				n, err := r.Read(buf)
+				decoded = append(decoded, buf[:n]...)
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+			}
+			assert.Equal(t, "Café 😀 € Café 😀", string(decoded), "reads of %d bytes", size)
+		}
+	})
+
+	t.Run("truncated utf-16", func(t *testing.T) {
+		r, err := decodeText(strings.NewReader("\xff\xfei\x00d"), "")
+		require.NoError(t, err)
+		_, err = io.ReadAll(r)
+		assert.ErrorContains(t, err, "truncated UTF-16")
+	})
+
+	t.Run("unknown encoding", func(t *testing.T) {
+		_, err := decodeText(strings.NewReader(text), "ebcdic")
+		assert.ErrorContains(t, err, "unknown encoding 'ebcdic'")
+	})
+}
+
+func BenchmarkDecodeText(b *testing.B) {
+	utf8Input := []byte(strings.Repeat("b001,2025-06-01,Café Jakarta,150000.00\n", 20000))
+	windows1252Input := []byte(strings.Repeat("b001,2025-06-01,Caf\xe9 Jakarta,150000.00\n", 20000))
+
+	benchmarks := []struct {
+		name   string
+		input  []byte
+		decode func(r io.Reader) (io.Reader, error)
+	}{
+		{"baseline", utf8Input, func(r io.Reader) (io.Reader, error) { return bufio.NewReader(r), nil }},
+		{"auto utf-8", utf8Input, func(r io.Reader) (io.Reader, error) { return decodeText(r, EncodingAuto) }},
+		{"auto windows-1252", windows1252Input, func(r io.Reader) (io.Reader, error) { return decodeText(r, EncodingAuto) }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bm.input)))
+			for i := 0; i < b.N; i++ {
+				r, err := bm.decode(bytes.NewReader(bm.input))
+				require.NoError(b, err)
+				// Read as the CSV reader does, not through WriteTo.
+				_, err = io.Copy(io.Discard, struct{ io.Reader }{r})
+				require.NoError(b, err)
+			}
+		})
+	}
+}
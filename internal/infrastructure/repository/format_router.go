@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// FormatRouter dispatches bank statement files to a reader chosen by file extension.
+type FormatRouter struct {
+	fallback domain.BankStatementReader
+	readers  map[string]domain.BankStatementReader
+}
+
+func NewFormatRouter(fallback domain.BankStatementReader, readers map[string]domain.BankStatementReader) *FormatRouter {
+	normalized := make(map[string]domain.BankStatementReader, len(readers))
+	for ext, reader := range readers {
+		normalized[strings.ToLower(ext)] = reader
+	}
+	return &FormatRouter{fallback: fallback, readers: normalized}
+}
+
+func (r *FormatRouter) readerFor(name string) domain.BankStatementReader {
+	if reader, ok := r.readers[strings.ToLower(filepath.Ext(name))]; ok {
+		return reader
+	}
+	return r.fallback
+}
+
+// ReadBankTransactions reads every file with its reader in parallel and keeps
+// the transactions in the order the files were given.
+func (r *FormatRouter) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	return readConcurrently(ctx, filePaths, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+		return r.readerFor(filePaths[i]).ReadBankTransactions(ctx, filePaths[i:i+1], startDate, endDate)
+	})
+}
+
+// BankTransactions streams the files one after another, each with its reader.
+func (r *FormatRouter) BankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		for _, filePath := range filePaths {
+			for tx, err := range iterateBankTransactions(ctx, r.readerFor(filePath), []string{filePath}, startDate, endDate) {
+				if !yield(tx, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ReadBankTransactionsFrom routes streams by the extension of their name. Every
+// reader involved must implement domain.BankStatementStreamReader.
+func (r *FormatRouter) ReadBankTransactionsFrom(ctx context.Context, sources []domain.InputSource, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	return readConcurrently(ctx, sourceNames(sources), func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+		streamReader, ok := r.readerFor(sources[i].Name).(domain.BankStatementStreamReader)
+		if !ok {
+			return nil, fmt.Errorf("reader for '%s' does not support streams", sources[i].Name)
+		}
+		return streamReader.ReadBankTransactionsFrom(ctx, sources[i:i+1], startDate, endDate)
+	})
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingReader struct {
+	name  string
+	mu    sync.Mutex
+	paths []string
+	err   error
+}
+
+func (r *recordingReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	r.mu.Lock()
+	r.paths = append(r.paths, filePaths...)
+	r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	var txs []domain.BankTransaction
+	for _, path := range filePaths {
+		txs = append(txs, domain.BankTransaction{ID: r.name + ":" + path})
+	}
+	return txs, nil
+}
+
+func TestFormatRouter_ReadBankTransactions(t *testing.T) {
+	t.Run("routes by extension and keeps input order", func(t *testing.T) {
+		csvReader := &recordingReader{name: "csv"}
+		xlsxReader := &recordingReader{name: "xlsx"}
+		router := NewFormatRouter(csvReader, map[string]domain.BankStatementReader{".XLSX": xlsxReader})
+
+		txs, err := router.ReadBankTransactions(context.Background(), []string{"a.csv", "b.xlsx", "c.CSV", "d.XLSX"}, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a.csv", "c.CSV"}, csvReader.paths)
+		assert.ElementsMatch(t, []string{"b.xlsx", "d.XLSX"}, xlsxReader.paths)
+		var ids []string
+		for _, tx := range txs {
+			ids = append(ids, tx.ID)
+		}
+		assert.Equal(t, []string{"csv:a.csv", "xlsx:b.xlsx", "csv:c.CSV", "xlsx:d.XLSX"}, ids)
+	})
+
+	t.Run("routes streams by name", func(t *testing.T) {
+		csvReader := NewCsvLedgerReader()
+		router := NewFormatRouter(csvReader, map[string]domain.BankStatementReader{".xlsx": &recordingReader{}})
+
+		txs, err := router.ReadBankTransactionsFrom(context.Background(), []domain.InputSource{
+			{Name: "bca.csv", Reader: strings.NewReader("unique_identifier,amount,date\nbank001,100,2023-01-15")},
+		}, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+
+		_, err = router.ReadBankTransactionsFrom(context.Background(), []domain.InputSource{{Name: "bca.xlsx", Reader: strings.NewReader("")}}, time.Time{}, time.Time{})
+		assert.Error(t, err)
+	})
+
+	t.Run("iterates files in order with their readers", func(t *testing.T) {
+		csvReader := &recordingReader{name: "csv"}
+		xlsxReader := &recordingReader{name: "xlsx"}
+		router := NewFormatRouter(csvReader, map[string]domain.BankStatementReader{".xlsx": xlsxReader})
+
+		txs, errs := drain(router.BankTransactions(context.Background(), []string{"a.csv", "b.xlsx", "c.csv"}, time.Time{}, time.Time{}))
+		assert.Empty(t, errs)
+		assert.Equal(t, []domain.BankTransaction{{ID: "csv:a.csv"}, {ID: "xlsx:b.xlsx"}, {ID: "csv:c.csv"}}, txs)
+	})
+
+	t.Run("reader error", func(t *testing.T) {
+		router := NewFormatRouter(&recordingReader{err: errors.New("boom")}, nil)
+		_, err := router.ReadBankTransactions(context.Background(), []string{"a.csv"}, time.Time{}, time.Time{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// StdinPath can be passed instead of a file path to read from standard input.
+const StdinPath = "-"
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	zipMagic      = []byte("PK\x03\x04")
+	emptyZipMagic = []byte("PK\x05\x06")
+)
+
+func isZip(magic []byte) bool {
+	return bytes.Equal(magic, zipMagic) || bytes.Equal(magic, emptyZipMagic)
+}
+
+var stdin io.Reader = os.Stdin
+
+type inputSource struct {
+	// path identifies the source in error messages, name is its short display name.
+	path string
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// openFile opens a path from fsys, or from the OS filesystem (including "-"
+// for stdin) when fsys is nil.
+func openFile(fsys fs.FS, filePath string) (io.ReadCloser, error) {
+	if fsys != nil {
+		return fsys.Open(filePath)
+	}
+	if filePath == StdinPath {
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(filePath)
+}
+
+// openInputSources resolves a path into readable sources. Gzip compression is
+// removed transparently and zip archives expand to one source per file inside.
+// The returned closer must be called once every source has been consumed.
+func openInputSources(fsys fs.FS, filePath string) ([]inputSource, io.Closer, error) {
+	file, err := openFile(fsys, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(filePath)), ".gz")
+	if fsys == nil && filePath == StdinPath {
+		name = "stdin"
+	}
+
+	br := bufio.NewReader(file)
+	if magic, _ := br.Peek(len(zipMagic)); isZip(magic) {
+		ra, size, err := readerAt(file, br)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		sources, err := zipSources(filePath, name, ra, size)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return sources, file, nil
+	}
+	return []inputSource{streamSource(filePath, name, br)}, file, nil
+}
+
+// readerAt gives random access to a zip archive, using the file directly when
+// it supports it and buffering the (already peeked) stream otherwise.
+func readerAt(file io.Reader, buffered io.Reader) (io.ReaderAt, int64, error) {
+	if ra, ok := file.(io.ReaderAt); ok {
+		if statter, ok := file.(interface{ Stat() (fs.FileInfo, error) }); ok {
+			if info, err := statter.Stat(); err == nil && info.Mode().IsRegular() {
+				return ra, info.Size(), nil
+			}
+		}
+	}
+	data, err := io.ReadAll(buffered)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// openSingleInput opens a path that must resolve to exactly one source.
+func openSingleInput(fsys fs.FS, filePath string) (io.ReadCloser, error) {
+	sources, closer, err := openInputSources(fsys, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) != 1 {
+		closer.Close()
+		return nil, fmt.Errorf("archive contains %d files, expected exactly one", len(sources))
+	}
+	rc, err := sources[0].open()
+	if err != nil {
+		closer.Close()
+		return nil, err
+	}
+	return &multiCloser{Reader: rc, closers: []io.Closer{rc, closer}}, nil
+}
+
+func streamSource(filePath, name string, r io.Reader) inputSource {
+	return inputSource{path: filePath, name: name, open: func() (io.ReadCloser, error) {
+		return decompress(r)
+	}}
+}
+
+func zipSources(archivePath, archiveName string, r io.ReaderAt, size int64) ([]inputSource, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("could not read zip archive: %w", err)
+	}
+	var sources []inputSource
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() || strings.HasPrefix(entry.Name, "__MACOSX/") || strings.HasPrefix(path.Base(entry.Name), ".") {
+			continue
+		}
+		sources = append(sources, inputSource{
+			path: archivePath + "/" + entry.Name,
+			name: archiveName + "/" + strings.TrimSuffix(entry.Name, ".gz"),
+			open: func() (io.ReadCloser, error) {
+				rc, err := entry.Open()
+				if err != nil {
+					return nil, err
+				}
+				dr, err := decompress(rc)
+				if err != nil {
+					rc.Close()
+					return nil, err
+				}
+				return &multiCloser{Reader: dr, closers: []io.Closer{dr, rc}}, nil
+			},
+		})
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("zip archive contains no files")
+	}
+	return sources, nil
+}
+
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not read gzip stream: %w", err)
+		}
+		return gz, nil
+	}
+	return io.NopCloser(br), nil
+}
+
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var firstErr error
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zipBytes(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func createTempFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func withStdin(t *testing.T, content []byte) {
+	t.Helper()
+	previous := stdin
+	stdin = bytes.NewReader(content)
+	t.Cleanup(func() { stdin = previous })
+}
+
+func readSources(t *testing.T, path string) map[string]string {
+	t.Helper()
+	sources, closer, err := openInputSources(nil, path)
+	require.NoError(t, err)
+	defer closer.Close()
+
+	contents := make(map[string]string)
+	for _, source := range sources {
+		rc, err := source.open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		contents[source.name] = string(data)
+	}
+	return contents
+}
+
+func TestOpenInputSources(t *testing.T) {
+	t.Run("plain file", func(t *testing.T) {
+		path := createTempFile(t, "bank.csv", []byte("a,b"))
+		assert.Equal(t, map[string]string{"bank.csv": "a,b"}, readSources(t, path))
+	})
+
+	t.Run("gzip file", func(t *testing.T) {
+		path := createTempFile(t, "bank.csv.gz", gzipBytes(t, "a,b"))
+		assert.Equal(t, map[string]string{"bank.csv": "a,b"}, readSources(t, path))
+	})
+
+	t.Run("gzip detected by content, not extension", func(t *testing.T) {
+		path := createTempFile(t, "bank.csv", gzipBytes(t, "a,b"))
+		assert.Equal(t, map[string]string{"bank.csv": "a,b"}, readSources(t, path))
+	})
+
+	t.Run("zip archive expands to entries", func(t *testing.T) {
+		path := createTempFile(t, "june.zip", zipBytes(t, map[string][]byte{
+			"bca.csv":           []byte("bca"),
+			"mandiri.csv.gz":    gzipBytes(t, "mandiri"),
+			"__MACOSX/._bca":    []byte("junk"),
+			"nested/.DS_Store":  []byte("junk"),
+			"nested/bri.csv":    []byte("bri"),
+			"nested/empty_dir/": nil,
+		}))
+		assert.Equal(t, map[string]string{
+			"june.zip/bca.csv":        "bca",
+			"june.zip/mandiri.csv":    "mandiri",
+			"june.zip/nested/bri.csv": "bri",
+		}, readSources(t, path))
+	})
+
+	t.Run("empty zip archive", func(t *testing.T) {
+		path := createTempFile(t, "empty.zip", zipBytes(t, nil))
+		_, _, err := openInputSources(nil, path)
+		assert.Error(t, err)
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		withStdin(t, gzipBytes(t, "from stdin"))
+		assert.Equal(t, map[string]string{"stdin": "from stdin"}, readSources(t, StdinPath))
+	})
+
+	t.Run("zip on stdin", func(t *testing.T) {
+		withStdin(t, zipBytes(t, map[string][]byte{"a.csv": []byte("a")}))
+		assert.Equal(t, map[string]string{"stdin/a.csv": "a"}, readSources(t, StdinPath))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, _, err := openInputSources(nil, "non_existent_file.csv")
+		assert.Error(t, err)
+	})
+}
+
+func TestCsvLedgerReader_CompressedInputs(t *testing.T) {
+	reader := NewCsvLedgerReader()
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("system transactions from stdin", func(t *testing.T) {
+		withStdin(t, []byte("trxID,amount,type,transactionTime\nsys001,100,CREDIT,2023-01-15T10:00:00Z"))
+		txs, err := reader.ReadSystemTransactions(context.Background(), StdinPath, startDate, endDate)
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+	})
+
+	t.Run("system transactions from multi-file zip", func(t *testing.T) {
+		path := createTempFile(t, "ledger.zip", zipBytes(t, map[string][]byte{"a.csv": nil, "b.csv": nil}))
+		_, err := reader.ReadSystemTransactions(context.Background(), path, startDate, endDate)
+		assert.Error(t, err)
+	})
+
+	t.Run("bank statements from zip and gzip", func(t *testing.T) {
+		archive := createTempFile(t, "bundle.zip", zipBytes(t, map[string][]byte{
+			"bca.csv":     []byte("unique_identifier,amount,date\nbank001,100,2023-01-15"),
+			"mandiri.csv": gzipBytes(t, "unique_identifier,amount,date\nbank002,200,2023-01-16"),
+		}))
+		compressed := createTempFile(t, "bri.csv.gz", gzipBytes(t, "unique_identifier,amount,date\nbank003,300,2023-01-17"))
+
+		txs, err := reader.ReadBankTransactions(context.Background(), []string{archive, compressed}, startDate, endDate)
+		require.NoError(t, err)
+		banks := make(map[string]string)
+		for _, tx := range txs {
+			banks[tx.ID] = tx.BankName
+		}
+		assert.Equal(t, map[string]string{
+			"bank001": "bundle.zip/bca.csv",
+			"bank002": "bundle.zip/mandiri.csv",
+			"bank003": "bri.csv",
+		}, banks)
+	})
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		path := createTempFile(t, "bad.csv.gz", []byte{0x1f, 0x8b, 0x00})
+		_, err := reader.ReadBankTransactions(context.Background(), []string{path}, startDate, endDate)
+		require.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), "bad.csv.gz"))
+	})
+}
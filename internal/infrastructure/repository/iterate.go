@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// collect runs a yield-based parser to completion and gathers its output, so
+// the slice and iterator APIs share one parsing loop.
+func collect[T any](parse func(yield func(T) bool) error) ([]T, error) {
+	var items []T
+	err := parse(func(item T) bool {
+		items = append(items, item)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// iterateBankTransactions uses the streaming API of reader when it has one and
+// otherwise reads each file fully before yielding its transactions.
+func iterateBankTransactions(ctx context.Context, reader domain.BankStatementReader, filePaths []string, startDate, endDate time.Time) iter.Seq2[domain.BankTransaction, error] {
+	if iterator, ok := reader.(domain.BankStatementIterator); ok {
+		return iterator.BankTransactions(ctx, filePaths, startDate, endDate)
+	}
+	return func(yield func(domain.BankTransaction, error) bool) {
+		for _, filePath := range filePaths {
+			transactions, err := reader.ReadBankTransactions(ctx, []string{filePath}, startDate, endDate)
+			for _, tx := range transactions {
+				if !yield(tx, nil) {
+					return
+				}
+			}
+			if err != nil && !yield(domain.BankTransaction{}, tagSource(filePath, err)) {
+				return
+			}
+		}
+	}
+}
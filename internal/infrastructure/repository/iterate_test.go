@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drain consumes seq to the end, separating items from errors.
+func drain[T any](seq iter.Seq2[T, error]) ([]T, []error) {
+	var items []T
+	var errs []error
+	for item, err := range seq {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, errs
+}
+
+func TestCollect(t *testing.T) {
+	items, err := collect(func(yield func(int) bool) error {
+		for i := 1; i <= 3; i++ {
+			if !yield(i) {
+				return nil
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, items)
+
+	items, err = collect(func(yield func(int) bool) error {
+		yield(1)
+		return errors.New("broken")
+	})
+	assert.EqualError(t, err, "broken")
+	assert.Nil(t, items)
+}
+
+func TestIterateBankTransactions(t *testing.T) {
+	t.Run("readers without an iterator are read file by file", func(t *testing.T) {
+		reader := &recordingReader{name: "csv"}
+		txs, errs := drain(iterateBankTransactions(context.Background(), reader, []string{"a.csv", "b.csv"}, time.Time{}, time.Time{}))
+		assert.Empty(t, errs)
+		assert.Equal(t, []domain.BankTransaction{{ID: "csv:a.csv"}, {ID: "csv:b.csv"}}, txs)
+	})
+
+	t.Run("failures are tagged with their file", func(t *testing.T) {
+		reader := &recordingReader{err: errors.New("boom")}
+		_, errs := drain(iterateBankTransactions(context.Background(), reader, []string{"a.csv", "b.csv"}, time.Time{}, time.Time{}))
+		require.Len(t, errs, 2)
+		assert.Equal(t, "b.csv", domain.SourceErrors(errs[1])[0].Source)
+	})
+}
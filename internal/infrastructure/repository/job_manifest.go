@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// JobManifest lists reconciliation jobs to run in one invocation.
+type JobManifest struct {
+	// Parallelism bounds how many jobs run at once; zero leaves the choice to
+	// the caller.
+	Parallelism int `json:"parallelism"`
+	// Period applies to jobs that do not declare their own.
+	Period PeriodConfig `json:"period"`
+	Jobs   []JobConfig  `json:"jobs"`
+}
+
+// JobConfig holds the inputs of one reconciliation, the same ones the
+// command line flags set for a single run.
+type JobConfig struct {
+	Name   string            `json:"name"`
+	System SystemInputConfig `json:"system"`
+	Bank   BankInputConfig   `json:"bank"`
+	Period PeriodConfig      `json:"period"`
+	Policy PolicyConfig      `json:"policy"`
+}
+
+type SystemInputConfig struct {
+	File        string `json:"file"`
+	Encoding    string `json:"encoding"`
+	DSN         string `json:"dsn"`
+	Driver      string `json:"driver"`
+	Query       string `json:"query"`
+	Columns     string `json:"columns"`
+	TypeAliases string `json:"type_aliases"`
+}
+
+type BankInputConfig struct {
+	Files     []string `json:"files"`
+	Recursive bool     `json:"recursive"`
+	Include   []string `json:"include"`
+	Exclude   []string `json:"exclude"`
+	Sources   string   `json:"sources"`
+	Encoding  string   `json:"encoding"`
+	Sheet     string   `json:"sheet"`
+}
+
+// PeriodConfig holds YYYY-MM-DD dates; End is included.
+type PeriodConfig struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type PolicyConfig struct {
+	AllowPartial bool `json:"allow_partial"`
+	Stream       bool `json:"stream"`
+}
+
+func (p PeriodConfig) Parse() (domain.Period, error) {
+	start, err := time.Parse("2006-01-02", p.Start)
+	if err != nil {
+		return domain.Period{}, fmt.Errorf("invalid start date '%s', expected YYYY-MM-DD", p.Start)
+	}
+	end, err := time.Parse("2006-01-02", p.End)
+	if err != nil {
+		return domain.Period{}, fmt.Errorf("invalid end date '%s', expected YYYY-MM-DD", p.End)
+	}
+	if end.Before(start) {
+		return domain.Period{}, fmt.Errorf("period ends on %s before it starts on %s", p.End, p.Start)
+	}
+	return domain.Period{Start: start, End: end}, nil
+}
+
+// Validate checks the settings that can be checked without opening inputs.
+func (j JobConfig) Validate() error {
+	if j.System.File == "" && j.System.DSN == "" {
+		return fmt.Errorf("no system transaction file or dsn")
+	}
+	if len(j.Bank.Files) == 0 {
+		return fmt.Errorf("no bank statement files")
+	}
+	for _, encoding := range []string{j.System.Encoding, j.Bank.Encoding} {
+		if _, err := ParseEncoding(encoding); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseSQLColumnMapping(j.System.Columns); err != nil {
+		return err
+	}
+	if _, err := ParseTypeAliases(j.System.TypeAliases); err != nil {
+		return err
+	}
+	if _, err := j.Period.Parse(); err != nil {
+		return err
+	}
+	if j.Policy.Stream && j.Policy.AllowPartial {
+		return fmt.Errorf("stream cannot be combined with allow_partial")
+	}
+	return nil
+}
+
+// LoadJobManifest reads a manifest file. Relative paths in it are resolved
+// against the manifest's directory.
+func LoadJobManifest(filePath string) (*JobManifest, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read job manifest '%s': %w", filePath, err)
+	}
+	manifest, err := ParseJobManifest(data, filepath.Dir(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("invalid job manifest '%s': %w", filePath, err)
+	}
+	return manifest, nil
+}
+
+// ParseJobManifest parses and validates a manifest, resolving relative paths
+// against baseDir.
+func ParseJobManifest(data []byte, baseDir string) (*JobManifest, error) {
+	var manifest JobManifest
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Jobs) == 0 {
+		return nil, fmt.Errorf("no jobs declared")
+	}
+	if manifest.Parallelism < 0 {
+		return nil, fmt.Errorf("parallelism must not be negative")
+	}
+
+	names := make(map[string]bool)
+	for i := range manifest.Jobs {
+		job := &manifest.Jobs[i]
+		if job.Name == "" {
+			return nil, fmt.Errorf("job #%d has no name", i+1)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("job '%s' is declared twice", job.Name)
+		}
+		names[job.Name] = true
+
+		if job.Period == (PeriodConfig{}) {
+			job.Period = manifest.Period
+		}
+		// Jobs run side by side, so none of them can own standard input.
+		for _, path := range append([]string{job.System.File}, job.Bank.Files...) {
+			if path == StdinPath {
+				return nil, fmt.Errorf("job '%s' reads standard input, which a manifest cannot use", job.Name)
+			}
+		}
+		job.System.File = resolvePath(baseDir, job.System.File)
+		job.Bank.Sources = resolvePath(baseDir, job.Bank.Sources)
+		for j, file := range job.Bank.Files {
+			job.Bank.Files[j] = resolvePath(baseDir, file)
+		}
+		if err := job.Validate(); err != nil {
+			return nil, fmt.Errorf("job '%s': %w", job.Name, err)
+		}
+	}
+	return &manifest, nil
+}
+
+// resolvePath joins relative paths to baseDir.
+func resolvePath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
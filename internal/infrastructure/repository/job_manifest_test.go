@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJobManifest = `{
+  "parallelism": 2,
+  "period": {"start": "2025-06-01", "end": "2025-06-30"},
+  "jobs": [
+    {
+      "name": "BCA Operating",
+      "system": {"file": "ledger/bca.csv", "type_aliases": "KR=CREDIT"},
+      "bank": {"files": ["statements/bca/*.csv"], "sources": "sources.json"},
+      "policy": {"allow_partial": true}
+    },
+    {
+      "name": "Payroll",
+      "system": {"dsn": "postgres://ledger", "columns": "time=created_at"},
+      "bank": {"files": ["/data/payroll.xlsx"], "sheet": "2"},
+      "period": {"start": "2025-06-15", "end": "2025-06-15"},
+      "policy": {"stream": true}
+    }
+  ]
+}`
+
+func TestParseJobManifest(t *testing.T) {
+	manifest, err := ParseJobManifest([]byte(testJobManifest), "/etc/reco")
+	require.NoError(t, err)
+	assert.Equal(t, 2, manifest.Parallelism)
+	require.Len(t, manifest.Jobs, 2)
+
+	bca := manifest.Jobs[0]
+	assert.Equal(t, filepath.Join("/etc/reco", "ledger/bca.csv"), bca.System.File)
+	assert.Equal(t, []string{filepath.Join("/etc/reco", "statements/bca/*.csv")}, bca.Bank.Files)
+	assert.Equal(t, filepath.Join("/etc/reco", "sources.json"), bca.Bank.Sources)
+	assert.Equal(t, manifest.Period, bca.Period)
+	assert.True(t, bca.Policy.AllowPartial)
+
+	payroll := manifest.Jobs[1]
+	assert.Equal(t, "", payroll.System.File)
+	assert.Equal(t, []string{"/data/payroll.xlsx"}, payroll.Bank.Files)
+	period, err := payroll.Period.Parse()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), period.Start)
+	assert.Equal(t, period.Start, period.End)
+
+	job := `"system": {"file": "a.csv"}, "bank": {"files": ["b.csv"]}, "period": {"start": "2025-06-01", "end": "2025-06-30"}`
+	invalid := map[string]string{
+		"no jobs":           `{"jobs": []}`,
+		"unknown field":     `{"jobs": [{"name": "A", ` + job + `, "colour": "red"}]}`,
+		"missing name":      `{"jobs": [{` + job + `}]}`,
+		"duplicate name":    `{"jobs": [{"name": "A", ` + job + `}, {"name": "A", ` + job + `}]}`,
+		"no system input":   `{"jobs": [{"name": "A", "bank": {"files": ["b.csv"]}, "period": {"start": "2025-06-01", "end": "2025-06-30"}}]}`,
+		"no bank files":     `{"jobs": [{"name": "A", "system": {"file": "a.csv"}, "period": {"start": "2025-06-01", "end": "2025-06-30"}}]}`,
+		"no period":         `{"jobs": [{"name": "A", "system": {"file": "a.csv"}, "bank": {"files": ["b.csv"]}}]}`,
+		"reversed period":   `{"jobs": [{"name": "A", "system": {"file": "a.csv"}, "bank": {"files": ["b.csv"]}, "period": {"start": "2025-06-30", "end": "2025-06-01"}}]}`,
+		"stdin":             `{"jobs": [{"name": "A", "system": {"file": "-"}, "bank": {"files": ["b.csv"]}, "period": {"start": "2025-06-01", "end": "2025-06-30"}}]}`,
+		"bad encoding":      `{"jobs": [{"name": "A", "system": {"file": "a.csv", "encoding": "ebcdic"}, "bank": {"files": ["b.csv"]}, "period": {"start": "2025-06-01", "end": "2025-06-30"}}]}`,
+		"bad type aliases":  `{"jobs": [{"name": "A", "system": {"file": "a.csv", "type_aliases": "KR=BOTH"}, "bank": {"files": ["b.csv"]}, "period": {"start": "2025-06-01", "end": "2025-06-30"}}]}`,
+		"stream partial":    `{"jobs": [{"name": "A", ` + job + `, "policy": {"stream": true, "allow_partial": true}}]}`,
+		"negative parallel": `{"parallelism": -1, "jobs": [{"name": "A", ` + job + `}]}`,
+		"not json":          `jobs:`,
+	}
+	for name, data := range invalid {
+		_, err := ParseJobManifest([]byte(data), ".")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadJobManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jobs.json")
+	require.NoError(t, os.WriteFile(path, []byte(testJobManifest), 0o600))
+
+	manifest, err := LoadJobManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "ledger/bca.csv"), manifest.Jobs[0].System.File)
+
+	_, err = LoadJobManifest(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
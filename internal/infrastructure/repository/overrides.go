@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+var overrideHeaders = []string{"system_id", "bank_id", "note"}
+
+// LoadOverrides reads a CSV of manual decisions with the columns
+// system_id,bank_id,note in any order. Other columns, such as those of a
+// reviewed CSV export, are ignored.
+func LoadOverrides(filePath string) ([]domain.Override, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open overrides file '%s': %w", filePath, err)
+	}
+	defer file.Close()
+	overrides, err := ReadOverrides(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overrides file '%s': %w", filePath, err)
+	}
+	return overrides, nil
+}
+
+func ReadOverrides(r io.Reader) ([]domain.Override, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("missing header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range overrideHeaders {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("expected header with columns '%s'", strings.Join(overrideHeaders, ","))
+		}
+	}
+
+	var overrides []domain.Override
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return overrides, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		override := domain.Override{
+			SystemTransactionID: overrideText(record[columns["system_id"]]),
+			BankTransactionID:   overrideText(record[columns["bank_id"]]),
+			Note:                overrideText(record[columns["note"]]),
+		}
+		if override.SystemTransactionID == "" && override.BankTransactionID == "" {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("line %d: system_id or bank_id is required", line)
+		}
+		overrides = append(overrides, override)
+	}
+}
+
+// overrideText trims a cell and removes the apostrophe that CSV exports put
+// before values a spreadsheet would otherwise run as a formula.
+func overrideText(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOverrides(t *testing.T) {
+	overrides, err := ReadOverrides(strings.NewReader("system_id,bank_id,note\nTRX-1,BNK-7,paid in two parts\n,BNK-FEE,bank fee\nTRX-9,,\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Override{
+		{SystemTransactionID: "TRX-1", BankTransactionID: "BNK-7", Note: "paid in two parts"},
+		{BankTransactionID: "BNK-FEE", Note: "bank fee"},
+		{SystemTransactionID: "TRX-9"},
+	}, overrides)
+
+	overrides, err = ReadOverrides(strings.NewReader("system_id,bank_id,bank,amount,note\nTRX-1,BNK-7,BCA,100,checked\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Override{{SystemTransactionID: "TRX-1", BankTransactionID: "BNK-7", Note: "checked"}}, overrides, "extra columns are ignored")
+
+	overrides, err = ReadOverrides(strings.NewReader("system_id,bank_id,note\n'=TRX-1,'-7,'@check\n'TRX-2,,\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []domain.Override{
+		{SystemTransactionID: "=TRX-1", BankTransactionID: "-7", Note: "@check"},
+		{SystemTransactionID: "'TRX-2"},
+	}, overrides, "apostrophes of escaped formulas are removed")
+
+	cases := map[string]string{
+		"empty":      "",
+		"bad header": "id,bank,note\n",
+		"no note":    "system_id,bank_id\nTRX-1,BNK-7\n",
+		"no ids":     "system_id,bank_id,note\n,,note\n",
+		"columns":    "system_id,bank_id,note\nTRX-1,BNK-7\n",
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadOverrides(strings.NewReader(input))
+			assert.Error(t, err)
+		})
+	}
+}
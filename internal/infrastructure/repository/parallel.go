@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// readConcurrently runs read for every named source in parallel and
+// concatenates the results in source order, so output never depends on
+// goroutine scheduling. A failing source does not stop the others: the
+// transactions that could be read are returned together with all failures
+// joined, each tagged with its source as a *domain.SourceError.
+func readConcurrently(ctx context.Context, names []string, read func(ctx context.Context, i int) ([]domain.BankTransaction, error)) ([]domain.BankTransaction, error) {
+	results := make([][]domain.BankTransaction, len(names))
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+
+	for i := range names {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = read(ctx, i)
+		}(i)
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var allBankTransactions []domain.BankTransaction
+	for i := range results {
+		allBankTransactions = append(allBankTransactions, results[i]...)
+		errs[i] = tagSource(names[i], errs[i])
+	}
+	return allBankTransactions, errors.Join(errs...)
+}
+
+// tagSource attributes err to name unless a nested reader already attributed it.
+func tagSource(name string, err error) error {
+	if err == nil || len(domain.SourceErrors(err)) > 0 {
+		return err
+	}
+	return &domain.SourceError{Source: name, Err: err}
+}
+
+func sourceNames(sources []domain.InputSource) []string {
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.Name
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConcurrently(t *testing.T) {
+	t.Run("results follow index order", func(t *testing.T) {
+		txs, err := readConcurrently(context.Background(), []string{"a", "b", "c"}, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+			return []domain.BankTransaction{{ID: string(rune('a' + i))}}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.BankTransaction{{ID: "a"}, {ID: "b"}, {ID: "c"}}, txs)
+	})
+
+	t.Run("failures are aggregated with the readable results", func(t *testing.T) {
+		txs, err := readConcurrently(context.Background(), []string{"a.csv", "b.csv", "c.csv"}, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+			if i == 1 {
+				return []domain.BankTransaction{{ID: "ok"}}, nil
+			}
+			return nil, errors.New("broken file " + string(rune('a'+i)))
+		})
+		assert.Equal(t, []domain.BankTransaction{{ID: "ok"}}, txs)
+		assert.EqualError(t, err, "broken file a\nbroken file c")
+
+		failures := domain.SourceErrors(err)
+		require.Len(t, failures, 2)
+		assert.Equal(t, "a.csv", failures[0].Source)
+		assert.Equal(t, "c.csv", failures[1].Source)
+		assert.True(t, domain.OnlySourceErrors(err))
+	})
+
+	t.Run("nested source errors are not tagged twice", func(t *testing.T) {
+		inner := &domain.SourceError{Source: "archive.zip/a.csv", Err: errors.New("broken entry")}
+		_, err := readConcurrently(context.Background(), []string{"archive.zip"}, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+			return nil, errors.Join(inner)
+		})
+		assert.Equal(t, []*domain.SourceError{inner}, domain.SourceErrors(err))
+	})
+
+	t.Run("parent cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := readConcurrently(ctx, []string{"a", "b"}, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+			return nil, nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type ExpandOptions struct {
+	Recursive bool
+	// Include and Exclude are filepath.Match patterns checked against the base
+	// name of files found through directories and globs. Hidden files are
+	// skipped there too; paths named explicitly are always kept.
+	Include []string
+	Exclude []string
+}
+
+// ExpandPaths turns a list of files, directories and glob patterns into the
+// files they refer to, keeping the order in which inputs were given.
+func ExpandPaths(inputs []string, opts ExpandOptions) ([]string, error) {
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter pattern '%s': %w", pattern, err)
+		}
+	}
+
+	var expanded []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			expanded = append(expanded, path)
+		}
+	}
+
+	for _, input := range inputs {
+		if input == StdinPath {
+			add(input)
+			continue
+		}
+
+		if info, err := os.Stat(input); err == nil {
+			if !info.IsDir() {
+				add(input)
+				continue
+			}
+			files, err := listDirectory(input, opts)
+			if err != nil {
+				return nil, err
+			}
+			if len(files) == 0 {
+				return nil, fmt.Errorf("directory '%s' contains no matching files", input)
+			}
+			for _, file := range files {
+				add(file)
+			}
+			continue
+		}
+
+		if !strings.ContainsAny(input, "*?[") {
+			// Not a pattern: keep it so the reader reports the missing file.
+			add(input)
+			continue
+		}
+
+		matches, err := filepath.Glob(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern '%s': %w", input, err)
+		}
+		var files []string
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				if !opts.Recursive {
+					continue
+				}
+				dirFiles, err := listDirectory(match, opts)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, dirFiles...)
+				continue
+			}
+			if opts.keep(match) {
+				files = append(files, match)
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("pattern '%s' matched no files", input)
+		}
+		for _, file := range files {
+			add(file)
+		}
+	}
+	return expanded, nil
+}
+
+// ReadPathList reads a file listing one path per line. Blank lines and lines
+// starting with '#' are skipped; everything else, including commas and
+// surrounding spaces, is part of the path.
+func ReadPathList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read path list '%s': %w", path, err)
+	}
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, nil
+}
+
+func listDirectory(dir string, opts ExpandOptions) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && opts.keep(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not list directory '%s': %w", dir, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (opts ExpandOptions) keep(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	if len(opts.Include) > 0 && !matchesAny(opts.Include, name) {
+		return false
+	}
+	return !matchesAny(opts.Exclude, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
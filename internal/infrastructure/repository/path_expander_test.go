@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTree(t *testing.T, files ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+	}
+	return root
+}
+
+func relativeTo(t *testing.T, root string, paths []string) []string {
+	t.Helper()
+	rel := make([]string, len(paths))
+	for i, path := range paths {
+		r, err := filepath.Rel(root, path)
+		require.NoError(t, err)
+		rel[i] = filepath.ToSlash(r)
+	}
+	return rel
+}
+
+func TestExpandPaths(t *testing.T) {
+	root := createTree(t,
+		"2025-06/bca.csv",
+		"2025-06/mandiri.csv",
+		"2025-06/notes.txt",
+		"2025-06/.hidden.csv",
+		"2025-06/late/bri.csv",
+		"2025-07/bca, main account.csv",
+	)
+	june := filepath.Join(root, "2025-06")
+
+	t.Run("directory", func(t *testing.T) {
+		paths, err := ExpandPaths([]string{june}, ExpandOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2025-06/bca.csv", "2025-06/mandiri.csv", "2025-06/notes.txt"}, relativeTo(t, root, paths))
+	})
+
+	t.Run("recursive directory with include filter", func(t *testing.T) {
+		paths, err := ExpandPaths([]string{june}, ExpandOptions{Recursive: true, Include: []string{"*.csv"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2025-06/bca.csv", "2025-06/late/bri.csv", "2025-06/mandiri.csv"}, relativeTo(t, root, paths))
+	})
+
+	t.Run("glob with exclude filter", func(t *testing.T) {
+		paths, err := ExpandPaths([]string{filepath.Join(root, "*", "*.csv")}, ExpandOptions{Exclude: []string{"mandiri*"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"2025-06/bca.csv", "2025-07/bca, main account.csv"}, relativeTo(t, root, paths))
+	})
+
+	t.Run("explicit files are kept in order and deduplicated", func(t *testing.T) {
+		explicit := filepath.Join(june, "notes.txt")
+		paths, err := ExpandPaths([]string{explicit, june, StdinPath}, ExpandOptions{Include: []string{"*.csv"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{explicit, filepath.Join(june, "bca.csv"), filepath.Join(june, "mandiri.csv"), StdinPath}, paths)
+	})
+
+	t.Run("missing plain path is passed through", func(t *testing.T) {
+		paths, err := ExpandPaths([]string{"non_existent_file.csv"}, ExpandOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"non_existent_file.csv"}, paths)
+	})
+
+	t.Run("pattern without matches", func(t *testing.T) {
+		_, err := ExpandPaths([]string{filepath.Join(root, "*.xlsx")}, ExpandOptions{})
+		assert.Error(t, err)
+	})
+
+	t.Run("directory without matches", func(t *testing.T) {
+		_, err := ExpandPaths([]string{june}, ExpandOptions{Include: []string{"*.xlsx"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := ExpandPaths([]string{june}, ExpandOptions{Include: []string{"["}})
+		assert.Error(t, err)
+	})
+}
+
+func TestReadPathList(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "statements.txt")
+	require.NoError(t, os.WriteFile(list, []byte("# June\r\nstatements/bca, main account.csv\r\n\nstatements/*.xlsx\n"), 0o600))
+
+	paths, err := ReadPathList(list)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"statements/bca, main account.csv", "statements/*.xlsx"}, paths)
+
+	_, err = ReadPathList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// defaultScope is the directory of records without a scope.
+const defaultScope = "default"
+
+// PeriodCloseDirEnv names the environment variable holding the directory of
+// closed period records.
+const PeriodCloseDirEnv = "RECONCILER_CLOSE_DIR"
+
+var unsafeScopeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// PeriodCloseStore keeps period close records as JSON files, one directory
+// per scope and one file per closed period. Reopened records are moved to a
+// "reopened" subdirectory rather than deleted.
+type PeriodCloseStore struct {
+	dir string
+}
+
+func NewPeriodCloseStore(dir string) *PeriodCloseStore {
+	return &PeriodCloseStore{dir: dir}
+}
+
+// DefaultPeriodCloseDir returns the directory closed periods are kept in when
+// none is given: $RECONCILER_CLOSE_DIR, or reconciler/closes in the user's
+// configuration directory.
+func DefaultPeriodCloseDir() (string, error) {
+	if dir := os.Getenv(PeriodCloseDirEnv); dir != "" {
+		return dir, nil
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("no directory for closed periods, set %s: %w", PeriodCloseDirEnv, err)
+	}
+	return filepath.Join(config, "reconciler", "closes"), nil
+}
+
+// Find returns the closed record of scope whose period overlaps period, or
+// nil when there is none.
+func (s *PeriodCloseStore) Find(scope string, period domain.Period) (*domain.PeriodClose, error) {
+	record, _, err := s.find(scope, period)
+	return record, err
+}
+
+// find also returns the path of the record found.
+func (s *PeriodCloseStore) find(scope string, period domain.Period) (*domain.PeriodClose, string, error) {
+	entries, err := os.ReadDir(s.scopeDir(scope))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("could not list closed periods: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(s.scopeDir(scope), entry.Name())
+		record, err := readPeriodClose(path)
+		if err != nil {
+			return nil, "", err
+		}
+		if record.Scope == scope && record.Overlaps(period) {
+			return record, path, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// Save stores a new record. It fails with domain.ErrPeriodClosed when an
+// overlapping period of the same scope is already closed.
+func (s *PeriodCloseStore) Save(record *domain.PeriodClose) error {
+	existing, err := s.Find(record.Scope, record.Period)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%w: %s", domain.ErrPeriodClosed, describePeriod(existing.Period))
+	}
+	if err := os.MkdirAll(s.scopeDir(record.Scope), 0o755); err != nil {
+		return fmt.Errorf("could not create period close directory: %w", err)
+	}
+	return writePeriodClose(filepath.Join(s.scopeDir(record.Scope), periodFileName(record.Period)), record)
+}
+
+// Reopen unlocks the closed period of scope that overlaps period, the one
+// Find returns and that refuses runs over period, and returns the record as
+// archived. Its Period may be wider than period.
+func (s *PeriodCloseStore) Reopen(scope string, period domain.Period, reopen domain.PeriodReopen) (*domain.PeriodClose, error) {
+	record, path, err := s.find(scope, period)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("period %s is not closed", describePeriod(period))
+	}
+
+	record.Reopened = &reopen
+	archiveDir := filepath.Join(s.scopeDir(scope), "reopened")
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create period close directory: %w", err)
+	}
+	archived := strings.TrimSuffix(periodFileName(record.Period), ".json") + "_" + reopen.ReopenedAt.UTC().Format("20060102T150405Z") + ".json"
+	if err := writePeriodClose(filepath.Join(archiveDir, archived), record); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("could not reopen period %s: %w", describePeriod(record.Period), err)
+	}
+	return record, nil
+}
+
+func (s *PeriodCloseStore) scopeDir(scope string) string {
+	name := unsafeScopeChars.ReplaceAllString(scope, "_")
+	if name == "" {
+		name = defaultScope
+	}
+	return filepath.Join(s.dir, name)
+}
+
+func periodFileName(period domain.Period) string {
+	return period.Start.Format("2006-01-02") + "_" + period.End.Format("2006-01-02") + ".json"
+}
+
+func describePeriod(period domain.Period) string {
+	return period.Start.Format("2006-01-02") + " to " + period.End.Format("2006-01-02")
+}
+
+func readPeriodClose(path string) (*domain.PeriodClose, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var record domain.PeriodClose
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid period close record '%s': %w", path, err)
+	}
+	return &record, nil
+}
+
+// writePeriodClose creates path; an existing record is never overwritten.
+func writePeriodClose(path string, record *domain.PeriodClose) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode period close record: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o444)
+	if err != nil {
+		return fmt.Errorf("could not write period close record: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("could not write period close record '%s': %w", path, err)
+	}
+	return file.Close()
+}
+
+// DigestFiles hashes the given files as stored on disk. Standard input cannot
+// be hashed after it has been read.
+func DigestFiles(paths []string) ([]domain.InputDigest, error) {
+	digests := make([]domain.InputDigest, 0, len(paths))
+	for _, path := range paths {
+		if path == StdinPath {
+			return nil, fmt.Errorf("standard input cannot be hashed")
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not hash input file '%s': %w", path, err)
+		}
+		hash := sha256.New()
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("could not hash input file '%s': %w", path, err)
+		}
+		digests = append(digests, domain.InputDigest{Path: path, SHA256: hex.EncodeToString(hash.Sum(nil))})
+	}
+	return digests, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodCloseStore(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+	june := domain.Period{Start: day(1), End: day(30)}
+	dir := t.TempDir()
+	store := NewPeriodCloseStore(dir)
+
+	record := &domain.PeriodClose{
+		Scope:  "BCA Operating",
+		Period: june,
+		Inputs: []domain.InputDigest{{Path: "bank.csv", SHA256: "abc"}},
+		Summary: &domain.ReconciliationSummary{
+			MatchedTransactions:    3,
+			AmountDiscrepancyTotal: decimal.RequireFromString("12.50"),
+			UnmatchedBankTransactions: map[string][]domain.BankTransaction{
+				"BCA": {{ID: "B9", Amount: decimal.RequireFromString("-15000"), Date: day(24), BankName: "BCA"}},
+			},
+			BankNames: []string{"BCA"},
+		},
+		Overrides:  []domain.Override{{BankTransactionID: "B9", Note: "bank fee"}},
+		ApprovedBy: "Controller",
+		ClosedAt:   time.Date(2025, 7, 2, 9, 0, 0, 0, time.UTC),
+	}
+
+	found, err := store.Find("BCA Operating", june)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	require.NoError(t, store.Save(record))
+	assert.FileExists(t, filepath.Join(dir, "BCA_Operating", "2025-06-01_2025-06-30.json"))
+
+	found, err = store.Find("BCA Operating", domain.Period{Start: day(30), End: day(30).AddDate(0, 0, 5)})
+	require.NoError(t, err)
+	require.NotNil(t, found, "overlapping periods are found")
+	assert.Equal(t, "Controller", found.ApprovedBy)
+	assert.Equal(t, "12.5", found.Summary.AmountDiscrepancyTotal.String())
+	assert.Equal(t, "B9", found.Summary.UnmatchedBankTransactions["BCA"][0].ID)
+	assert.Equal(t, record.Overrides, found.Overrides)
+
+	found, err = store.Find("BRI", june)
+	require.NoError(t, err)
+	assert.Nil(t, found, "scopes are separate")
+
+	err = store.Save(&domain.PeriodClose{Scope: "BCA Operating", Period: domain.Period{Start: day(15), End: day(20)}})
+	assert.ErrorIs(t, err, domain.ErrPeriodClosed)
+
+	_, err = store.Reopen("BCA Operating", domain.Period{Start: day(1).AddDate(0, 1, 0), End: day(5).AddDate(0, 1, 0)}, domain.PeriodReopen{})
+	assert.ErrorContains(t, err, "is not closed")
+
+	reopenedAt := time.Date(2025, 7, 5, 10, 30, 0, 0, time.UTC)
+	reopened, err := store.Reopen("BCA Operating", domain.Period{Start: day(15), End: day(20)}, domain.PeriodReopen{ReopenedBy: "Auditor", Reason: "corrected statement", ReopenedAt: reopenedAt})
+	require.NoError(t, err, "the close overlapping the period is reopened, as Find finds it")
+	assert.Equal(t, june, reopened.Period)
+	assert.Equal(t, "Auditor", reopened.Reopened.ReopenedBy)
+	assert.FileExists(t, filepath.Join(dir, "BCA_Operating", "reopened", "2025-06-01_2025-06-30_20250705T103000Z.json"))
+
+	found, err = store.Find("BCA Operating", june)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	assert.NoError(t, store.Save(record), "a reopened period can be closed again")
+}
+
+func TestDefaultPeriodCloseDir(t *testing.T) {
+	t.Setenv(PeriodCloseDirEnv, "/srv/closes")
+	dir, err := DefaultPeriodCloseDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/closes", dir)
+
+	t.Setenv(PeriodCloseDirEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", "/home/rina/.config")
+	t.Setenv("HOME", "/home/rina")
+	dir, err = DefaultPeriodCloseDir()
+	require.NoError(t, err)
+	assert.Equal(t, "reconciler", filepath.Base(filepath.Dir(dir)))
+	assert.Equal(t, "closes", filepath.Base(dir))
+}
+
+func TestDigestFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.csv")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0o644))
+
+	digests, err := DigestFiles([]string{path})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.InputDigest{{Path: path, SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"}}, digests)
+
+	_, err = DigestFiles([]string{StdinPath})
+	assert.Error(t, err)
+	_, err = DigestFiles([]string{filepath.Join(t.TempDir(), "missing.csv")})
+	assert.ErrorContains(t, err, "could not hash input file")
+}
@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// SignNegativeDebit is the default convention: debits are negative amounts.
+	SignNegativeDebit = "negative-debit"
+	// SignNegativeCredit is used by banks that export debits as positive amounts.
+	SignNegativeCredit = "negative-credit"
+)
+
+type BankSourceConfig struct {
+	Name           string   `json:"name"`
+	AccountNumber  string   `json:"account_number"`
+	Format         string   `json:"format"`
+	Sheet          string   `json:"sheet"`
+	Timezone       string   `json:"timezone"`
+	SignConvention string   `json:"sign_convention"`
+	Encoding       string   `json:"encoding"`
+	Files          []string `json:"files"`
+	// OpeningBalance and ClosingBalance are the account balances at the start
+	// and end of the reconciliation period, checked against the statements.
+	OpeningBalance decimal.NullDecimal `json:"opening_balance"`
+	ClosingBalance decimal.NullDecimal `json:"closing_balance"`
+}
+
+type sourceRegistryFile struct {
+	Sources []BankSourceConfig `json:"sources"`
+}
+
+type bankSource struct {
+	BankSourceConfig
+	location *time.Location
+}
+
+// SourceRegistry maps statement files to the bank accounts declared in a sources config.
+type SourceRegistry struct {
+	sources []*bankSource
+}
+
+func LoadSourceRegistry(filePath string) (*SourceRegistry, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read sources config '%s': %w", filePath, err)
+	}
+	registry, err := ParseSourceRegistry(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sources config '%s': %w", filePath, err)
+	}
+	return registry, nil
+}
+
+func ParseSourceRegistry(data []byte) (*SourceRegistry, error) {
+	var file sourceRegistryFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+	if len(file.Sources) == 0 {
+		return nil, fmt.Errorf("no sources declared")
+	}
+
+	registry := &SourceRegistry{}
+	names := make(map[string]bool)
+	for i, config := range file.Sources {
+		if config.Name == "" {
+			return nil, fmt.Errorf("source #%d has no name", i+1)
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("source '%s' is declared twice", config.Name)
+		}
+		names[config.Name] = true
+
+		if len(config.Files) == 0 {
+			return nil, fmt.Errorf("source '%s' has no file patterns", config.Name)
+		}
+		for _, pattern := range config.Files {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("source '%s' has invalid file pattern '%s': %w", config.Name, pattern, err)
+			}
+		}
+
+		switch strings.ToLower(config.Format) {
+		case "", "csv", "xlsx":
+			config.Format = strings.ToLower(config.Format)
+		default:
+			return nil, fmt.Errorf("source '%s' has unknown format '%s', expected csv or xlsx", config.Name, config.Format)
+		}
+
+		if config.Encoding != "" {
+			encoding, err := ParseEncoding(config.Encoding)
+			if err != nil {
+				return nil, fmt.Errorf("source '%s' has %w", config.Name, err)
+			}
+			// XLSX text is always UTF-8 XML, so an encoding implies CSV.
+			switch config.Format {
+			case "":
+				config.Format = "csv"
+			case "xlsx":
+				return nil, fmt.Errorf("source '%s' declares an encoding, which only applies to csv", config.Name)
+			}
+			config.Encoding = encoding
+		}
+
+		switch config.SignConvention {
+		case "":
+			config.SignConvention = SignNegativeDebit
+		case SignNegativeDebit, SignNegativeCredit:
+		default:
+			return nil, fmt.Errorf("source '%s' has unknown sign convention '%s', expected %s or %s", config.Name, config.SignConvention, SignNegativeDebit, SignNegativeCredit)
+		}
+
+		source := &bankSource{BankSourceConfig: config}
+		if config.Timezone != "" {
+			location, err := time.LoadLocation(config.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("source '%s' has invalid timezone: %w", config.Name, err)
+			}
+			source.location = location
+		}
+		registry.sources = append(registry.sources, source)
+	}
+	return registry, nil
+}
+
+// DeclaredBalances returns the opening and closing balances of the sources
+// that declare any.
+func (r *SourceRegistry) DeclaredBalances() []domain.DeclaredBalance {
+	var balances []domain.DeclaredBalance
+	for _, source := range r.sources {
+		if !source.OpeningBalance.Valid && !source.ClosingBalance.Valid {
+			continue
+		}
+		balances = append(balances, domain.DeclaredBalance{
+			BankName:      source.Name,
+			AccountNumber: source.AccountNumber,
+			Opening:       source.OpeningBalance,
+			Closing:       source.ClosingBalance,
+		})
+	}
+	return balances
+}
+
+// resolve returns the first declared source with a pattern matching one of the
+// names. Patterns without a slash match base names, others match trailing path
+// segments.
+func (r *SourceRegistry) resolve(names ...string) *bankSource {
+	for _, source := range r.sources {
+		for _, pattern := range source.Files {
+			for _, name := range names {
+				if matchSourcePattern(pattern, name) {
+					return source
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func matchSourcePattern(pattern, name string) bool {
+	name = path.Clean(filepath.ToSlash(name))
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	segments := strings.Split(name, "/")
+	for i := range segments {
+		if ok, _ := path.Match(pattern, strings.Join(segments[i:], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *bankSource) apply(tx *domain.BankTransaction) {
+	tx.BankName = s.Name
+	tx.AccountNumber = s.AccountNumber
+	if s.SignConvention == SignNegativeCredit {
+		tx.Amount = tx.Amount.Neg()
+	}
+	if s.location != nil {
+		year, month, day := tx.Date.Date()
+		tx.Date = time.Date(year, month, day, 0, 0, 0, 0, s.location)
+	}
+}
+
+// RegistryStatementReader reads statements with the format declared for their
+// source and attributes every transaction to that source instead of the file name.
+type RegistryStatementReader struct {
+	registry     *SourceRegistry
+	fsys         fs.FS
+	fallback     domain.BankStatementReader
+	rejected     *domain.RejectionLog
+	fingerprints *domain.FingerprintLog
+}
+
+// NewRegistryStatementReader uses fallback for files whose source does not
+// declare a format; fsys may be nil for the OS filesystem.
+func NewRegistryStatementReader(registry *SourceRegistry, fsys fs.FS, fallback domain.BankStatementReader) *RegistryStatementReader {
+	return &RegistryStatementReader{registry: registry, fsys: fsys, fallback: fallback}
+}
+
+// WithRejectionLog reports the rows skipped in files read with a declared
+// format to log; fallback reports to its own.
+func (r *RegistryStatementReader) WithRejectionLog(log *domain.RejectionLog) *RegistryStatementReader {
+	r.rejected = log
+	return r
+}
+
+// WithFingerprintLog reports the fingerprints of files read with a declared
+// format to log; fallback reports to its own.
+func (r *RegistryStatementReader) WithFingerprintLog(log *domain.FingerprintLog) *RegistryStatementReader {
+	r.fingerprints = log
+	return r
+}
+
+func (r *RegistryStatementReader) readerFor(source *bankSource) domain.BankStatementReader {
+	if source == nil {
+		return r.fallback
+	}
+	switch source.Format {
+	case "csv":
+		return NewCsvLedgerReaderFS(r.fsys).WithEncoding(source.Encoding).WithRejectionLog(r.rejected).WithFingerprintLog(r.fingerprints)
+	case "xlsx":
+		return NewXlsxStatementReaderFS(r.fsys, XlsxReaderConfig{Sheet: source.Sheet}).WithRejectionLog(r.rejected).WithFingerprintLog(r.fingerprints)
+	default:
+		return r.fallback
+	}
+}
+
+func (r *RegistryStatementReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	return readConcurrently(ctx, filePaths, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+		return r.readFile(ctx, filePaths[i], startDate, endDate)
+	})
+}
+
+// BankTransactions streams the files one after another and attributes each
+// transaction to its source as it passes.
+func (r *RegistryStatementReader) BankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		for _, filePath := range filePaths {
+			fileSource := r.registry.resolve(filePath)
+			read, failed := 0, false
+			for tx, err := range iterateBankTransactions(ctx, r.readerFor(fileSource), []string{filePath}, startDate, endDate) {
+				if err == nil {
+					read++
+					source := fileSource
+					if source == nil {
+						source = r.registry.resolve(tx.BankName)
+					}
+					if source == nil {
+						err = &domain.SourceError{Source: filePath, Err: fmt.Errorf("bank statement '%s' does not match any declared source", tx.BankName)}
+						tx = domain.BankTransaction{}
+					} else {
+						source.apply(&tx)
+					}
+				}
+				failed = failed || err != nil
+				if !yield(tx, err) {
+					return
+				}
+			}
+			if fileSource == nil && read == 0 && !failed {
+				err := &domain.SourceError{Source: filePath, Err: fmt.Errorf("bank statement file '%s' does not match any declared source", filePath)}
+				if !yield(domain.BankTransaction{}, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *RegistryStatementReader) readFile(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	fileSource := r.registry.resolve(filePath)
+	// Archive entries that failed are reported in readErr; the others are still
+	// attributed below.
+	transactions, readErr := r.readerFor(fileSource).ReadBankTransactions(ctx, []string{filePath}, startDate, endDate)
+	if readErr != nil && len(transactions) == 0 {
+		return nil, readErr
+	}
+	if fileSource == nil && len(transactions) == 0 {
+		return nil, fmt.Errorf("bank statement file '%s' does not match any declared source", filePath)
+	}
+
+	// Archives may bundle statements of several accounts, so entries that the
+	// archive path did not resolve are matched by their own names.
+	for i := range transactions {
+		source := fileSource
+		if source == nil {
+			source = r.registry.resolve(transactions[i].BankName)
+		}
+		if source == nil {
+			return nil, errors.Join(readErr, fmt.Errorf("bank statement '%s' does not match any declared source", transactions[i].BankName))
+		}
+		source.apply(&transactions[i])
+	}
+	return transactions, readErr
+}
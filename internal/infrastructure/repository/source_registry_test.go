@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSourcesConfig = `{
+  "sources": [
+    {
+      "name": "BCA Operating",
+      "account_number": "123-456",
+      "timezone": "Asia/Jakarta",
+      "files": ["*bca*.csv", "bca/*"]
+    },
+    {
+      "name": "Mandiri Payroll",
+      "account_number": "987",
+      "format": "csv",
+      "sign_convention": "negative-credit",
+      "opening_balance": "1000.50",
+      "closing_balance": 700.5,
+      "files": ["mandiri*"]
+    }
+  ]
+}`
+
+func TestParseSourceRegistry(t *testing.T) {
+	registry, err := ParseSourceRegistry([]byte(testSourcesConfig))
+	require.NoError(t, err)
+	assert.Len(t, registry.sources, 2)
+	assert.Equal(t, SignNegativeDebit, registry.sources[0].SignConvention)
+
+	balances := registry.DeclaredBalances()
+	require.Len(t, balances, 1)
+	assert.Equal(t, "Mandiri Payroll", balances[0].BankName)
+	assert.Equal(t, "987", balances[0].AccountNumber)
+	assert.Equal(t, "1000.5", balances[0].Opening.Decimal.String())
+	assert.Equal(t, "700.5", balances[0].Closing.Decimal.String())
+
+	invalid := map[string]string{
+		"empty":            `{"sources": []}`,
+		"unknown field":    `{"sources": [{"name": "A", "files": ["*"], "colour": "red"}]}`,
+		"missing name":     `{"sources": [{"files": ["*"]}]}`,
+		"duplicate name":   `{"sources": [{"name": "A", "files": ["*"]}, {"name": "A", "files": ["*"]}]}`,
+		"no files":         `{"sources": [{"name": "A"}]}`,
+		"bad pattern":      `{"sources": [{"name": "A", "files": ["["]}]}`,
+		"unknown format":   `{"sources": [{"name": "A", "files": ["*"], "format": "pdf"}]}`,
+		"unknown sign":     `{"sources": [{"name": "A", "files": ["*"], "sign_convention": "upside-down"}]}`,
+		"unknown timezone": `{"sources": [{"name": "A", "files": ["*"], "timezone": "Mars/Olympus"}]}`,
+		"unknown encoding": `{"sources": [{"name": "A", "files": ["*"], "encoding": "ebcdic"}]}`,
+		"xlsx encoding":    `{"sources": [{"name": "A", "files": ["*"], "format": "xlsx", "encoding": "utf-8"}]}`,
+		"bad balance":      `{"sources": [{"name": "A", "files": ["*"], "opening_balance": "lots"}]}`,
+		"not json":         `sources:`,
+	}
+	for name, config := range invalid {
+		_, err := ParseSourceRegistry([]byte(config))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMatchSourcePattern(t *testing.T) {
+	assert.True(t, matchSourcePattern("*bca*.csv", "/data/june/bca.csv"))
+	assert.True(t, matchSourcePattern("bca/*", "/data/bca/june.csv"))
+	assert.True(t, matchSourcePattern("2025-06/bca.csv", "./statements/2025-06/bca.csv"))
+	assert.True(t, matchSourcePattern("mandiri*", "bundle.zip/mandiri.csv"))
+	assert.False(t, matchSourcePattern("bca/*", "/data/bca.csv"))
+	assert.False(t, matchSourcePattern("*bca*.csv", "bri.csv"))
+}
+
+func TestRegistryStatementReader_ReadBankTransactions(t *testing.T) {
+	registry, err := ParseSourceRegistry([]byte(testSourcesConfig))
+	require.NoError(t, err)
+	reader := NewRegistryStatementReader(registry, nil, NewCsvLedgerReader())
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	root := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+		return path
+	}
+	june := write("june/bca.csv", []byte("unique_identifier,amount,date\nbank001,100,2023-01-15"))
+	july := write("july/bca-renamed.csv", []byte("unique_identifier,amount,date\nbank002,200,2023-01-20"))
+	bundle := write("bundle.zip", zipBytes(t, map[string][]byte{
+		"mandiri.csv": []byte("unique_identifier,amount,date\nbank003,300,2023-01-21"),
+	}))
+
+	t.Run("transactions are attributed to declared sources", func(t *testing.T) {
+		txs, err := reader.ReadBankTransactions(context.Background(), []string{june, july, bundle}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 3)
+
+		jakarta, err := time.LoadLocation("Asia/Jakarta")
+		require.NoError(t, err)
+		byID := make(map[string]int)
+		for i, tx := range txs {
+			byID[tx.ID] = i
+		}
+
+		bca := txs[byID["bank001"]]
+		assert.Equal(t, "BCA Operating", bca.BankName)
+		assert.Equal(t, "123-456", bca.AccountNumber)
+		assert.Equal(t, time.Date(2023, 1, 15, 0, 0, 0, 0, jakarta), bca.Date)
+		assert.Equal(t, "BCA Operating", txs[byID["bank002"]].BankName)
+
+		mandiri := txs[byID["bank003"]]
+		assert.Equal(t, "Mandiri Payroll", mandiri.BankName)
+		assert.Equal(t, "-300", mandiri.Amount.String())
+	})
+
+	t.Run("declared encoding", func(t *testing.T) {
+		registry, err := ParseSourceRegistry([]byte(`{"sources": [{"name": "Legacy", "encoding": "cp1252", "files": ["legacy*"]}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, "csv", registry.sources[0].Format)
+
+		legacy := write("legacy.csv", []byte("unique_identifier,amount,date\nb\xe9\xe9,100,2023-01-15"))
+		txs, err := NewRegistryStatementReader(registry, nil, nil).ReadBankTransactions(context.Background(), []string{legacy}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Equal(t, "b\u00e9\u00e9", txs[0].ID)
+	})
+
+	t.Run("iterator attributes transactions to declared sources", func(t *testing.T) {
+		expected, err := reader.ReadBankTransactions(context.Background(), []string{june, july, bundle}, startDate, endDate)
+		require.NoError(t, err)
+		txs, errs := drain(reader.BankTransactions(context.Background(), []string{june, july, bundle}, startDate, endDate))
+		assert.Empty(t, errs)
+		assert.Equal(t, expected, txs)
+
+		other := write("bri-stream.csv", []byte("unique_identifier,amount,date"))
+		_, errs = drain(reader.BankTransactions(context.Background(), []string{other}, startDate, endDate))
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "does not match any declared source")
+	})
+
+	t.Run("undeclared file", func(t *testing.T) {
+		other := write("bri.csv", []byte("unique_identifier,amount,date\nbank004,400,2023-01-22"))
+		_, err := reader.ReadBankTransactions(context.Background(), []string{other}, startDate, endDate)
+		assert.ErrorContains(t, err, "does not match any declared source")
+
+		empty := write("bri-empty.csv", []byte("unique_identifier,amount,date"))
+		_, err = reader.ReadBankTransactions(context.Background(), []string{empty}, startDate, endDate)
+		assert.ErrorContains(t, err, "does not match any declared source")
+	})
+
+	t.Run("load from file", func(t *testing.T) {
+		path := write("sources.json", []byte(testSourcesConfig))
+		_, err := LoadSourceRegistry(path)
+		require.NoError(t, err)
+
+		_, err = LoadSourceRegistry(filepath.Join(root, "missing.json"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultSQLLedgerQuery binds the period as $1 (inclusive start) and $2 (exclusive end).
+// Rows are ordered by time so the result can be streamed into the engine.
+const DefaultSQLLedgerQuery = `SELECT trx_id, amount, type, transaction_time FROM transactions WHERE transaction_time >= $1 AND transaction_time < $2 ORDER BY transaction_time`
+
+// sqlRejectSource names query results in rejected row reports; rows are
+// numbered in result order.
+const sqlRejectSource = "system transaction query"
+
+type SQLColumnMapping struct {
+	ID     string
+	Amount string
+	Type   string
+	Time   string
+}
+
+var DefaultSQLColumnMapping = SQLColumnMapping{
+	ID:     "trx_id",
+	Amount: "amount",
+	Type:   "type",
+	Time:   "transaction_time",
+}
+
+// ParseSQLColumnMapping reads overrides such as "id=ref,time=created_at" on top
+// of DefaultSQLColumnMapping.
+func ParseSQLColumnMapping(spec string) (SQLColumnMapping, error) {
+	mapping := DefaultSQLColumnMapping
+	if strings.TrimSpace(spec) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return mapping, fmt.Errorf("invalid column mapping '%s', expected field=column", pair)
+		}
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "id":
+			mapping.ID = column
+		case "amount":
+			mapping.Amount = column
+		case "type":
+			mapping.Type = column
+		case "time":
+			mapping.Time = column
+		default:
+			return mapping, fmt.Errorf("unknown column mapping field '%s', expected id, amount, type or time", field)
+		}
+	}
+	return mapping, nil
+}
+
+type SQLLedgerConfig struct {
+	Query   string
+	Columns SQLColumnMapping
+	// TypeAliases defaults to domain.DefaultTransactionTypeAliases.
+	TypeAliases domain.TransactionTypeAliases
+}
+
+type SQLLedgerReader struct {
+	db       *sql.DB
+	config   SQLLedgerConfig
+	rejected *domain.RejectionLog
+}
+
+func NewSQLLedgerReader(db *sql.DB, config SQLLedgerConfig) *SQLLedgerReader {
+	if config.Query == "" {
+		config.Query = DefaultSQLLedgerQuery
+	}
+	if config.Columns == (SQLColumnMapping{}) {
+		config.Columns = DefaultSQLColumnMapping
+	}
+	if config.TypeAliases == nil {
+		config.TypeAliases = domain.DefaultTransactionTypeAliases
+	}
+	return &SQLLedgerReader{db: db, config: config}
+}
+
+// WithRejectionLog reports the rows the reader skips to log.
+func (r *SQLLedgerReader) WithRejectionLog(log *domain.RejectionLog) *SQLLedgerReader {
+	r.rejected = log
+	return r
+}
+
+// ReadSystemTransactions ignores filePath; transactions come from the configured query.
+func (r *SQLLedgerReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	return collect(func(yield func(domain.SystemTransaction) bool) error {
+		return r.query(ctx, startDate, endDate, yield)
+	})
+}
+
+// SystemTransactions streams the rows of the query as they are fetched.
+func (r *SQLLedgerReader) SystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) iter.Seq2[domain.SystemTransaction, error] {
+	return func(yield func(domain.SystemTransaction, error) bool) {
+		err := r.query(ctx, startDate, endDate, func(tx domain.SystemTransaction) bool {
+			return yield(tx, nil)
+		})
+		if err != nil {
+			yield(domain.SystemTransaction{}, err)
+		}
+	}
+}
+
+func (r *SQLLedgerReader) query(ctx context.Context, startDate, endDate time.Time, yield func(domain.SystemTransaction) bool) error {
+	periodEnd := endDate.Add(24 * time.Hour)
+	rows, err := r.db.QueryContext(ctx, r.config.Query, startDate, periodEnd)
+	if err != nil {
+		return fmt.Errorf("could not query system transactions: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("could not read columns of system transaction query: %w", err)
+	}
+	idCol, amountCol, typeCol, timeCol, err := r.columnIndexes(columns)
+	if err != nil {
+		return err
+	}
+
+	// IDs and amounts are scanned as text, so that numeric and float columns
+	// are parsed exactly and NULL stays apart from an empty value.
+	var id, amountText sql.NullString
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	dest[idCol], dest[amountCol] = &id, &amountText
+
+	for row := 1; rows.Next(); row++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("error reading system transaction row: %w", err)
+		}
+
+		txTime, err := sqlTime(values[timeCol])
+		if err != nil {
+			rejectRow(r.rejected, sqlRejectSource, row, "invalid transaction time: %v", err)
+			continue
+		}
+
+		if txTime.Before(startDate) || !txTime.Before(periodEnd) {
+			continue
+		}
+
+		if !id.Valid || id.String == "" {
+			rejectRow(r.rejected, sqlRejectSource, row, "missing transaction ID")
+			continue
+		}
+
+		amount, err := sqlDecimal(amountText)
+		if err != nil {
+			rejectRow(r.rejected, sqlRejectSource, row, "invalid amount: %v", err)
+			continue
+		}
+
+		txType, err := r.config.TypeAliases.Normalize(sqlString(values[typeCol]))
+		if err != nil {
+			rejectRow(r.rejected, sqlRejectSource, row, "%v", err)
+			continue
+		}
+
+		tx := domain.SystemTransaction{
+			ID:              id.String,
+			Amount:          amount,
+			Type:            txType,
+			TransactionTime: txTime,
+		}
+		if !yield(tx) {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading system transaction rows: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLLedgerReader) columnIndexes(columns []string) (idCol, amountCol, typeCol, timeCol int, err error) {
+	find := func(name string) (int, error) {
+		for i, column := range columns {
+			if strings.EqualFold(column, name) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("system transaction query does not return column '%s'", name)
+	}
+	if idCol, err = find(r.config.Columns.ID); err != nil {
+		return
+	}
+	if amountCol, err = find(r.config.Columns.Amount); err != nil {
+		return
+	}
+	if typeCol, err = find(r.config.Columns.Type); err != nil {
+		return
+	}
+	timeCol, err = find(r.config.Columns.Time)
+	return
+}
+
+func sqlString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sqlDecimal(value sql.NullString) (decimal.Decimal, error) {
+	if !value.Valid {
+		return decimal.Zero, fmt.Errorf("amount is NULL")
+	}
+	return decimal.NewFromString(value.String)
+}
+
+func sqlTime(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case nil:
+		return time.Time{}, fmt.Errorf("transaction time is NULL")
+	default:
+		return time.Parse(time.RFC3339, sqlString(v))
+	}
+}
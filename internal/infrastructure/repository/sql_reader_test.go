@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLedgerDriver serves canned result sets keyed by DSN and records the
+// arguments each query was run with.
+type fakeLedgerDriver struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	args    map[string][]driver.Value
+}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+var testLedgerDriver = &fakeLedgerDriver{results: map[string]fakeResult{}, args: map[string][]driver.Value{}}
+
+func init() {
+	sql.Register("fakeledger", testLedgerDriver)
+}
+
+func openFakeLedger(t *testing.T, result fakeResult) (*sql.DB, string) {
+	t.Helper()
+	dsn := t.Name()
+	testLedgerDriver.mu.Lock()
+	testLedgerDriver.results[dsn] = result
+	testLedgerDriver.mu.Unlock()
+	db, err := sql.Open("fakeledger", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, dsn
+}
+
+func (d *fakeLedgerDriver) Open(dsn string) (driver.Conn, error) {
+	return &fakeConn{driver: d, dsn: dsn}, nil
+}
+
+type fakeConn struct {
+	driver *fakeLedgerDriver
+	dsn    string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{conn: c}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	conn *fakeConn
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.args[s.conn.dsn] = args
+	result := d.results[s.conn.dsn]
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func TestSQLLedgerReader_ReadSystemTransactions(t *testing.T) {
+	startDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	t.Run("default columns and period binding", func(t *testing.T) {
+		db, dsn := openFakeLedger(t, fakeResult{
+			columns: []string{"trx_id", "amount", "type", "transaction_time"},
+			rows: [][]driver.Value{
+				{"sys001", []byte("100.50"), "CREDIT", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)},
+				{"sys002", int64(200), []byte("DEBIT"), "2023-01-31T23:59:59Z"},
+				{"sys003", float64(1), "DEBIT", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)},
+				{"sys004", nil, "DEBIT", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{"sys005", "abc", "DEBIT", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{"sys006", "1", "DEBIT", nil},
+				{nil, "1", "DEBIT", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{"", "1", "DEBIT", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{"sys009", float64(0.1), "CREDIT", time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC)},
+			},
+		})
+
+		log := &domain.RejectionLog{}
+		txs, err := NewSQLLedgerReader(db, SQLLedgerConfig{}).WithRejectionLog(log).ReadSystemTransactions(context.Background(), "", startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 3)
+		assert.Equal(t, "sys001", txs[0].ID)
+		assert.Equal(t, "100.5", txs[0].Amount.String())
+		assert.Equal(t, "DEBIT", string(txs[1].Type))
+		assert.Equal(t, "0.1", txs[2].Amount.String(), "float columns are parsed from their shortest text form")
+		assert.Equal(t, []driver.Value{startDate, time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)}, testLedgerDriver.args[dsn])
+
+		rows := log.Rows()
+		require.Len(t, rows, 5)
+		assert.Equal(t, []int{4, 5, 6, 7, 8}, []int{rows[0].Line, rows[1].Line, rows[2].Line, rows[3].Line, rows[4].Line})
+		assert.Equal(t, sqlRejectSource, rows[0].Source)
+		assert.Equal(t, "missing transaction ID", rows[3].Reason)
+		assert.Equal(t, "missing transaction ID", rows[4].Reason)
+	})
+
+	t.Run("transaction types are normalised", func(t *testing.T) {
+		db, _ := openFakeLedger(t, fakeResult{
+			columns: []string{"trx_id", "amount", "type", "transaction_time"},
+			rows: [][]driver.Value{
+				{"sys001", "100", "cr", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)},
+				{"sys002", "100", "KR", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)},
+				{"sys003", "100", "VOID", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)},
+			},
+		})
+		aliases, err := ParseTypeAliases("KR=CREDIT")
+		require.NoError(t, err)
+
+		log := &domain.RejectionLog{}
+		txs, err := NewSQLLedgerReader(db, SQLLedgerConfig{TypeAliases: aliases}).WithRejectionLog(log).ReadSystemTransactions(context.Background(), "", startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+		assert.Equal(t, domain.Credit, txs[0].Type)
+		assert.Equal(t, domain.Credit, txs[1].Type)
+		assert.Equal(t, []domain.RejectedRow{{Source: sqlRejectSource, Line: 3, Reason: "unknown transaction type 'VOID'"}}, log.Rows())
+	})
+
+	t.Run("custom column mapping", func(t *testing.T) {
+		db, _ := openFakeLedger(t, fakeResult{
+			columns: []string{"REF", "VALUE", "DIRECTION", "CREATED_AT", "EXTRA"},
+			rows: [][]driver.Value{
+				{"sys001", "100", "CREDIT", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC), "ignored"},
+			},
+		})
+		columns, err := ParseSQLColumnMapping("id=ref, amount=value,type=direction,time=created_at")
+		require.NoError(t, err)
+
+		txs, err := NewSQLLedgerReader(db, SQLLedgerConfig{Query: "SELECT * FROM ledger", Columns: columns}).ReadSystemTransactions(context.Background(), "", startDate, endDate)
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+	})
+
+	t.Run("iterator", func(t *testing.T) {
+		db, _ := openFakeLedger(t, fakeResult{
+			columns: []string{"trx_id", "amount", "type", "transaction_time"},
+			rows: [][]driver.Value{
+				{"sys001", "100", "CREDIT", time.Date(2023, 1, 15, 10, 0, 0, 0, time.UTC)},
+				{"sys002", "200", "DEBIT", time.Date(2023, 1, 16, 10, 0, 0, 0, time.UTC)},
+			},
+		})
+		txs, errs := drain(NewSQLLedgerReader(db, SQLLedgerConfig{}).SystemTransactions(context.Background(), "", startDate, endDate))
+		assert.Empty(t, errs)
+		assert.Len(t, txs, 2)
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		db, _ := openFakeLedger(t, fakeResult{columns: []string{"trx_id", "amount", "type"}})
+		_, err := NewSQLLedgerReader(db, SQLLedgerConfig{}).ReadSystemTransactions(context.Background(), "", startDate, endDate)
+		assert.ErrorContains(t, err, "transaction_time")
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		db, _ := openFakeLedger(t, fakeResult{err: errors.New("connection refused")})
+		_, err := NewSQLLedgerReader(db, SQLLedgerConfig{}).ReadSystemTransactions(context.Background(), "", startDate, endDate)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+}
+
+func TestParseSQLColumnMapping(t *testing.T) {
+	mapping, err := ParseSQLColumnMapping("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSQLColumnMapping, mapping)
+
+	mapping, err = ParseSQLColumnMapping("time=created_at")
+	require.NoError(t, err)
+	assert.Equal(t, "created_at", mapping.Time)
+	assert.Equal(t, "trx_id", mapping.ID)
+
+	_, err = ParseSQLColumnMapping("colour=red")
+	assert.Error(t, err)
+
+	_, err = ParseSQLColumnMapping("id")
+	assert.Error(t, err)
+}
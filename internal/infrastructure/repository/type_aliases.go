@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// ParseTypeAliases reads extra transaction type spellings such as
+// "DB=DEBIT,KR=CREDIT" on top of domain.DefaultTransactionTypeAliases.
+func ParseTypeAliases(spec string) (domain.TransactionTypeAliases, error) {
+	aliases := maps.Clone(domain.DefaultTransactionTypeAliases)
+	if strings.TrimSpace(spec) == "" {
+		return aliases, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		alias, target, ok := strings.Cut(pair, "=")
+		alias = strings.ToUpper(strings.TrimSpace(alias))
+		if !ok || alias == "" {
+			return nil, fmt.Errorf("invalid type alias '%s', expected alias=TYPE", pair)
+		}
+		txType := domain.TransactionType(strings.ToUpper(strings.TrimSpace(target)))
+		if !txType.Valid() {
+			return nil, fmt.Errorf("invalid type alias '%s', expected %s or %s", pair, domain.Debit, domain.Credit)
+		}
+		aliases[alias] = txType
+	}
+	return aliases, nil
+}
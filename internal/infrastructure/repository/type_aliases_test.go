@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTypeAliases(t *testing.T) {
+	aliases, err := ParseTypeAliases("")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultTransactionTypeAliases, aliases)
+
+	aliases, err = ParseTypeAliases("kr=credit, DR=CREDIT")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Credit, aliases["KR"])
+	assert.Equal(t, domain.Credit, aliases["DR"])
+	assert.Equal(t, domain.Debit, aliases["D"])
+	assert.Equal(t, domain.Debit, domain.DefaultTransactionTypeAliases["DR"], "defaults are not modified")
+
+	txType, err := aliases.Normalize(" Kr ")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Credit, txType)
+
+	for _, spec := range []string{"KR", "=CREDIT", "KR=REFUND"} {
+		_, err := ParseTypeAliases(spec)
+		assert.Error(t, err, spec)
+	}
+}
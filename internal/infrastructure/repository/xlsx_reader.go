@@ -0,0 +1,523 @@
+package repository
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"math"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+const defaultHeaderSearchRows = 20
+
+var (
+	bankIDHeaders     = []string{"unique_identifier", "id", "transaction_id", "trx_id", "trxid", "reference", "ref"}
+	bankAmountHeaders = []string{"amount", "amt"}
+	bankDateHeaders   = []string{"date", "transaction_date", "value_date", "posting_date"}
+	// bankBalanceHeaders name the optional running balance column.
+	bankBalanceHeaders = []string{"balance", "running_balance", "saldo"}
+)
+
+type XlsxReaderConfig struct {
+	// Sheet selects the worksheet by name, or by 1-based position when no
+	// sheet has that name. The first sheet is used when empty.
+	Sheet            string
+	HeaderSearchRows int
+}
+
+type XlsxStatementReader struct {
+	fsys         fs.FS
+	config       XlsxReaderConfig
+	rejected     *domain.RejectionLog
+	fingerprints *domain.FingerprintLog
+}
+
+func NewXlsxStatementReader(config XlsxReaderConfig) *XlsxStatementReader {
+	return NewXlsxStatementReaderFS(nil, config)
+}
+
+// NewXlsxStatementReaderFS resolves file paths against fsys instead of the OS filesystem.
+func NewXlsxStatementReaderFS(fsys fs.FS, config XlsxReaderConfig) *XlsxStatementReader {
+	if config.HeaderSearchRows <= 0 {
+		config.HeaderSearchRows = defaultHeaderSearchRows
+	}
+	return &XlsxStatementReader{fsys: fsys, config: config}
+}
+
+// WithRejectionLog reports the rows the reader skips to log.
+func (r *XlsxStatementReader) WithRejectionLog(log *domain.RejectionLog) *XlsxStatementReader {
+	r.rejected = log
+	return r
+}
+
+// WithFingerprintLog reports the SHA-256 of every workbook the reader reads to
+// the end to log, for the duplicate statement check.
+func (r *XlsxStatementReader) WithFingerprintLog(log *domain.FingerprintLog) *XlsxStatementReader {
+	r.fingerprints = log
+	return r
+}
+
+func (r *XlsxStatementReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	return readConcurrently(ctx, filePaths, func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+		return collect(func(yield func(domain.BankTransaction) bool) error {
+			return r.parseWorkbookFile(ctx, filePaths[i], startDate, endDate, yield)
+		})
+	})
+}
+
+// BankTransactions yields workbooks one after another. The selected sheet of
+// the current workbook is held in memory, which Excel's row limit bounds.
+func (r *XlsxStatementReader) BankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		for _, filePath := range filePaths {
+			stopped := false
+			err := r.parseWorkbookFile(ctx, filePath, startDate, endDate, func(tx domain.BankTransaction) bool {
+				stopped = !yield(tx, nil)
+				return !stopped
+			})
+			if stopped {
+				return
+			}
+			if err != nil && !yield(domain.BankTransaction{}, &domain.SourceError{Source: filePath, Err: err}) {
+				return
+			}
+		}
+	}
+}
+
+func (r *XlsxStatementReader) parseWorkbookFile(ctx context.Context, filePath string, startDate, endDate time.Time, yield func(domain.BankTransaction) bool) error {
+	file, err := openFile(r.fsys, filePath)
+	if err != nil {
+		return fmt.Errorf("could not open bank statement workbook '%s': %w", filePath, err)
+	}
+	defer file.Close()
+
+	ra, size, err := readerAt(file, file)
+	if err != nil {
+		return fmt.Errorf("could not open bank statement workbook '%s': %w", filePath, err)
+	}
+	return r.parseSingleWorkbook(ctx, ra, size, filePath, path.Base(filepath.ToSlash(filePath)), startDate, endDate, yield)
+}
+
+func (r *XlsxStatementReader) ReadBankTransactionsFrom(ctx context.Context, sources []domain.InputSource, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	return readConcurrently(ctx, sourceNames(sources), func(ctx context.Context, i int) ([]domain.BankTransaction, error) {
+		source := sources[i]
+		ra, size, err := readerAt(source.Reader, source.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("could not open bank statement workbook '%s': %w", source.Name, err)
+		}
+		return collect(func(yield func(domain.BankTransaction) bool) error {
+			return r.parseSingleWorkbook(ctx, ra, size, source.Name, source.Name, startDate, endDate, yield)
+		})
+	})
+}
+
+func (r *XlsxStatementReader) parseSingleWorkbook(ctx context.Context, ra io.ReaderAt, size int64, filePath, bankName string, startDate, endDate time.Time, yield func(domain.BankTransaction) bool) error {
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return fmt.Errorf("could not open bank statement workbook '%s': %w", filePath, err)
+	}
+
+	wb, err := openXlsxWorkbook(zr)
+	if err != nil {
+		return fmt.Errorf("could not read workbook '%s': %w", filePath, err)
+	}
+	rows, rowNumbers, err := wb.sheetRows(r.config.Sheet)
+	if err != nil {
+		return fmt.Errorf("could not read sheet from '%s': %w", filePath, err)
+	}
+
+	headerRow, idCol, amountCol, dateCol, err := detectBankHeader(rows, r.config.HeaderSearchRows)
+	if err != nil {
+		return fmt.Errorf("could not read header from '%s': %w", filePath, err)
+	}
+	balanceCol := findColumn(rows[headerRow], bankBalanceHeaders)
+
+	for i := headerRow + 1; i < len(rows); i++ {
+		row := rows[i]
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		id := cellAt(row, idCol)
+		if id == "" {
+			continue
+		}
+
+		date, err := parseXlsxDate(cellAt(row, dateCol), wb.date1904)
+		if err != nil {
+			rejectRow(r.rejected, filePath, rowNumbers[i], "invalid date '%s'", cellAt(row, dateCol))
+			continue
+		}
+
+		if date.Before(startDate) || date.After(endDate) {
+			continue
+		}
+
+		amount, err := decimal.NewFromString(cellAt(row, amountCol))
+		if err != nil {
+			rejectRow(r.rejected, filePath, rowNumbers[i], "invalid amount '%s'", cellAt(row, amountCol))
+			continue
+		}
+
+		balance, err := parseBalance(cellAt(row, balanceCol))
+		if err != nil {
+			rejectRow(r.rejected, filePath, rowNumbers[i], "invalid balance '%s'", cellAt(row, balanceCol))
+			continue
+		}
+
+		tx := domain.BankTransaction{
+			ID:       id,
+			Amount:   amount,
+			Date:     date,
+			BankName: bankName,
+			Source:   filePath,
+			Balance:  balance,
+		}
+		if !yield(tx) {
+			return nil
+		}
+	}
+
+	if r.fingerprints == nil {
+		return nil
+	}
+	content := sha256.New()
+	if _, err := io.Copy(content, io.NewSectionReader(ra, 0, size)); err != nil {
+		return fmt.Errorf("could not read workbook '%s': %w", filePath, err)
+	}
+	reportFingerprint(r.fingerprints, filePath, content)
+	return nil
+}
+
+func detectBankHeader(rows [][]string, searchRows int) (headerRow, idCol, amountCol, dateCol int, err error) {
+	for i, row := range rows {
+		if i >= searchRows {
+			break
+		}
+		idCol, amountCol, dateCol = -1, -1, -1
+		for col, value := range row {
+			name := normalizeHeader(value)
+			switch {
+			case idCol < 0 && containsString(bankIDHeaders, name):
+				idCol = col
+			case amountCol < 0 && containsString(bankAmountHeaders, name):
+				amountCol = col
+			case dateCol < 0 && containsString(bankDateHeaders, name):
+				dateCol = col
+			}
+		}
+		if idCol >= 0 && amountCol >= 0 && dateCol >= 0 {
+			return i, idCol, amountCol, dateCol, nil
+		}
+	}
+	return 0, 0, 0, 0, fmt.Errorf("no header row with id, amount and date columns in the first %d rows", searchRows)
+}
+
+// findColumn returns the first column of header named one of names, or -1.
+func findColumn(header []string, names []string) int {
+	for col, value := range header {
+		if containsString(names, normalizeHeader(value)) {
+			return col
+		}
+	}
+	return -1
+}
+
+// parseBalance reads an optional balance cell (see cellAt); empty cells have
+// no balance.
+func parseBalance(value string) (decimal.NullDecimal, error) {
+	if value == "" {
+		return decimal.NullDecimal{}, nil
+	}
+	balance, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.NullDecimal{}, err
+	}
+	return decimal.NewNullDecimal(balance), nil
+}
+
+func normalizeHeader(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	return strings.NewReplacer(" ", "_", "-", "_", ".", "").Replace(value)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func cellAt(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[col])
+}
+
+// parseXlsxDate accepts Excel serial dates as well as ISO dates stored as text.
+func parseXlsxDate(value string, date1904 bool) (time.Time, error) {
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		return excelSerialToDate(serial, date1904)
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func excelSerialToDate(serial float64, date1904 bool) (time.Time, error) {
+	if serial < 0 || math.IsNaN(serial) || math.IsInf(serial, 0) {
+		return time.Time{}, fmt.Errorf("invalid excel serial date %v", serial)
+	}
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	switch {
+	case date1904:
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	case serial < 61:
+		// Excel treats 1900 as a leap year, so serials before 1900-03-01 are off by one.
+		epoch = time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+	return epoch.AddDate(0, 0, int(math.Floor(serial))), nil
+}
+
+type xlsxWorkbook struct {
+	files         map[string]*zip.File
+	sheets        []xlsxSheetRef
+	sharedStrings []string
+	date1904      bool
+}
+
+type xlsxSheetRef struct {
+	name string
+	path string
+}
+
+type xlsxWorkbookXML struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationshipsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt xlsxRichText) String() string {
+	if len(rt.Runs) == 0 {
+		return rt.T
+	}
+	var sb strings.Builder
+	sb.WriteString(rt.T)
+	for _, run := range rt.Runs {
+		sb.WriteString(run.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStringsXML struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxSheetXML struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func openXlsxWorkbook(zr *zip.Reader) (*xlsxWorkbook, error) {
+	wb := &xlsxWorkbook{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		wb.files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var workbook xlsxWorkbookXML
+	if err := wb.decode("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	wb.date1904 = workbook.WorkbookPr.Date1904
+
+	var rels xlsxRelationshipsXML
+	if err := wb.decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+	for _, sheet := range workbook.Sheets {
+		wb.sheets = append(wb.sheets, xlsxSheetRef{name: sheet.Name, path: targets[sheet.RID]})
+	}
+	if len(wb.sheets) == 0 {
+		return nil, fmt.Errorf("workbook has no sheets")
+	}
+
+	if _, ok := wb.files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSharedStringsXML
+		if err := wb.decode("xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		wb.sharedStrings = make([]string, len(sst.Items))
+		for i, item := range sst.Items {
+			wb.sharedStrings[i] = item.String()
+		}
+	}
+	return wb, nil
+}
+
+func (wb *xlsxWorkbook) decode(name string, v any) error {
+	f, ok := wb.files[name]
+	if !ok {
+		return fmt.Errorf("missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("could not parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func (wb *xlsxWorkbook) findSheet(selector string) (xlsxSheetRef, error) {
+	if selector == "" {
+		return wb.sheets[0], nil
+	}
+	for _, sheet := range wb.sheets {
+		if strings.EqualFold(sheet.name, selector) {
+			return sheet, nil
+		}
+	}
+	if n, err := strconv.Atoi(selector); err == nil && n >= 1 && n <= len(wb.sheets) {
+		return wb.sheets[n-1], nil
+	}
+	return xlsxSheetRef{}, fmt.Errorf("sheet '%s' not found", selector)
+}
+
+// sheetRows returns the rows present in the sheet along with their 1-based row
+// numbers; empty rows are usually not stored, so the two can differ.
+func (wb *xlsxWorkbook) sheetRows(selector string) ([][]string, []int, error) {
+	sheet, err := wb.findSheet(selector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var data xlsxSheetXML
+	if err := wb.decode(sheet.path, &data); err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([][]string, 0, len(data.Rows))
+	numbers := make([]int, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		number := row.Ref
+		if number <= 0 {
+			number = 1
+			if len(numbers) > 0 {
+				number = numbers[len(numbers)-1] + 1
+			}
+		}
+		numbers = append(numbers, number)
+		var values []string
+		for i, cell := range row.Cells {
+			col, err := columnIndex(cell.Ref)
+			if err != nil {
+				return nil, nil, err
+			}
+			if col < 0 {
+				col = i
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			values[col] = wb.cellValue(cell.Type, cell.Value, cell.Inline)
+		}
+		rows = append(rows, values)
+	}
+	return rows, numbers, nil
+}
+
+func (wb *xlsxWorkbook) cellValue(cellType, value string, inline xlsxRichText) string {
+	switch cellType {
+	case "s":
+		idx, err := strconv.Atoi(value)
+		if err != nil || idx < 0 || idx >= len(wb.sharedStrings) {
+			return ""
+		}
+		return wb.sharedStrings[idx]
+	case "inlineStr":
+		return inline.String()
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return value
+	}
+}
+
+// maxXlsxColumns is the number of columns Excel allows, A to XFD.
+const maxXlsxColumns = 16384
+
+// columnIndex converts the letter part of a cell reference such as "AB12" to
+// a 0-based column, or -1 when the reference has no letters. Columns beyond
+// XFD are rejected, since rows are allocated up to the column.
+func columnIndex(ref string) (int, error) {
+	col := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		if col > maxXlsxColumns {
+			return 0, fmt.Errorf("cell reference '%s' is beyond column XFD", ref)
+		}
+	}
+	return col - 1, nil
+}
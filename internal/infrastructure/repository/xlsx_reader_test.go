@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSheet struct {
+	name string
+	rows [][]string
+}
+
+// createTempXlsx writes a minimal workbook. Cells prefixed with "n:" are stored
+// as numbers, everything else goes through the shared strings table.
+func createTempXlsx(t *testing.T, date1904 bool, sheets ...testSheet) string {
+	t.Helper()
+	tmpfile, err := os.CreateTemp("", "test_*.xlsx")
+	require.NoError(t, err, "Failed to create temp file")
+	t.Cleanup(func() { os.Remove(tmpfile.Name()) })
+
+	zw := zip.NewWriter(tmpfile)
+	write := func(name, content string) {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	var sheetEntries, relEntries strings.Builder
+	var sharedStrings []string
+	for i, sheet := range sheets {
+		fmt.Fprintf(&sheetEntries, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, sheet.name, i+1, i+1)
+		fmt.Fprintf(&relEntries, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+
+		var data strings.Builder
+		for r, row := range sheet.rows {
+			fmt.Fprintf(&data, `<row r="%d">`, r+1)
+			for c, value := range row {
+				if value == "" {
+					continue
+				}
+				ref := fmt.Sprintf("%c%d", 'A'+c, r+1)
+				if num, ok := strings.CutPrefix(value, "n:"); ok {
+					fmt.Fprintf(&data, `<c r="%s"><v>%s</v></c>`, ref, num)
+					continue
+				}
+				fmt.Fprintf(&data, `<c r="%s" t="s"><v>%d</v></c>`, ref, len(sharedStrings))
+				sharedStrings = append(sharedStrings, value)
+			}
+			data.WriteString(`</row>`)
+		}
+		write(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1),
+			`<?xml version="1.0" encoding="UTF-8"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+data.String()+`</sheetData></worksheet>`)
+	}
+
+	write("xl/workbook.xml", fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><workbookPr date1904="%t"/><sheets>%s</sheets></workbook>`, date1904, sheetEntries.String()))
+	write("xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`+relEntries.String()+`</Relationships>`)
+
+	var sst strings.Builder
+	for _, s := range sharedStrings {
+		fmt.Fprintf(&sst, `<si><t>%s</t></si>`, s)
+	}
+	write("xl/sharedStrings.xml", `<?xml version="1.0" encoding="UTF-8"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+sst.String()+`</sst>`)
+
+	require.NoError(t, zw.Close())
+	require.NoError(t, tmpfile.Close())
+	return tmpfile.Name()
+}
+
+func TestXlsxStatementReader_ReadBankTransactions(t *testing.T) {
+	startDate := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+
+	t.Run("header detection and serial dates", func(t *testing.T) {
+		file := createTempXlsx(t, false, testSheet{name: "Statement", rows: [][]string{
+			{"Account Statement"},
+			{"Account No", "1234567"},
+			{},
+			{"Date", "Description", "Reference", "Amount"},
+			{"n:45831", "Transfer", "BNK-A-100", "n:50000"},
+			{"2025-06-24", "Fee", "BNK-FEE-X", "n:-15000.5"},
+			{"n:45870", "Out of range", "BNK-A-999", "n:1"},
+		}})
+
+		txs, err := NewXlsxStatementReader(XlsxReaderConfig{}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+		byID := map[string]int{}
+		for i, tx := range txs {
+			byID[tx.ID] = i
+		}
+		first := txs[byID["BNK-A-100"]]
+		assert.Equal(t, time.Date(2025, 6, 23, 0, 0, 0, 0, time.UTC), first.Date)
+		assert.Equal(t, "50000", first.Amount.String())
+		assert.Equal(t, filepath.Base(file), first.BankName)
+		assert.Equal(t, "-15000.5", txs[byID["BNK-FEE-X"]].Amount.String())
+	})
+
+	t.Run("sheet selection by name and position", func(t *testing.T) {
+		file := createTempXlsx(t, false,
+			testSheet{name: "Summary", rows: [][]string{{"nothing here"}}},
+			testSheet{name: "Transactions", rows: [][]string{
+				{"unique_identifier", "amount", "date"},
+				{"BNK-1", "n:10", "n:45831"},
+			}},
+		)
+
+		txs, err := NewXlsxStatementReader(XlsxReaderConfig{Sheet: "transactions"}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+
+		txs, err = NewXlsxStatementReader(XlsxReaderConfig{Sheet: "2"}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		require.NoError(t, err)
+		assert.Len(t, txs, 1)
+
+		_, err = NewXlsxStatementReader(XlsxReaderConfig{}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		assert.Error(t, err)
+
+		_, err = NewXlsxStatementReader(XlsxReaderConfig{Sheet: "Missing"}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		assert.Error(t, err)
+	})
+
+	t.Run("1904 date system", func(t *testing.T) {
+		file := createTempXlsx(t, true, testSheet{name: "Sheet1", rows: [][]string{
+			{"id", "amount", "date"},
+			{"BNK-1", "n:10", "n:44369"},
+		}})
+
+		txs, err := NewXlsxStatementReader(XlsxReaderConfig{}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Equal(t, time.Date(2025, 6, 23, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	})
+
+	t.Run("header outside search window", func(t *testing.T) {
+		file := createTempXlsx(t, false, testSheet{name: "Sheet1", rows: [][]string{
+			{"title"},
+			{"subtitle"},
+			{"id", "amount", "date"},
+		}})
+
+		_, err := NewXlsxStatementReader(XlsxReaderConfig{HeaderSearchRows: 2}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed rows are skipped", func(t *testing.T) {
+		file := createTempXlsx(t, false, testSheet{name: "Sheet1", rows: [][]string{
+			{"id", "amount", "date"},
+			{"BNK-1", "abc", "n:45831"},
+			{"BNK-2", "n:10", "not-a-date"},
+			{"", "n:10", "n:45831"},
+		}})
+
+		log := &domain.RejectionLog{}
+		txs, err := NewXlsxStatementReader(XlsxReaderConfig{}).WithRejectionLog(log).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		require.NoError(t, err)
+		assert.Empty(t, txs)
+		assert.Equal(t, []domain.RejectedRow{
+			{Source: file, Line: 2, Reason: "invalid amount 'abc'"},
+			{Source: file, Line: 3, Reason: "invalid date 'not-a-date'"},
+		}, log.Rows())
+	})
+
+	t.Run("balance column", func(t *testing.T) {
+		file := createTempXlsx(t, false, testSheet{name: "Sheet1", rows: [][]string{
+			{"Reference", "Amount", "Date", "Saldo"},
+			{"BNK-1", "n:100", "n:45831", "n:1100.5"},
+			{"BNK-2", "n:-50", "n:45832"},
+		}})
+
+		txs, err := NewXlsxStatementReader(XlsxReaderConfig{}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 2)
+		assert.Equal(t, "1100.5", txs[0].Balance.Decimal.String())
+		assert.False(t, txs[1].Balance.Valid)
+	})
+
+	t.Run("content fingerprint", func(t *testing.T) {
+		file := createTempXlsx(t, false, testSheet{name: "Sheet1", rows: [][]string{
+			{"id", "amount", "date"},
+			{"BNK-1", "n:10", "n:45831"},
+		}})
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		log := &domain.FingerprintLog{}
+		_, err = NewXlsxStatementReader(XlsxReaderConfig{}).WithFingerprintLog(log).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{file: fmt.Sprintf("%x", sha256.Sum256(data))}, log.Fingerprints())
+	})
+
+	t.Run("not a workbook", func(t *testing.T) {
+		file := createTempCsv(t, "unique_identifier,amount,date")
+		_, err := NewXlsxStatementReader(XlsxReaderConfig{}).ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+		assert.Error(t, err)
+	})
+}
+
+func TestXlsxStatementReader_BankTransactions(t *testing.T) {
+	startDate := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	file := createTempXlsx(t, false, testSheet{name: "Statement", rows: [][]string{
+		{"Date", "Reference", "Amount"},
+		{"2025-06-23", "BNK-A-100", "n:50000"},
+		{"2025-06-24", "BNK-FEE-X", "n:-15000"},
+	}})
+	reader := NewXlsxStatementReader(XlsxReaderConfig{})
+
+	expected, err := reader.ReadBankTransactions(context.Background(), []string{file}, startDate, endDate)
+	require.NoError(t, err)
+	txs, errs := drain(reader.BankTransactions(context.Background(), []string{file, "missing.xlsx"}, startDate, endDate))
+	assert.Equal(t, expected, txs)
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "missing.xlsx")
+}
+
+func TestColumnIndex(t *testing.T) {
+	cases := map[string]int{"A1": 0, "AB12": 27, "XFD1": maxXlsxColumns - 1, "12": -1, "": -1}
+	for ref, expected := range cases {
+		col, err := columnIndex(ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, expected, col, ref)
+	}
+
+	for _, ref := range []string{"XFE1", "ZZZZZZZ1", strings.Repeat("Z", 40) + "1"} {
+		_, err := columnIndex(ref)
+		assert.Error(t, err, ref)
+	}
+}
+
+func TestExcelSerialToDate(t *testing.T) {
+	testCases := []struct {
+		serial   float64
+		date1904 bool
+		expected time.Time
+	}{
+		{serial: 1, expected: time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{serial: 59, expected: time.Date(1900, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{serial: 61, expected: time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{serial: 45831.75, expected: time.Date(2025, 6, 23, 0, 0, 0, 0, time.UTC)},
+		{serial: 0, date1904: true, expected: time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		date, err := excelSerialToDate(tc.serial, tc.date1904)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, date, "serial %v", tc.serial)
+	}
+
+	_, err := excelSerialToDate(-1, false)
+	assert.Error(t, err)
+}
+
+func TestXlsxStatementReader_FSAndStreams(t *testing.T) {
+	startDate := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	data, err := os.ReadFile(createTempXlsx(t, false, testSheet{name: "Sheet1", rows: [][]string{
+		{"id", "amount", "date"},
+		{"BNK-1", "n:10", "n:45831"},
+	}}))
+	require.NoError(t, err)
+
+	t.Run("fs", func(t *testing.T) {
+		reader := NewXlsxStatementReaderFS(fstest.MapFS{"june/bca.xlsx": {Data: data}}, XlsxReaderConfig{})
+		txs, err := reader.ReadBankTransactions(context.Background(), []string{"june/bca.xlsx"}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Equal(t, "bca.xlsx", txs[0].BankName)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		reader := NewXlsxStatementReader(XlsxReaderConfig{})
+		txs, err := reader.ReadBankTransactionsFrom(context.Background(), []domain.InputSource{{Name: "upload.xlsx", Reader: bytes.NewReader(data)}}, startDate, endDate)
+		require.NoError(t, err)
+		require.Len(t, txs, 1)
+		assert.Equal(t, "upload.xlsx", txs[0].BankName)
+	})
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+// AgeUnmatched sorts the unmatched transactions of summary into
+// domain.AgingBuckets by their age in calendar days at asOf, system
+// transactions by the day of their own time zone. Transactions dated after
+// asOf count as 0 days old.
+func AgeUnmatched(summary *domain.ReconciliationSummary, asOf time.Time) *domain.Aging {
+	aging := &domain.Aging{AsOf: asOf, System: newAgingCounts()}
+	for _, tx := range summary.UnmatchedSystemTransactions {
+		addAged(aging.System, ageInDays(tx.TransactionTime, asOf), tx.Amount)
+	}
+	for _, bank := range summary.BankNames {
+		txs := summary.UnmatchedBankTransactions[bank]
+		if len(txs) == 0 {
+			continue
+		}
+		counts := newAgingCounts()
+		for _, tx := range txs {
+			addAged(counts, ageInDays(tx.Date, asOf), tx.Amount)
+		}
+		aging.Banks = append(aging.Banks, domain.BankAging{BankName: bank, Buckets: counts})
+	}
+	return aging
+}
+
+func newAgingCounts() []domain.AgingCount {
+	counts := make([]domain.AgingCount, len(domain.AgingBuckets))
+	for i, bucket := range domain.AgingBuckets {
+		counts[i].Bucket = bucket.Label
+	}
+	return counts
+}
+
+func addAged(counts []domain.AgingCount, days int, amount decimal.Decimal) {
+	for i, bucket := range domain.AgingBuckets {
+		if bucket.Contains(days) {
+			counts[i].Count++
+			counts[i].Amount = counts[i].Amount.Add(amount.Abs())
+			return
+		}
+	}
+}
+
+// ageInDays counts the calendar days from the day of t to the day of asOf.
+func ageInDays(t, asOf time.Time) int {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	return max(0, int(end.Sub(day).Hours()/24))
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgeUnmatched(t *testing.T) {
+	asOf := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	jakarta := time.FixedZone("Asia/Jakarta", 7*60*60)
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+	amount := decimal.RequireFromString
+
+	summary := &domain.ReconciliationSummary{
+		UnmatchedSystemTransactions: []domain.SystemTransaction{
+			{ID: "sys1", Amount: amount("100"), TransactionTime: time.Date(2025, 6, 30, 23, 0, 0, 0, jakarta)},
+			{ID: "sys2", Amount: amount("50.50"), TransactionTime: time.Date(2025, 6, 27, 1, 0, 0, 0, jakarta)},
+			{ID: "sys3", Amount: amount("10"), TransactionTime: time.Date(2025, 5, 31, 12, 0, 0, 0, time.UTC)},
+			{ID: "sys4", Amount: amount("20"), TransactionTime: time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)},
+		},
+		UnmatchedBankTransactions: map[string][]domain.BankTransaction{
+			"BRI": {{ID: "bri1", Amount: amount("-75"), Date: day(23)}, {ID: "bri2", Amount: amount("25"), Date: day(26)}},
+			"BCA": {{ID: "bca1", Amount: amount("5"), Date: day(29)}},
+		},
+		BankNames: []string{"HSBC", "BRI", "BCA"},
+	}
+
+	aging := AgeUnmatched(summary, asOf)
+
+	assert.Equal(t, asOf, aging.AsOf)
+	counts := func(aged []domain.AgingCount) []string {
+		var formatted []string
+		for _, count := range aged {
+			formatted = append(formatted, fmt.Sprintf("%s:%d:%s", count.Bucket, count.Count, count.Amount))
+		}
+		return formatted
+	}
+	assert.Equal(t, []string{"0-3:2:150.5", "4-7:0:0", "8-30:1:10", "30+:1:20"}, counts(aging.System),
+		"days are counted in the transaction's own time zone")
+
+	assert.Len(t, aging.Banks, 2, "banks without unmatched transactions are left out")
+	assert.Equal(t, "BRI", aging.Banks[0].BankName)
+	assert.Equal(t, []string{"0-3:0:0", "4-7:2:100", "8-30:0:0", "30+:0:0"}, counts(aging.Banks[0].Buckets), "amounts are absolute")
+	assert.Equal(t, []string{"0-3:1:5", "4-7:0:0", "8-30:0:0", "30+:0:0"}, counts(aging.Banks[1].Buckets))
+
+	after := AgeUnmatched(&domain.ReconciliationSummary{UnmatchedSystemTransactions: []domain.SystemTransaction{{Amount: amount("1"), TransactionTime: day(30).AddDate(0, 0, 2)}}}, asOf)
+	assert.Equal(t, 1, after.System[0].Count, "transactions after the period end are 0 days old")
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"iter"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+// ValidateBalances follows the running balance of every bank account through
+// its transactions in statement order and reports each stated balance that
+// is not the previous balance plus the transaction amount. The chain starts
+// at the declared opening balance, or else at the first stated balance, and
+// rows without a balance carry it forward. The final balance is checked
+// against the declared closing balance.
+func ValidateBalances(txs []domain.BankTransaction, declared []domain.DeclaredBalance) []domain.BalanceGap {
+	validator := newBalanceValidator(declared)
+	for _, tx := range txs {
+		validator.add(tx)
+	}
+	return validator.finish()
+}
+
+// ValidateBalanceStream validates the balances of a stream as it passes
+// through, see ValidateBalances. The gaps are stored in gaps once the stream
+// has been read to the end.
+func ValidateBalanceStream(txs iter.Seq2[domain.BankTransaction, error], declared []domain.DeclaredBalance, gaps *[]domain.BalanceGap) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		validator := newBalanceValidator(declared)
+		for tx, err := range txs {
+			if err == nil {
+				validator.add(tx)
+			}
+			if !yield(tx, err) {
+				return
+			}
+		}
+		*gaps = validator.finish()
+	}
+}
+
+type accountKey struct {
+	bankName, accountNumber string
+}
+
+type accountBalance struct {
+	declared domain.DeclaredBalance
+	balance  decimal.Decimal
+	known    bool
+	source   string
+}
+
+type balanceValidator struct {
+	accounts []accountKey
+	balances map[accountKey]*accountBalance
+	gaps     []domain.BalanceGap
+}
+
+func newBalanceValidator(declared []domain.DeclaredBalance) *balanceValidator {
+	v := &balanceValidator{balances: make(map[accountKey]*accountBalance)}
+	for _, d := range declared {
+		account := v.account(accountKey{d.BankName, d.AccountNumber})
+		account.declared = d
+		account.balance, account.known = d.Opening.Decimal, d.Opening.Valid
+	}
+	return v
+}
+
+func (v *balanceValidator) account(key accountKey) *accountBalance {
+	account, ok := v.balances[key]
+	if !ok {
+		account = &accountBalance{declared: domain.DeclaredBalance{BankName: key.bankName, AccountNumber: key.accountNumber}}
+		v.balances[key] = account
+		v.accounts = append(v.accounts, key)
+	}
+	return account
+}
+
+func (v *balanceValidator) add(tx domain.BankTransaction) {
+	account := v.account(accountKey{tx.BankName, tx.AccountNumber})
+	account.source = tx.Source
+	expected := account.balance.Add(tx.Amount)
+	if !tx.Balance.Valid {
+		account.balance = expected
+		return
+	}
+	if account.known && !expected.Equal(tx.Balance.Decimal) {
+		v.gaps = append(v.gaps, domain.BalanceGap{
+			BankName:      tx.BankName,
+			AccountNumber: tx.AccountNumber,
+			Source:        tx.Source,
+			TransactionID: tx.ID,
+			Expected:      expected,
+			Actual:        tx.Balance.Decimal,
+		})
+	}
+	account.balance, account.known = tx.Balance.Decimal, true
+}
+
+func (v *balanceValidator) finish() []domain.BalanceGap {
+	gaps := v.gaps
+	for _, key := range v.accounts {
+		account := v.balances[key]
+		closing := account.declared.Closing
+		if !closing.Valid || !account.known || account.balance.Equal(closing.Decimal) {
+			continue
+		}
+		gaps = append(gaps, domain.BalanceGap{
+			BankName:      key.bankName,
+			AccountNumber: key.accountNumber,
+			Source:        account.source,
+			Expected:      account.balance,
+			Actual:        closing.Decimal,
+		})
+	}
+	return gaps
+}
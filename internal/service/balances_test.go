@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withBalance(tx domain.BankTransaction, balance string) domain.BankTransaction {
+	tx.Balance = decimal.NewNullDecimal(newDecimalFromString(balance))
+	return tx
+}
+
+func TestValidateBalances(t *testing.T) {
+	row := func(id, amount, balance string) domain.BankTransaction {
+		tx := domain.BankTransaction{ID: id, Amount: newDecimalFromString(amount), Date: newDate(1), BankName: "BCA", AccountNumber: "123", Source: "bca.csv"}
+		if balance == "" {
+			return tx
+		}
+		return withBalance(tx, balance)
+	}
+	declared := func(opening, closing string) []domain.DeclaredBalance {
+		d := domain.DeclaredBalance{BankName: "BCA", AccountNumber: "123"}
+		if opening != "" {
+			d.Opening = decimal.NewNullDecimal(newDecimalFromString(opening))
+		}
+		if closing != "" {
+			d.Closing = decimal.NewNullDecimal(newDecimalFromString(closing))
+		}
+		return []domain.DeclaredBalance{d}
+	}
+
+	t.Run("consistent statement", func(t *testing.T) {
+		txs := []domain.BankTransaction{row("B1", "100", "1100"), row("B2", "-50", "1050"), row("B3", "25", "")}
+		assert.Empty(t, ValidateBalances(txs, nil))
+		assert.Empty(t, ValidateBalances(txs, declared("1000", "1075")))
+	})
+
+	t.Run("missing row", func(t *testing.T) {
+		txs := []domain.BankTransaction{row("B1", "100", "1100"), row("B3", "25", "1075")}
+		gaps := ValidateBalances(txs, nil)
+		require.Len(t, gaps, 1)
+		assert.Equal(t, "B3", gaps[0].TransactionID)
+		assert.Equal(t, "bca.csv", gaps[0].Source)
+		assert.Equal(t, "1125", gaps[0].Expected.String())
+		assert.Equal(t, "1075", gaps[0].Actual.String())
+	})
+
+	t.Run("declared balances", func(t *testing.T) {
+		txs := []domain.BankTransaction{row("B1", "100", "1100"), row("B2", "-50", "")}
+		gaps := ValidateBalances(txs, declared("900", "1000"))
+		require.Len(t, gaps, 2)
+		assert.Equal(t, "B1", gaps[0].TransactionID)
+		assert.Equal(t, "1000", gaps[0].Expected.String())
+		assert.Equal(t, "", gaps[1].TransactionID)
+		assert.Equal(t, "1050", gaps[1].Expected.String())
+		assert.Equal(t, "1000", gaps[1].Actual.String())
+	})
+
+	t.Run("statements without balances", func(t *testing.T) {
+		txs := []domain.BankTransaction{row("B1", "100", ""), row("B2", "-50", "")}
+		assert.Empty(t, ValidateBalances(txs, nil))
+		assert.Empty(t, ValidateBalances(txs, declared("", "1000")), "nothing to start from")
+
+		gaps := ValidateBalances(txs, declared("1000", "1000"))
+		require.Len(t, gaps, 1)
+		assert.Equal(t, "1050", gaps[0].Expected.String())
+		assert.Len(t, ValidateBalances(nil, declared("1000", "1200")), 1)
+	})
+
+	t.Run("accounts are followed separately", func(t *testing.T) {
+		other := row("X1", "10", "10")
+		other.AccountNumber = "987"
+		txs := []domain.BankTransaction{row("B1", "100", "1100"), other, row("B2", "-50", "1050")}
+		assert.Empty(t, ValidateBalances(txs, nil))
+	})
+}
+
+func TestValidateBalanceStream(t *testing.T) {
+	txs := []domain.BankTransaction{
+		withBalance(domain.BankTransaction{ID: "B1", Amount: newDecimalFromString("100"), Date: newDate(1), BankName: "BCA"}, "100"),
+		withBalance(domain.BankTransaction{ID: "B2", Amount: newDecimalFromString("100"), Date: newDate(2), BankName: "BCA"}, "300"),
+	}
+	var gaps []domain.BalanceGap
+	var ids []string
+	for tx, err := range ValidateBalanceStream(seqOf(txs), nil, &gaps) {
+		require.NoError(t, err)
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []string{"B1", "B2"}, ids)
+	require.Len(t, gaps, 1)
+	assert.Equal(t, "B2", gaps[0].TransactionID)
+}
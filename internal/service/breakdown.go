@@ -0,0 +1,95 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// Breakdowns adds up a summary by bank and by calendar day, from its matched
+// pairs and unmatched transactions, which together hold every transaction of
+// the run. A matched pair counts on the day of its bank transaction, which is
+// the day it was matched on; unmatched system transactions count on the day
+// of their own time zone.
+func Breakdowns(summary *domain.ReconciliationSummary) ([]domain.BankBreakdown, []domain.DayBreakdown) {
+	tally := newBreakdownTally()
+	for _, pair := range summary.MatchedPairs {
+		tally.addPair(pair)
+	}
+	tally.addUnmatched(summary)
+	return tally.result(summary.BankNames)
+}
+
+// breakdownTally adds up breakdowns one transaction at a time, so that
+// ReconcileStream can count matched pairs without keeping them.
+type breakdownTally struct {
+	banks map[string]*domain.BankBreakdown
+	days  map[time.Time]*domain.DayBreakdown
+}
+
+func newBreakdownTally() *breakdownTally {
+	return &breakdownTally{
+		banks: make(map[string]*domain.BankBreakdown),
+		days:  make(map[time.Time]*domain.DayBreakdown),
+	}
+}
+
+func (t *breakdownTally) day(at time.Time) *domain.Breakdown {
+	date := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	if t.days[date] == nil {
+		t.days[date] = &domain.DayBreakdown{Date: date}
+	}
+	return &t.days[date].Breakdown
+}
+
+func (t *breakdownTally) bank(name string) *domain.Breakdown {
+	if t.banks[name] == nil {
+		t.banks[name] = &domain.BankBreakdown{BankName: name}
+	}
+	return &t.banks[name].Breakdown
+}
+
+func (t *breakdownTally) addPair(pair domain.MatchedPair) {
+	for _, breakdown := range []*domain.Breakdown{t.bank(pair.Bank.BankName), t.day(pair.Bank.Date)} {
+		figures := breakdown.Direction(pair.System.Type)
+		figures.System.Add(pair.System.Amount)
+		figures.Bank.Add(pair.Bank.Amount)
+		figures.Matched.Add(pair.System.Amount)
+		figures.Discrepancy = figures.Discrepancy.Add(pair.Discrepancy)
+	}
+}
+
+// addUnmatched adds the unmatched transactions of summary.
+func (t *breakdownTally) addUnmatched(summary *domain.ReconciliationSummary) {
+	for _, tx := range summary.UnmatchedSystemTransactions {
+		figures := t.day(tx.TransactionTime).Direction(tx.Type)
+		figures.System.Add(tx.Amount)
+		figures.UnmatchedSystem.Add(tx.Amount)
+	}
+	for name, txs := range summary.UnmatchedBankTransactions {
+		for _, tx := range txs {
+			for _, breakdown := range []*domain.Breakdown{t.bank(name), t.day(tx.Date)} {
+				figures := breakdown.Direction(bankTxType(tx))
+				figures.Bank.Add(tx.Amount)
+				figures.UnmatchedBank.Add(tx.Amount)
+			}
+		}
+	}
+}
+
+// result lists the bank breakdowns in the order of bankNames, including banks
+// without transactions, and the day breakdowns by date.
+func (t *breakdownTally) result(bankNames []string) ([]domain.BankBreakdown, []domain.DayBreakdown) {
+	byBank := make([]domain.BankBreakdown, 0, len(bankNames))
+	for _, name := range bankNames {
+		t.bank(name)
+		byBank = append(byBank, *t.banks[name])
+	}
+	byDay := make([]domain.DayBreakdown, 0, len(t.days))
+	for _, breakdown := range t.days {
+		byDay = append(byDay, *breakdown)
+	}
+	sort.Slice(byDay, func(i, j int) bool { return byDay[i].Date.Before(byDay[j].Date) })
+	return byBank, byDay
+}
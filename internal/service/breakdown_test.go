@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakdowns(t *testing.T) {
+	jakarta := time.FixedZone("Asia/Jakarta", 7*60*60)
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+	amount := decimal.RequireFromString
+	tally := func(count int, value string) domain.Tally {
+		return domain.Tally{Count: count, Amount: amount(value)}
+	}
+
+	systemTxs := []domain.SystemTransaction{
+		{ID: "sys1", Amount: amount("100"), Type: domain.Credit, TransactionTime: day(23).Add(10 * time.Hour)},
+		{ID: "sys2", Amount: amount("250"), Type: domain.Debit, TransactionTime: day(23).Add(11 * time.Hour)},
+		{ID: "sys3", Amount: amount("75"), Type: domain.Debit, TransactionTime: time.Date(2025, 6, 24, 23, 0, 0, 0, jakarta)},
+	}
+	bankTxs := []domain.BankTransaction{
+		{ID: "bca1", Amount: amount("100"), Date: day(23), BankName: "BCA"},
+		{ID: "bca2", Amount: amount("-250.50"), Date: day(23), BankName: "BCA"},
+		{ID: "bri1", Amount: amount("15"), Date: day(24), BankName: "BRI"},
+	}
+	summary, err := NewReconciliationEngine().Reconcile(context.Background(), systemTxs, bankTxs, MatchOptions{})
+	require.NoError(t, err)
+
+	require.Len(t, summary.BankBreakdowns, 2)
+	bca, bri := summary.BankBreakdowns[0], summary.BankBreakdowns[1]
+	assert.Equal(t, "BCA", bca.BankName)
+	assert.Equal(t, tally(1, "100"), bca.Credit.Matched)
+	assert.Equal(t, tally(1, "250.50"), bca.Debit.Bank, "amounts are absolute")
+	assert.Equal(t, tally(1, "250"), bca.Debit.System, "matched system transactions count towards the bank")
+	assert.Equal(t, "0.5", bca.Debit.Discrepancy.String())
+	assert.Equal(t, tally(1, "15"), bri.Credit.UnmatchedBank)
+	assert.Equal(t, 0, bri.Debit.System.Count, "unmatched system transactions have no bank")
+
+	require.Len(t, summary.DayBreakdowns, 2)
+	june23, june24 := summary.DayBreakdowns[0], summary.DayBreakdowns[1]
+	assert.Equal(t, day(23), june23.Date)
+	assert.Equal(t, tally(1, "100"), june23.Credit.System)
+	assert.Equal(t, tally(1, "250"), june23.Debit.Matched)
+	assert.Equal(t, day(24), june24.Date, "days of unmatched system transactions are in their own time zone")
+	assert.Equal(t, tally(1, "75"), june24.Debit.UnmatchedSystem)
+	assert.Equal(t, tally(1, "15"), june24.Credit.UnmatchedBank)
+	assert.Equal(t, 0, june24.Debit.Bank.Count)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// DiffSummaries compares two reconciliations. System transactions are told
+// apart by ID and bank transactions by bank name and ID; results follow the
+// order of the new summary and are never nil, so that they encode as empty
+// JSON arrays.
+func DiffSummaries(previous, current *domain.ReconciliationSummary) *domain.SummaryDiff {
+	diff := &domain.SummaryDiff{
+		NewlyMatched:                     []domain.MatchedPair{},
+		NewlyUnmatchedSystem:             []domain.SystemTransaction{},
+		NewlyUnmatchedBank:               []domain.BankTransaction{},
+		ChangedPairings:                  []domain.PairingChange{},
+		DiscrepancyChanges:               []domain.DiscrepancyChange{},
+		TotalSystemTransactionsDelta:     current.TotalSystemTransactions - previous.TotalSystemTransactions,
+		TotalBankTransactionsDelta:       current.TotalBankTransactions - previous.TotalBankTransactions,
+		MatchedTransactionsDelta:         current.MatchedTransactions - previous.MatchedTransactions,
+		UnmatchedSystemTransactionsDelta: len(current.UnmatchedSystemTransactions) - len(previous.UnmatchedSystemTransactions),
+		UnmatchedBankTransactionsDelta:   current.UnmatchedBankCount() - previous.UnmatchedBankCount(),
+		AmountDiscrepancyDelta:           current.AmountDiscrepancyTotal.Sub(previous.AmountDiscrepancyTotal),
+	}
+
+	previousPairs := make(map[string]domain.MatchedPair, len(previous.MatchedPairs))
+	for _, pair := range previous.MatchedPairs {
+		previousPairs[pair.System.ID] = pair
+	}
+	for _, pair := range current.MatchedPairs {
+		before, matched := previousPairs[pair.System.ID]
+		switch {
+		case !matched:
+			diff.NewlyMatched = append(diff.NewlyMatched, pair)
+		case bankKey(before.Bank) != bankKey(pair.Bank):
+			diff.ChangedPairings = append(diff.ChangedPairings, domain.PairingChange{
+				System:         pair.System,
+				OldBank:        before.Bank,
+				NewBank:        pair.Bank,
+				OldDiscrepancy: before.Discrepancy,
+				NewDiscrepancy: pair.Discrepancy,
+			})
+		case !before.Discrepancy.Equal(pair.Discrepancy):
+			diff.DiscrepancyChanges = append(diff.DiscrepancyChanges, domain.DiscrepancyChange{Pair: pair, OldDiscrepancy: before.Discrepancy})
+		}
+	}
+
+	previousUnmatchedSystem := make(map[string]bool, len(previous.UnmatchedSystemTransactions))
+	for _, tx := range previous.UnmatchedSystemTransactions {
+		previousUnmatchedSystem[tx.ID] = true
+	}
+	for _, tx := range current.UnmatchedSystemTransactions {
+		if !previousUnmatchedSystem[tx.ID] {
+			diff.NewlyUnmatchedSystem = append(diff.NewlyUnmatchedSystem, tx)
+		}
+	}
+
+	previousUnmatchedBank := make(map[string]bool)
+	for _, txs := range previous.UnmatchedBankTransactions {
+		for _, tx := range txs {
+			previousUnmatchedBank[bankKey(tx)] = true
+		}
+	}
+	for _, bank := range current.BankNames {
+		for _, tx := range current.UnmatchedBankTransactions[bank] {
+			if !previousUnmatchedBank[bankKey(tx)] {
+				diff.NewlyUnmatchedBank = append(diff.NewlyUnmatchedBank, tx)
+			}
+		}
+	}
+	return diff
+}
+
+func bankKey(tx domain.BankTransaction) string {
+	return tx.BankName + "\x1f" + tx.ID
+}
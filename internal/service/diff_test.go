@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSummaries(t *testing.T) {
+	engine := NewReconciliationEngine()
+	systemTxs := []domain.SystemTransaction{
+		{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(1)},
+		{ID: "S2", Amount: newDecimalFromString("200"), Type: domain.Credit, TransactionTime: newDate(1)},
+		{ID: "S3", Amount: newDecimalFromString("50"), Type: domain.Debit, TransactionTime: newDate(2)},
+	}
+	bank := func(id, amount string, day int) domain.BankTransaction {
+		return domain.BankTransaction{ID: id, Amount: newDecimalFromString(amount), Date: newDate(day), BankName: "BCA"}
+	}
+
+	old, err := engine.Reconcile(context.Background(), systemTxs, []domain.BankTransaction{bank("B1", "100", 1), bank("B2", "-50", 2)}, MatchOptions{})
+	require.NoError(t, err)
+	corrected, err := engine.Reconcile(context.Background(), systemTxs, []domain.BankTransaction{
+		bank("B1", "100", 1), bank("B3", "199", 1), bank("B2", "-49", 2), bank("B4", "-10", 3),
+	}, MatchOptions{})
+	require.NoError(t, err)
+
+	t.Run("corrected statement", func(t *testing.T) {
+		diff := DiffSummaries(old, corrected)
+		require.Len(t, diff.NewlyMatched, 1)
+		assert.Equal(t, "S2", diff.NewlyMatched[0].System.ID)
+		assert.Equal(t, "B3", diff.NewlyMatched[0].Bank.ID)
+		require.Len(t, diff.NewlyUnmatchedBank, 1)
+		assert.Equal(t, "B4", diff.NewlyUnmatchedBank[0].ID)
+		assert.Empty(t, diff.NewlyUnmatchedSystem)
+		assert.Empty(t, diff.ChangedPairings)
+		require.Len(t, diff.DiscrepancyChanges, 1)
+		assert.Equal(t, "S3", diff.DiscrepancyChanges[0].Pair.System.ID)
+		assert.Equal(t, "0", diff.DiscrepancyChanges[0].OldDiscrepancy.String())
+		assert.Equal(t, "1", diff.DiscrepancyChanges[0].Pair.Discrepancy.String())
+
+		assert.Equal(t, 2, diff.TotalBankTransactionsDelta)
+		assert.Equal(t, 1, diff.MatchedTransactionsDelta)
+		assert.Equal(t, -1, diff.UnmatchedSystemTransactionsDelta)
+		assert.Equal(t, 1, diff.UnmatchedBankTransactionsDelta)
+		assert.Equal(t, "2", diff.AmountDiscrepancyDelta.String())
+		assert.False(t, diff.Empty())
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		diff := DiffSummaries(corrected, old)
+		assert.Empty(t, diff.NewlyMatched)
+		require.Len(t, diff.NewlyUnmatchedSystem, 1)
+		assert.Equal(t, "S2", diff.NewlyUnmatchedSystem[0].ID)
+		assert.Equal(t, "-2", diff.AmountDiscrepancyDelta.String())
+	})
+
+	t.Run("changed pairing", func(t *testing.T) {
+		repaired := *corrected
+		repaired.MatchedPairs = append([]domain.MatchedPair(nil), corrected.MatchedPairs...)
+		repaired.MatchedPairs[0].Bank = bank("B9", "100", 1)
+		diff := DiffSummaries(corrected, &repaired)
+		require.Len(t, diff.ChangedPairings, 1)
+		assert.Equal(t, "S1", diff.ChangedPairings[0].System.ID)
+		assert.Equal(t, "B1", diff.ChangedPairings[0].OldBank.ID)
+		assert.Equal(t, "B9", diff.ChangedPairings[0].NewBank.ID)
+	})
+
+	t.Run("identical", func(t *testing.T) {
+		assert.True(t, DiffSummaries(old, old).Empty())
+	})
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// DeduplicateBankTransactions leaves out bank input that an earlier statement,
+// in input order, already covers:
+//   - statements whose file content is identical to an earlier statement's,
+//     so that renamed copies are found too. fingerprints holds the content
+//     hash of each source, as collected by a domain.FingerprintLog; sources
+//     without one are not compared whole;
+//   - transactions that an earlier statement of the same account also lists,
+//     as with downloads covering overlapping days (see overlapFilter).
+//
+// Repeated transactions within one statement are kept, and a later statement
+// only loses as many copies of a transaction as the earlier one has.
+func DeduplicateBankTransactions(txs []domain.BankTransaction, fingerprints map[string]string) ([]domain.BankTransaction, []domain.DuplicateStatement, []domain.DuplicateBankTransaction) {
+	var sources []string
+	bySource := make(map[string][]domain.BankTransaction)
+	for _, tx := range txs {
+		if _, ok := bySource[tx.Source]; !ok {
+			sources = append(sources, tx.Source)
+		}
+		bySource[tx.Source] = append(bySource[tx.Source], tx)
+	}
+
+	var statements []domain.DuplicateStatement
+	skipped := make(map[string]bool)
+	firstWith := make(map[string]string)
+	for _, source := range sources {
+		fingerprint, ok := fingerprints[source]
+		if !ok {
+			continue
+		}
+		if original, ok := firstWith[fingerprint]; ok {
+			statements = append(statements, domain.DuplicateStatement{
+				Source:       source,
+				DuplicateOf:  original,
+				Fingerprint:  fingerprint,
+				Transactions: len(bySource[source]),
+			})
+			skipped[source] = true
+			continue
+		}
+		firstWith[fingerprint] = source
+	}
+
+	kept := make([]domain.BankTransaction, 0, len(txs))
+	var dropped []domain.DuplicateBankTransaction
+	overlaps := newOverlapFilter()
+	for _, tx := range txs {
+		if skipped[tx.Source] {
+			continue
+		}
+		if original, ok := overlaps.duplicateOf(tx); ok {
+			dropped = append(dropped, domain.DuplicateBankTransaction{Transaction: tx, DuplicateOf: original})
+			continue
+		}
+		kept = append(kept, tx)
+	}
+	return kept, statements, dropped
+}
+
+// DeduplicateBankStream drops transactions that an earlier statement of the
+// same account also lists from a stream in date order, such as the one of
+// MergeBankTransactions, and appends them to dropped. Only the current day is
+// remembered. Whole statements are only compared once the stream has ended,
+// see DuplicateStatementsOfStream.
+func DeduplicateBankStream(txs iter.Seq2[domain.BankTransaction, error], dropped *[]domain.DuplicateBankTransaction) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		overlaps := newOverlapFilter()
+		var day time.Time
+		for tx, err := range txs {
+			if err == nil {
+				if !tx.Date.Equal(day) {
+					overlaps = newOverlapFilter()
+					day = tx.Date
+				}
+				if original, ok := overlaps.duplicateOf(tx); ok {
+					*dropped = append(*dropped, domain.DuplicateBankTransaction{Transaction: tx, DuplicateOf: original})
+					continue
+				}
+			}
+			if !yield(tx, err) {
+				return
+			}
+		}
+	}
+}
+
+// SourceCounter counts the transactions of each source of a stream, in the
+// order the sources first appear.
+type SourceCounter struct {
+	Sources []string
+	Counts  map[string]int
+}
+
+func (c *SourceCounter) Count(txs iter.Seq2[domain.BankTransaction, error]) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		for tx, err := range txs {
+			if err == nil {
+				if c.Counts == nil {
+					c.Counts = make(map[string]int)
+				}
+				if _, ok := c.Counts[tx.Source]; !ok {
+					c.Sources = append(c.Sources, tx.Source)
+				}
+				c.Counts[tx.Source]++
+			}
+			if !yield(tx, err) {
+				return
+			}
+		}
+	}
+}
+
+// DuplicateStatementsOfStream finds the statements of a streamed run whose
+// content is identical to an earlier statement's, as
+// DeduplicateBankTransactions does. counters hold the sources of each stream,
+// in input order. A fingerprint is only known once its statement has been
+// read, so the transactions of a copy must already have been dropped as
+// overlapping by DeduplicateBankStream; they are taken out of dropped and
+// reported as the copy instead. A copy that was matched anyway, as when it is
+// listed under another account, is an error, since the result would differ
+// from a run reading the statements whole.
+func DuplicateStatementsOfStream(counters []SourceCounter, fingerprints map[string]string, dropped []domain.DuplicateBankTransaction) ([]domain.DuplicateStatement, []domain.DuplicateBankTransaction, error) {
+	var statements []domain.DuplicateStatement
+	copies := make(map[string]int)
+	firstWith := make(map[string]string)
+	for _, counter := range counters {
+		for _, source := range counter.Sources {
+			fingerprint, ok := fingerprints[source]
+			if !ok {
+				continue
+			}
+			if original, ok := firstWith[fingerprint]; ok {
+				statements = append(statements, domain.DuplicateStatement{
+					Source:       source,
+					DuplicateOf:  original,
+					Fingerprint:  fingerprint,
+					Transactions: counter.Counts[source],
+				})
+				copies[source] = 0
+				continue
+			}
+			firstWith[fingerprint] = source
+		}
+	}
+	if len(statements) == 0 {
+		return nil, dropped, nil
+	}
+
+	kept := make([]domain.DuplicateBankTransaction, 0, len(dropped))
+	for _, duplicate := range dropped {
+		if count, ok := copies[duplicate.Transaction.Source]; ok {
+			copies[duplicate.Transaction.Source] = count + 1
+			continue
+		}
+		kept = append(kept, duplicate)
+	}
+	for _, statement := range statements {
+		if copies[statement.Source] != statement.Transactions {
+			return nil, nil, fmt.Errorf("bank statement '%s' is a copy of '%s' under another account, which is only left out when reconciling without streaming", statement.Source, statement.DuplicateOf)
+		}
+	}
+	return statements, kept, nil
+}
+
+// bankTxKey identifies a transaction of an account. Statements are grouped
+// by account number when they have one. Without one, as when no sources
+// config is given, the bank name is only the file name, so such statements
+// are all compared with each other; transaction IDs are bank references, so
+// two accounts rarely share an ID, amount and date.
+type bankTxKey struct {
+	accountNumber    string
+	id, amount, date string
+}
+
+// overlap tracks one transaction key: the statement that listed it first and
+// how many copies each later statement has had dropped so far.
+type overlap struct {
+	source  string
+	count   int
+	dropped map[string]int
+}
+
+type overlapFilter struct {
+	seen map[bankTxKey]*overlap
+}
+
+func newOverlapFilter() *overlapFilter {
+	return &overlapFilter{seen: make(map[bankTxKey]*overlap)}
+}
+
+// duplicateOf returns the earlier statement listing tx, if tx is a copy.
+func (f *overlapFilter) duplicateOf(tx domain.BankTransaction) (string, bool) {
+	key := bankTxKey{
+		accountNumber: tx.AccountNumber,
+		id:            tx.ID,
+		amount:        tx.Amount.String(),
+		date:          tx.Date.Format(time.RFC3339),
+	}
+	entry, ok := f.seen[key]
+	if !ok {
+		f.seen[key] = &overlap{source: tx.Source, count: 1}
+		return "", false
+	}
+	if tx.Source == entry.source {
+		entry.count++
+		return "", false
+	}
+	if entry.dropped == nil {
+		entry.dropped = make(map[string]int)
+	}
+	if entry.dropped[tx.Source] >= entry.count {
+		return "", false
+	}
+	entry.dropped[tx.Source]++
+	return entry.source, true
+}
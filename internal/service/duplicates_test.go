@@ -0,0 +1,167 @@
+package service
+
+import (
+	"iter"
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statement(source, bankName string, rows ...domain.BankTransaction) []domain.BankTransaction {
+	txs := make([]domain.BankTransaction, len(rows))
+	for i, tx := range rows {
+		tx.Source, tx.BankName = source, bankName
+		txs[i] = tx
+	}
+	return txs
+}
+
+func TestDeduplicateBankTransactions(t *testing.T) {
+	b1 := domain.BankTransaction{ID: "B1", Amount: newDecimalFromString("100"), Date: newDate(1)}
+	b2 := domain.BankTransaction{ID: "B2", Amount: newDecimalFromString("-50"), Date: newDate(2)}
+	b3 := domain.BankTransaction{ID: "B3", Amount: newDecimalFromString("75"), Date: newDate(3)}
+
+	t.Run("identical statements", func(t *testing.T) {
+		june := statement("june.csv", "june.csv", b1, b2)
+		copied := statement("june (1).csv", "june (1).csv", b1, b2)
+		other := statement("bri.csv", "bri.csv", b3)
+		txs := append(append(append([]domain.BankTransaction{}, june...), copied...), other...)
+		fingerprints := map[string]string{"june.csv": "f1", "june (1).csv": "f1", "bri.csv": "f2"}
+
+		kept, statements, dropped := DeduplicateBankTransactions(txs, fingerprints)
+		assert.Equal(t, append(june, other...), kept)
+		assert.Equal(t, []domain.DuplicateStatement{{Source: "june (1).csv", DuplicateOf: "june.csv", Fingerprint: "f1", Transactions: 2}}, statements)
+		assert.Empty(t, dropped)
+	})
+
+	t.Run("statements with the same rows but different content", func(t *testing.T) {
+		june := statement("june.csv", "june.csv", b1, b2)
+		trimmed := statement("june-trimmed.csv", "june-trimmed.csv", b1, b2)
+		txs := append(append([]domain.BankTransaction{}, june...), trimmed...)
+
+		kept, statements, dropped := DeduplicateBankTransactions(txs, map[string]string{"june.csv": "f1", "june-trimmed.csv": "f2"})
+		assert.Equal(t, june, kept)
+		assert.Empty(t, statements, "only identical files are whole duplicates")
+		assert.Len(t, dropped, 2, "their rows still overlap")
+	})
+
+	t.Run("overlapping statements of one account", func(t *testing.T) {
+		first := statement("bca-1.csv", "BCA", b1, b2)
+		second := statement("bca-2.csv", "BCA-old", b2, b3)
+		otherBank := statement("bri.csv", "BRI", b2)
+		for i := range first {
+			first[i].AccountNumber, second[i].AccountNumber = "123", "123"
+		}
+		otherBank[0].AccountNumber = "456"
+		txs := append(append(append([]domain.BankTransaction{}, first...), second...), otherBank...)
+
+		kept, statements, dropped := DeduplicateBankTransactions(txs, nil)
+		assert.Equal(t, []domain.BankTransaction{first[0], first[1], second[1], otherBank[0]}, kept)
+		assert.Empty(t, statements)
+		assert.Equal(t, []domain.DuplicateBankTransaction{{Transaction: second[0], DuplicateOf: "bca-1.csv"}}, dropped)
+	})
+
+	t.Run("repeated rows within a statement are kept", func(t *testing.T) {
+		first := statement("bca-1.csv", "BCA", b1, b1)
+		second := statement("bca-2.csv", "BCA", b1, b1, b1, b3)
+		txs := append(append([]domain.BankTransaction{}, first...), second...)
+
+		kept, _, dropped := DeduplicateBankTransactions(txs, nil)
+		assert.Equal(t, []domain.BankTransaction{first[0], first[1], second[2], second[3]}, kept)
+		assert.Len(t, dropped, 2)
+	})
+
+	t.Run("account numbers tell accounts apart", func(t *testing.T) {
+		first := statement("a.csv", "BCA", b1, b3)
+		second := statement("b.csv", "BCA", b1)
+		second[0].AccountNumber = "987"
+
+		kept, statements, dropped := DeduplicateBankTransactions(append(first, second...), nil)
+		assert.Len(t, kept, 3)
+		assert.Empty(t, statements)
+		assert.Empty(t, dropped)
+	})
+
+	t.Run("downloads without account numbers overlap across file names", func(t *testing.T) {
+		first := statement("bca-0601.csv", "bca-0601.csv", b1, b2)
+		second := statement("bca-0602.csv", "bca-0602.csv", b2, b3)
+
+		kept, _, dropped := DeduplicateBankTransactions(append(first, second...), nil)
+		assert.Equal(t, []domain.BankTransaction{first[0], first[1], second[1]}, kept)
+		assert.Equal(t, []domain.DuplicateBankTransaction{{Transaction: second[0], DuplicateOf: "bca-0601.csv"}}, dropped)
+	})
+}
+
+func TestDeduplicateBankStream(t *testing.T) {
+	first := statement("bca-1.csv", "BCA",
+		domain.BankTransaction{ID: "B1", Amount: newDecimalFromString("100"), Date: newDate(1)},
+		domain.BankTransaction{ID: "B2", Amount: newDecimalFromString("200"), Date: newDate(2)})
+	second := statement("bca-2.csv", "BCA",
+		domain.BankTransaction{ID: "B2", Amount: newDecimalFromString("200"), Date: newDate(2)},
+		domain.BankTransaction{ID: "B3", Amount: newDecimalFromString("300"), Date: newDate(3)})
+
+	var dropped []domain.DuplicateBankTransaction
+	var ids []string
+	for tx, err := range DeduplicateBankStream(MergeBankTransactions(seqOf(first), seqOf(second)), &dropped) {
+		require.NoError(t, err)
+		ids = append(ids, tx.Source+":"+tx.ID)
+	}
+	assert.Equal(t, []string{"bca-1.csv:B1", "bca-1.csv:B2", "bca-2.csv:B3"}, ids)
+	assert.Equal(t, []domain.DuplicateBankTransaction{{Transaction: second[0], DuplicateOf: "bca-1.csv"}}, dropped)
+}
+
+func TestDuplicateStatementsOfStream(t *testing.T) {
+	b1 := domain.BankTransaction{ID: "B1", Amount: newDecimalFromString("100"), Date: newDate(1)}
+	b2 := domain.BankTransaction{ID: "B2", Amount: newDecimalFromString("-50"), Date: newDate(2)}
+	fingerprints := map[string]string{"june.csv": "f1", "june (1).csv": "f1", "bri.csv": "f2"}
+
+	// streamed runs the statements through the stream filters, one stream each.
+	streamed := func(statements ...[]domain.BankTransaction) ([]SourceCounter, []domain.DuplicateBankTransaction) {
+		counters := make([]SourceCounter, len(statements))
+		streams := make([]iter.Seq2[domain.BankTransaction, error], len(statements))
+		for i, txs := range statements {
+			streams[i] = counters[i].Count(seqOf(txs))
+		}
+		var dropped []domain.DuplicateBankTransaction
+		for _, err := range DeduplicateBankStream(MergeBankTransactions(streams...), &dropped) {
+			require.NoError(t, err)
+		}
+		return counters, dropped
+	}
+
+	t.Run("same result as DeduplicateBankTransactions", func(t *testing.T) {
+		june := statement("june.csv", "june.csv", b1, b2)
+		copied := statement("june (1).csv", "june (1).csv", b1, b2)
+		other := statement("bri.csv", "bri.csv", b1)
+		counters, dropped := streamed(june, copied, other)
+
+		statements, kept, err := DuplicateStatementsOfStream(counters, fingerprints, dropped)
+		require.NoError(t, err)
+		_, expectedStatements, expectedDropped := DeduplicateBankTransactions(append(append(append([]domain.BankTransaction{}, june...), copied...), other...), fingerprints)
+		assert.Equal(t, expectedStatements, statements)
+		assert.Equal(t, expectedDropped, kept)
+		assert.Equal(t, []domain.DuplicateBankTransaction{{Transaction: other[0], DuplicateOf: "june.csv"}}, kept)
+	})
+
+	t.Run("no copies", func(t *testing.T) {
+		counters, dropped := streamed(statement("june.csv", "june.csv", b1), statement("bri.csv", "bri.csv", b1))
+		statements, kept, err := DuplicateStatementsOfStream(counters, fingerprints, dropped)
+		require.NoError(t, err)
+		assert.Empty(t, statements)
+		assert.Equal(t, dropped, kept)
+	})
+
+	t.Run("copy under another account", func(t *testing.T) {
+		june := statement("june.csv", "BCA", b1, b2)
+		copied := statement("june (1).csv", "BCA", b1, b2)
+		for i := range copied {
+			june[i].AccountNumber, copied[i].AccountNumber = "123", "456"
+		}
+		counters, dropped := streamed(june, copied)
+		_, _, err := DuplicateStatementsOfStream(counters, fingerprints, dropped)
+		assert.EqualError(t, err, "bank statement 'june (1).csv' is a copy of 'june.csv' under another account, which is only left out when reconciling without streaming")
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"time"
 
 	"github.com/nmmugia/reconciliation-service/internal/domain"
@@ -8,49 +9,86 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// discrepancyThreshold is the maximum difference for a pair to match.
+var discrepancyThreshold = decimal.NewFromInt(1000)
+
 type ReconciliationEngine struct{}
 
+// MatchOptions are the choices of a single run of the engine.
+type MatchOptions struct {
+	// Observer receives an event for every match, suggested match and
+	// unmatched transaction. Suggestions are only looked for when it is set.
+	Observer domain.Observer
+	// KeepMatchedPairs makes ReconcileStream keep the matched pairs in its
+	// summary, for reports that list them. Reconcile always keeps them.
+	KeepMatchedPairs bool
+}
+
 func NewReconciliationEngine() *ReconciliationEngine {
 	return &ReconciliationEngine{}
 }
 
+// getMatchKey buckets by calendar day in the location of date, so bank dates
+// declared in a source timezone are compared with system times in that zone.
 func (e *ReconciliationEngine) getMatchKey(date time.Time, txType domain.TransactionType) string {
-	return date.Truncate(24*time.Hour).Format("2006-01-02") + ":" + string(txType)
+	return date.Location().String() + ":" + date.Format("2006-01-02") + ":" + string(txType)
 }
 
-func (e *ReconciliationEngine) Reconcile(systemTxs []domain.SystemTransaction, bankTxs []domain.BankTransaction) *domain.ReconciliationSummary {
-	discrepancyThreshold := decimal.NewFromInt(1000) // Maximum treshold for matching
-
-	systemTxMap := make(map[string][]*domain.SystemTransaction)
-	for i := range systemTxs {
-		tx := &systemTxs[i]
-		key := e.getMatchKey(tx.TransactionTime, tx.Type)
-		systemTxMap[key] = append(systemTxMap[key], tx)
+func (e *ReconciliationEngine) Reconcile(ctx context.Context, systemTxs []domain.SystemTransaction, bankTxs []domain.BankTransaction, options MatchOptions) (*domain.ReconciliationSummary, error) {
+	summary := &domain.ReconciliationSummary{
+		UnmatchedBankTransactions:   make(map[string][]domain.BankTransaction),
+		UnmatchedSystemTransactions: make([]domain.SystemTransaction, 0),
+		AmountDiscrepancyTotal:      decimal.Zero,
 	}
 
+	// Maps are only used for lookups; iteration follows input order so the
+	// same inputs always produce the same pairings and report order.
+	var locations []*time.Location
+	seenLocations := make(map[string]bool)
+	seenBanks := make(map[string]bool)
 	bankTxMap := make(map[string][]*domain.BankTransaction)
 	for i := range bankTxs {
 		tx := &bankTxs[i]
-		txType := domain.Credit
-		if tx.Amount.IsNegative() {
-			txType = domain.Debit
+		txType := bankTxType(*tx)
+		if location := tx.Date.Location(); !seenLocations[location.String()] {
+			seenLocations[location.String()] = true
+			locations = append(locations, location)
+		}
+		if !seenBanks[tx.BankName] {
+			seenBanks[tx.BankName] = true
+			summary.BankNames = append(summary.BankNames, tx.BankName)
 		}
 		key := e.getMatchKey(tx.Date, txType)
 		bankTxMap[key] = append(bankTxMap[key], tx)
 	}
 
-	summary := &domain.ReconciliationSummary{
-		UnmatchedBankTransactions:   make(map[string][]domain.BankTransaction),
-		UnmatchedSystemTransactions: make([]domain.SystemTransaction, 0),
-		AmountDiscrepancyTotal:      decimal.Zero,
+	var systemKeys []string
+	systemTxMap := make(map[string][]*domain.SystemTransaction)
+	for _, location := range locations {
+		for i := range systemTxs {
+			tx := &systemTxs[i]
+			key := e.getMatchKey(tx.TransactionTime.In(location), tx.Type)
+			if _, seen := systemTxMap[key]; !seen {
+				systemKeys = append(systemKeys, key)
+			}
+			systemTxMap[key] = append(systemTxMap[key], tx)
+		}
 	}
+
 	summary.TotalSystemTransactions = len(systemTxs)
 	summary.TotalBankTransactions = len(bankTxs)
 
 	processedSystemTx := make(map[string]bool)
 	processedBankTx := make(map[string]bool)
+	pairs := make(map[string]domain.MatchedPair)
+	observer := options.Observer
+	suggestions := make(map[string]domain.MatchedPair)
 
-	for key, systemTxs := range systemTxMap {
+	for _, key := range systemKeys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		systemTxs := systemTxMap[key]
 		bankTxs, found := bankTxMap[key]
 		if !found {
 			continue
@@ -59,43 +97,111 @@ func (e *ReconciliationEngine) Reconcile(systemTxs []domain.SystemTransaction, b
 		bankTxUsed := make([]bool, len(bankTxs))
 
 		for _, systemTx := range systemTxs {
-			bestFitIndex := -1
-			minDifference := discrepancyThreshold
-
-			for i, bankTx := range bankTxs {
-				if bankTxUsed[i] {
-					continue
-				}
-
-				currentDifference := systemTx.Amount.Sub(bankTx.Amount.Abs()).Abs()
-
-				if currentDifference.LessThan(minDifference) {
-					minDifference = currentDifference
-					bestFitIndex = i
-				}
+			if processedSystemTx[systemTx.ID] {
+				continue
 			}
-
+			bestFitIndex, minDifference := bestFit(systemTx, bankTxs, bankTxUsed)
 			if bestFitIndex != -1 {
 				summary.MatchedTransactions++
 				summary.AmountDiscrepancyTotal = summary.AmountDiscrepancyTotal.Add(minDifference)
 
+				pairs[systemTx.ID] = domain.MatchedPair{System: *systemTx, Bank: *bankTxs[bestFitIndex], Discrepancy: minDifference}
 				processedSystemTx[systemTx.ID] = true
 				processedBankTx[bankTxs[bestFitIndex].ID] = true
 				bankTxUsed[bestFitIndex] = true
 			}
 		}
+		if observer != nil {
+			suggest(systemTxs, bankTxs, bankTxUsed, processedSystemTx, suggestions)
+		}
 	}
 
 	for _, tx := range systemTxs {
-		if !processedSystemTx[tx.ID] {
+		if pair, matched := pairs[tx.ID]; matched {
+			summary.MatchedPairs = append(summary.MatchedPairs, pair)
+			domain.Notify(ctx, observer, domain.Event{Type: domain.EventMatched, Pair: &pair})
+			delete(pairs, tx.ID)
+		} else if !processedSystemTx[tx.ID] {
 			summary.UnmatchedSystemTransactions = append(summary.UnmatchedSystemTransactions, tx)
+			notifyUnmatchedSystem(ctx, observer, tx, suggestions)
 		}
 	}
 	for _, tx := range bankTxs {
 		if !processedBankTx[tx.ID] {
 			summary.UnmatchedBankTransactions[tx.BankName] = append(summary.UnmatchedBankTransactions[tx.BankName], tx)
+			domain.Notify(ctx, observer, domain.Event{Type: domain.EventUnmatchedBank, BankTransaction: &tx})
 		}
 	}
+	summary.BankBreakdowns, summary.DayBreakdowns = Breakdowns(summary)
 
-	return summary
+	return summary, nil
+}
+
+// bankTxType derives the direction of a bank transaction from its sign.
+func bankTxType(tx domain.BankTransaction) domain.TransactionType {
+	if tx.Amount.IsNegative() {
+		return domain.Debit
+	}
+	return domain.Credit
+}
+
+// bestFit returns the unused bank transaction closest in amount to systemTx,
+// or -1 when none is within discrepancyThreshold.
+func bestFit(systemTx *domain.SystemTransaction, bankTxs []*domain.BankTransaction, bankTxUsed []bool) (int, decimal.Decimal) {
+	bestFitIndex := -1
+	minDifference := discrepancyThreshold
+
+	for i, bankTx := range bankTxs {
+		if bankTxUsed[i] {
+			continue
+		}
+
+		currentDifference := systemTx.Amount.Sub(bankTx.Amount.Abs()).Abs()
+
+		if currentDifference.LessThan(minDifference) {
+			minDifference = currentDifference
+			bestFitIndex = i
+		}
+	}
+	return bestFitIndex, minDifference
+}
+
+// suggest records, for the system transactions of a bucket that stayed
+// unmatched, the closest bank transaction that is still unused. A system
+// transaction bucketed in several timezones keeps its closest candidate.
+func suggest(systemTxs []*domain.SystemTransaction, bankTxs []*domain.BankTransaction, bankTxUsed []bool, matched map[string]bool, suggestions map[string]domain.MatchedPair) {
+	for _, systemTx := range systemTxs {
+		if matched[systemTx.ID] {
+			continue
+		}
+		index, difference := nearest(systemTx, bankTxs, bankTxUsed)
+		if index == -1 {
+			continue
+		}
+		if previous, ok := suggestions[systemTx.ID]; !ok || difference.LessThan(previous.Discrepancy) {
+			suggestions[systemTx.ID] = domain.MatchedPair{System: *systemTx, Bank: *bankTxs[index], Discrepancy: difference}
+		}
+	}
+}
+
+// nearest is bestFit without the threshold.
+func nearest(systemTx *domain.SystemTransaction, bankTxs []*domain.BankTransaction, bankTxUsed []bool) (int, decimal.Decimal) {
+	index, minDifference := -1, decimal.Zero
+	for i, bankTx := range bankTxs {
+		if bankTxUsed[i] {
+			continue
+		}
+		difference := systemTx.Amount.Sub(bankTx.Amount.Abs()).Abs()
+		if index == -1 || difference.LessThan(minDifference) {
+			index, minDifference = i, difference
+		}
+	}
+	return index, minDifference
+}
+
+func notifyUnmatchedSystem(ctx context.Context, observer domain.Observer, tx domain.SystemTransaction, suggestions map[string]domain.MatchedPair) {
+	if suggestion, ok := suggestions[tx.ID]; ok {
+		domain.Notify(ctx, observer, domain.Event{Type: domain.EventSuggestedMatch, Pair: &suggestion})
+	}
+	domain.Notify(ctx, observer, domain.Event{Type: domain.EventUnmatchedSystem, SystemTransaction: &tx})
 }
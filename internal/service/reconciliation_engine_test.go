@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newDecimalFromString(val string) decimal.Decimal {
@@ -135,7 +137,8 @@ func TestReconciliationEngine_Reconcile_BestFit(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			summary := engine.Reconcile(tc.systemTxs, tc.bankTxs)
+			summary, err := engine.Reconcile(context.Background(), tc.systemTxs, tc.bankTxs, MatchOptions{})
+			assert.NoError(t, err)
 
 			assert.Equal(t, tc.expectedMatchedCount, summary.MatchedTransactions, "Mismatched count of matched transactions")
 			assert.Equal(t, tc.expectedUnmatchedSystemCount, len(summary.UnmatchedSystemTransactions), "Mismatched count of unmatched system transactions")
@@ -150,3 +153,129 @@ func TestReconciliationEngine_Reconcile_BestFit(t *testing.T) {
 		})
 	}
 }
+
+func TestReconciliationEngine_Reconcile_SourceTimezone(t *testing.T) {
+	engine := NewReconciliationEngine()
+	jakarta := time.FixedZone("Asia/Jakarta", 7*60*60)
+
+	systemTxs := []domain.SystemTransaction{
+		// 01:30 in Jakarta on the 2nd, still the 1st in UTC.
+		{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: time.Date(2023, 1, 1, 18, 30, 0, 0, time.UTC)},
+		{ID: "S2", Amount: newDecimalFromString("200"), Type: domain.Credit, TransactionTime: time.Date(2023, 1, 1, 18, 30, 0, 0, time.UTC)},
+	}
+	bankTxs := []domain.BankTransaction{
+		{ID: "B-JKT", Amount: newDecimalFromString("100"), Date: time.Date(2023, 1, 2, 0, 0, 0, 0, jakarta), BankName: "BCA"},
+		{ID: "B-UTC", Amount: newDecimalFromString("200"), Date: newDate(1), BankName: "HSBC"},
+	}
+
+	summary, err := engine.Reconcile(context.Background(), systemTxs, bankTxs, MatchOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, summary.MatchedTransactions)
+	assert.Empty(t, summary.UnmatchedSystemTransactions)
+	assert.Empty(t, summary.UnmatchedBankTransactions)
+	require.Len(t, summary.MatchedPairs, 2)
+	for i, pair := range summary.MatchedPairs {
+		assert.Equal(t, systemTxs[i], pair.System)
+		assert.Equal(t, bankTxs[i], pair.Bank)
+		assert.True(t, pair.Discrepancy.IsZero())
+	}
+}
+
+func TestReconciliationEngine_Reconcile_DeterministicOrder(t *testing.T) {
+	engine := NewReconciliationEngine()
+	jakarta := time.FixedZone("Asia/Jakarta", 7*60*60)
+
+	systemTxs := []domain.SystemTransaction{
+		{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: time.Date(2023, 1, 1, 18, 30, 0, 0, time.UTC)},
+		{ID: "S2", Amount: newDecimalFromString("999"), Type: domain.Debit, TransactionTime: newDate(3)},
+	}
+	bankTxs := []domain.BankTransaction{
+		{ID: "B-ZULU", Amount: newDecimalFromString("-5"), Date: newDate(4), BankName: "Zulu"},
+		// S1 falls on the 1st in UTC and the 2nd in Jakarta; both candidates fit.
+		{ID: "B-UTC", Amount: newDecimalFromString("101"), Date: newDate(1), BankName: "Alpha"},
+		{ID: "B-JKT", Amount: newDecimalFromString("100"), Date: time.Date(2023, 1, 2, 0, 0, 0, 0, jakarta), BankName: "Mike"},
+		{ID: "B-ALPHA-2", Amount: newDecimalFromString("-7"), Date: newDate(5), BankName: "Alpha"},
+	}
+
+	first, err := engine.Reconcile(context.Background(), systemTxs, bankTxs, MatchOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Zulu", "Alpha", "Mike"}, first.BankNames)
+	for run := 0; run < 20; run++ {
+		summary, err := engine.Reconcile(context.Background(), systemTxs, bankTxs, MatchOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, first, summary)
+	}
+	assert.Equal(t, []domain.BankTransaction{bankTxs[2]}, first.UnmatchedBankTransactions["Mike"])
+}
+
+func TestReconciliationEngine_Reconcile_Cancelled(t *testing.T) {
+	engine := NewReconciliationEngine()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := engine.Reconcile(ctx,
+		[]domain.SystemTransaction{{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(1)}},
+		[]domain.BankTransaction{{ID: "B1", Amount: newDecimalFromString("100"), Date: newDate(1)}},
+		MatchOptions{},
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+type eventRecorder struct {
+	events []domain.Event
+}
+
+func (r *eventRecorder) OnEvent(_ context.Context, event domain.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) describe() []string {
+	var described []string
+	for _, event := range r.events {
+		switch {
+		case event.Pair != nil:
+			described = append(described, string(event.Type)+" "+event.Pair.System.ID+"/"+event.Pair.Bank.ID+" "+event.Pair.Discrepancy.String())
+		case event.SystemTransaction != nil:
+			described = append(described, string(event.Type)+" "+event.SystemTransaction.ID)
+		case event.BankTransaction != nil:
+			described = append(described, string(event.Type)+" "+event.BankTransaction.ID)
+		}
+	}
+	return described
+}
+
+func TestReconciliationEngine_Events(t *testing.T) {
+	engine := NewReconciliationEngine()
+	systemTxs := []domain.SystemTransaction{
+		{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(1)},
+		{ID: "S2", Amount: newDecimalFromString("5000"), Type: domain.Credit, TransactionTime: newDate(1)},
+		{ID: "S3", Amount: newDecimalFromString("70"), Type: domain.Debit, TransactionTime: newDate(2)},
+	}
+	bankTxs := []domain.BankTransaction{
+		{ID: "B1", Amount: newDecimalFromString("100.50"), Date: newDate(1), BankName: "BCA"},
+		{ID: "B2", Amount: newDecimalFromString("7000"), Date: newDate(1), BankName: "BCA"},
+		{ID: "B3", Amount: newDecimalFromString("-15"), Date: newDate(3), BankName: "BCA"},
+	}
+	expected := []string{
+		"matched S1/B1 0.5",
+		"suggested_match S2/B2 2000",
+		"unmatched_system S2",
+		"unmatched_system S3",
+		"unmatched_bank B2",
+		"unmatched_bank B3",
+	}
+
+	recorder := &eventRecorder{}
+	_, err := engine.Reconcile(context.Background(), systemTxs, bankTxs, MatchOptions{Observer: recorder})
+	require.NoError(t, err)
+	assert.Equal(t, expected, recorder.describe())
+	for _, event := range recorder.events {
+		assert.False(t, event.Time.IsZero())
+	}
+
+	streamed := &eventRecorder{}
+	_, err = engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{Observer: streamed})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, streamed.describe())
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+// streamBucket collects the transactions of one match key until neither input
+// can add to it anymore.
+type streamBucket struct {
+	key        string
+	start, end time.Time
+	system     []*streamSystemTx
+	bank       []*domain.BankTransaction
+	bankSeq    []int
+}
+
+type streamSystemTx struct {
+	tx          domain.SystemTransaction
+	seq         int
+	openBuckets int
+	matched     bool
+	suggestion  *domain.MatchedPair
+}
+
+type streamPair struct {
+	pair domain.MatchedPair
+	seq  int
+}
+
+type streamBankTx struct {
+	tx  domain.BankTransaction
+	seq int
+}
+
+type streamState struct {
+	ctx       context.Context
+	observer  domain.Observer
+	keepPairs bool
+	engine    *ReconciliationEngine
+	summary   *domain.ReconciliationSummary
+
+	location    *time.Location
+	closedUntil time.Time
+	lastBank    time.Time
+	buckets     map[string]*streamBucket
+	open        []*streamBucket
+	seenBanks   map[string]bool
+
+	systemCount, bankCount int
+	unmatchedSystem        []*streamSystemTx
+	unmatchedBank          []streamBankTx
+	matchedPairs           []streamPair
+	breakdowns             *breakdownTally
+}
+
+// ReconcileStream matches transactions while they are being read. Both inputs
+// must be in time order (bank statements by date, system transactions by
+// transaction time, where order within a day does not matter), so that a
+// bucket can be matched and released as soon as both inputs have moved past
+// its day. Memory is then bounded by the buckets of the days in flight plus the
+// unmatched transactions. Matched pairs are only sent as events and counted,
+// unless options.KeepMatchedPairs is set. Bank dates going back, system
+// transactions for a day that was already matched, and bank dates in more than
+// one timezone are reported as errors: Reconcile matches the days of one
+// timezone after the other, which a stream cannot. Otherwise the result equals
+// Reconcile on the same transactions; unmatched transactions are listed in
+// input order. Events are sent as soon as a bucket is matched.
+func (e *ReconciliationEngine) ReconcileStream(ctx context.Context, systemTxs iter.Seq2[domain.SystemTransaction, error], bankTxs iter.Seq2[domain.BankTransaction, error], options MatchOptions) (*domain.ReconciliationSummary, error) {
+	nextSystem, stopSystem := iter.Pull2(systemTxs)
+	defer stopSystem()
+	nextBank, stopBank := iter.Pull2(bankTxs)
+	defer stopBank()
+
+	s := &streamState{
+		ctx:       ctx,
+		observer:  options.Observer,
+		keepPairs: options.KeepMatchedPairs,
+		engine:    e,
+		summary: &domain.ReconciliationSummary{
+			UnmatchedBankTransactions:   make(map[string][]domain.BankTransaction),
+			UnmatchedSystemTransactions: make([]domain.SystemTransaction, 0),
+			AmountDiscrepancyTotal:      decimal.Zero,
+		},
+		buckets:    make(map[string]*streamBucket),
+		seenBanks:  make(map[string]bool),
+		breakdowns: newBreakdownTally(),
+	}
+
+	systemTx, systemErr, systemOK := nextSystem()
+	bankTx, bankErr, bankOK := nextBank()
+	for systemOK || bankOK {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if systemOK && systemErr != nil {
+			return nil, systemErr
+		}
+		if bankOK && bankErr != nil {
+			return nil, bankErr
+		}
+
+		// Bank dates are the start of their day, so on a tie the bank side goes
+		// first: a system transaction is only taken once the bank day that
+		// could match it has been seen.
+		if bankOK && (!systemOK || !systemTx.TransactionTime.Before(bankTx.Date)) {
+			if err := s.addBank(bankTx); err != nil {
+				return nil, err
+			}
+			bankTx, bankErr, bankOK = nextBank()
+		} else {
+			if err := s.addSystem(systemTx); err != nil {
+				return nil, err
+			}
+			systemTx, systemErr, systemOK = nextSystem()
+		}
+
+		systemMark, bankMark := endOfStream, endOfStream
+		if systemOK {
+			systemMark = systemTx.TransactionTime
+		}
+		if bankOK {
+			bankMark = bankTx.Date
+		}
+		s.closeBuckets(systemMark, bankMark)
+	}
+	s.closeBuckets(endOfStream, endOfStream)
+
+	return s.finish(), nil
+}
+
+// endOfStream is later than any transaction time.
+var endOfStream = time.Unix(1<<62, 0)
+
+func (s *streamState) addSystem(tx domain.SystemTransaction) error {
+	entry := &streamSystemTx{tx: tx, seq: s.systemCount}
+	s.systemCount++
+
+	if s.location != nil {
+		if tx.TransactionTime.Before(s.closedUntil) {
+			return fmt.Errorf("system transactions are not in time order: '%s' at %s arrives after its day was reconciled", tx.ID, tx.TransactionTime.Format(time.RFC3339))
+		}
+		bucket := s.bucket(tx.TransactionTime.In(s.location), tx.Type)
+		bucket.system = append(bucket.system, entry)
+		entry.openBuckets++
+	}
+	if entry.openBuckets == 0 {
+		s.unmatchedSystem = append(s.unmatchedSystem, entry)
+		s.notifyUnmatched(entry)
+	}
+	return nil
+}
+
+func (s *streamState) addBank(tx domain.BankTransaction) error {
+	seq := s.bankCount
+	s.bankCount++
+
+	// Bank dates must not go back at all: system transactions taken before a
+	// bank day starts are never added to its bucket.
+	if tx.Date.Before(s.lastBank) {
+		return fmt.Errorf("bank transactions are not in date order: '%s' on %s comes after %s", tx.ID, tx.Date.Format("2006-01-02"), s.lastBank.Format("2006-01-02"))
+	}
+	s.lastBank = tx.Date
+
+	if s.location == nil {
+		s.location = tx.Date.Location()
+	} else if zone := tx.Date.Location().String(); zone != s.location.String() {
+		return fmt.Errorf("bank transactions are in more than one timezone: '%s' is in %s, earlier ones in %s; reconcile without streaming to match several timezones", tx.ID, zone, s.location)
+	}
+	if !s.seenBanks[tx.BankName] {
+		s.seenBanks[tx.BankName] = true
+		s.summary.BankNames = append(s.summary.BankNames, tx.BankName)
+	}
+
+	bucket := s.bucket(tx.Date, bankTxType(tx))
+	bucket.bank = append(bucket.bank, &tx)
+	bucket.bankSeq = append(bucket.bankSeq, seq)
+	return nil
+}
+
+func (s *streamState) bucket(date time.Time, txType domain.TransactionType) *streamBucket {
+	key := s.engine.getMatchKey(date, txType)
+	bucket, ok := s.buckets[key]
+	if !ok {
+		year, month, day := date.Date()
+		bucket = &streamBucket{
+			key:   key,
+			start: time.Date(year, month, day, 0, 0, 0, 0, date.Location()),
+			end:   time.Date(year, month, day+1, 0, 0, 0, 0, date.Location()),
+		}
+		s.buckets[key] = bucket
+		s.open = append(s.open, bucket)
+	}
+	return bucket
+}
+
+// closeBuckets matches every bucket that neither input can reach anymore:
+// the next system transaction is past the bucket's day and the next bank date
+// is after its start.
+func (s *streamState) closeBuckets(systemMark, bankMark time.Time) {
+	open := s.open[:0]
+	for _, bucket := range s.open {
+		if systemMark.Before(bucket.end) || !bankMark.After(bucket.start) {
+			open = append(open, bucket)
+			continue
+		}
+		s.match(bucket)
+		delete(s.buckets, bucket.key)
+		if bucket.end.After(s.closedUntil) {
+			s.closedUntil = bucket.end
+		}
+	}
+	clear(s.open[len(open):])
+	s.open = open
+}
+
+func (s *streamState) match(bucket *streamBucket) {
+	bankTxUsed := make([]bool, len(bucket.bank))
+	for _, entry := range bucket.system {
+		if entry.matched {
+			continue
+		}
+		if bestFitIndex, minDifference := bestFit(&entry.tx, bucket.bank, bankTxUsed); bestFitIndex != -1 {
+			s.summary.MatchedTransactions++
+			s.summary.AmountDiscrepancyTotal = s.summary.AmountDiscrepancyTotal.Add(minDifference)
+			entry.matched = true
+			bankTxUsed[bestFitIndex] = true
+			pair := domain.MatchedPair{System: entry.tx, Bank: *bucket.bank[bestFitIndex], Discrepancy: minDifference}
+			s.breakdowns.addPair(pair)
+			if s.keepPairs {
+				s.matchedPairs = append(s.matchedPairs, streamPair{pair: pair, seq: entry.seq})
+			}
+			domain.Notify(s.ctx, s.observer, domain.Event{Type: domain.EventMatched, Pair: &pair})
+		}
+	}
+
+	for _, entry := range bucket.system {
+		if s.observer != nil && !entry.matched {
+			index, difference := nearest(&entry.tx, bucket.bank, bankTxUsed)
+			if index != -1 && (entry.suggestion == nil || difference.LessThan(entry.suggestion.Discrepancy)) {
+				entry.suggestion = &domain.MatchedPair{System: entry.tx, Bank: *bucket.bank[index], Discrepancy: difference}
+			}
+		}
+		entry.openBuckets--
+		if entry.openBuckets == 0 && !entry.matched {
+			s.unmatchedSystem = append(s.unmatchedSystem, entry)
+			s.notifyUnmatched(entry)
+		}
+	}
+	for i, tx := range bucket.bank {
+		if !bankTxUsed[i] {
+			s.unmatchedBank = append(s.unmatchedBank, streamBankTx{tx: *tx, seq: bucket.bankSeq[i]})
+			domain.Notify(s.ctx, s.observer, domain.Event{Type: domain.EventUnmatchedBank, BankTransaction: tx})
+		}
+	}
+}
+
+func (s *streamState) notifyUnmatched(entry *streamSystemTx) {
+	if entry.suggestion != nil {
+		domain.Notify(s.ctx, s.observer, domain.Event{Type: domain.EventSuggestedMatch, Pair: entry.suggestion})
+	}
+	domain.Notify(s.ctx, s.observer, domain.Event{Type: domain.EventUnmatchedSystem, SystemTransaction: &entry.tx})
+}
+
+func (s *streamState) finish() *domain.ReconciliationSummary {
+	sort.Slice(s.unmatchedSystem, func(i, j int) bool { return s.unmatchedSystem[i].seq < s.unmatchedSystem[j].seq })
+	sort.Slice(s.unmatchedBank, func(i, j int) bool { return s.unmatchedBank[i].seq < s.unmatchedBank[j].seq })
+	sort.Slice(s.matchedPairs, func(i, j int) bool { return s.matchedPairs[i].seq < s.matchedPairs[j].seq })
+
+	summary := s.summary
+	summary.TotalSystemTransactions = s.systemCount
+	summary.TotalBankTransactions = s.bankCount
+	for _, entry := range s.matchedPairs {
+		summary.MatchedPairs = append(summary.MatchedPairs, entry.pair)
+	}
+	for _, entry := range s.unmatchedSystem {
+		summary.UnmatchedSystemTransactions = append(summary.UnmatchedSystemTransactions, entry.tx)
+	}
+	for _, entry := range s.unmatchedBank {
+		summary.UnmatchedBankTransactions[entry.tx.BankName] = append(summary.UnmatchedBankTransactions[entry.tx.BankName], entry.tx)
+	}
+	s.breakdowns.addUnmatched(summary)
+	summary.BankBreakdowns, summary.DayBreakdowns = s.breakdowns.result(summary.BankNames)
+	return summary
+}
+
+// MergeBankTransactions interleaves bank statement streams by date, for
+// ReconcileStream when every statement is in date order on its own. On equal
+// dates earlier streams go first. Errors are passed on as they occur.
+func MergeBankTransactions(streams ...iter.Seq2[domain.BankTransaction, error]) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		type head struct {
+			next func() (domain.BankTransaction, error, bool)
+			tx   domain.BankTransaction
+			ok   bool
+		}
+		// advance loads the next transaction of h; it returns false once the
+		// consumer stops.
+		advance := func(h *head) bool {
+			for {
+				tx, err, ok := h.next()
+				if !ok || err == nil {
+					h.tx, h.ok = tx, ok
+					return true
+				}
+				if !yield(domain.BankTransaction{}, err) {
+					return false
+				}
+			}
+		}
+
+		heads := make([]*head, len(streams))
+		for i, stream := range streams {
+			next, stop := iter.Pull2(stream)
+			defer stop()
+			heads[i] = &head{next: next}
+			if !advance(heads[i]) {
+				return
+			}
+		}
+
+		for {
+			var earliest *head
+			for _, h := range heads {
+				if h.ok && (earliest == nil || h.tx.Date.Before(earliest.tx.Date)) {
+					earliest = h
+				}
+			}
+			if earliest == nil {
+				return
+			}
+			if !yield(earliest.tx, nil) || !advance(earliest) {
+				return
+			}
+		}
+	}
+}
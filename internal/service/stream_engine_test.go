@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seqOf[T any](items []T, errs ...error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+		var zero T
+		for _, err := range errs {
+			if !yield(zero, err) {
+				return
+			}
+		}
+	}
+}
+
+// sortedTransactions generates a month of transactions in time order where
+// most system transactions have a bank counterpart with a small discrepancy.
+func sortedTransactions(seed int64) ([]domain.SystemTransaction, []domain.BankTransaction) {
+	rng := rand.New(rand.NewSource(seed))
+	var systemTxs []domain.SystemTransaction
+	var bankTxs []domain.BankTransaction
+	for day := 1; day <= 31; day++ {
+		for i := 0; i < 20; i++ {
+			amount := decimal.NewFromInt(int64(rng.Intn(5000) + 1))
+			txType := domain.Credit
+			if rng.Intn(2) == 0 {
+				txType = domain.Debit
+			}
+			id := strconv.Itoa(day*100 + i)
+			systemTxs = append(systemTxs, domain.SystemTransaction{
+				ID: "S" + id, Amount: amount, Type: txType,
+				TransactionTime: newDate(day).Add(time.Duration(i) * time.Hour),
+			})
+			if rng.Intn(5) == 0 {
+				continue
+			}
+			bankAmount := amount.Add(decimal.NewFromInt(int64(rng.Intn(1500))))
+			if txType == domain.Debit {
+				bankAmount = bankAmount.Neg()
+			}
+			bankTxs = append(bankTxs, domain.BankTransaction{
+				ID: "B" + id, Amount: bankAmount, Date: newDate(day), BankName: []string{"BCA", "BRI"}[rng.Intn(2)],
+			})
+		}
+	}
+	return systemTxs, bankTxs
+}
+
+func TestReconciliationEngine_ReconcileStream(t *testing.T) {
+	engine := NewReconciliationEngine()
+
+	t.Run("same result as Reconcile for time ordered input", func(t *testing.T) {
+		for seed := int64(1); seed <= 5; seed++ {
+			systemTxs, bankTxs := sortedTransactions(seed)
+			expected, err := engine.Reconcile(context.Background(), systemTxs, bankTxs, MatchOptions{})
+			require.NoError(t, err)
+
+			summary, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{KeepMatchedPairs: true})
+			require.NoError(t, err)
+			assert.Equal(t, expected, summary, "seed %d", seed)
+		}
+	})
+
+	t.Run("matched pairs only on request", func(t *testing.T) {
+		systemTxs, bankTxs := sortedTransactions(2)
+		expected, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{KeepMatchedPairs: true})
+		require.NoError(t, err)
+		require.NotEmpty(t, expected.MatchedPairs)
+
+		summary, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, summary.MatchedPairs)
+		assert.Equal(t, expected.MatchedTransactions, summary.MatchedTransactions)
+		assert.Equal(t, expected.BankBreakdowns, summary.BankBreakdowns)
+		assert.Equal(t, expected.DayBreakdowns, summary.DayBreakdowns)
+	})
+
+	t.Run("source timezone", func(t *testing.T) {
+		jakarta := time.FixedZone("Asia/Jakarta", 7*60*60)
+		systemTxs := []domain.SystemTransaction{
+			// 01:30 in Jakarta on the 2nd, still the 1st in UTC.
+			{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: time.Date(2023, 1, 1, 18, 30, 0, 0, time.UTC)},
+		}
+		bankTxs := []domain.BankTransaction{
+			{ID: "B-JKT", Amount: newDecimalFromString("100"), Date: time.Date(2023, 1, 2, 0, 0, 0, 0, jakarta), BankName: "BCA"},
+		}
+
+		summary, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.MatchedTransactions)
+		assert.Empty(t, summary.UnmatchedSystemTransactions)
+
+		expected, err := engine.Reconcile(context.Background(), systemTxs, bankTxs, MatchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, expected.MatchedTransactions, summary.MatchedTransactions)
+	})
+
+	t.Run("bank dates in more than one timezone", func(t *testing.T) {
+		jakarta := time.FixedZone("Asia/Jakarta", 7*60*60)
+		systemTxs := []domain.SystemTransaction{
+			{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: time.Date(2023, 1, 1, 18, 30, 0, 0, time.UTC)},
+		}
+		bankTxs := []domain.BankTransaction{
+			{ID: "B-JKT", Amount: newDecimalFromString("100"), Date: time.Date(2023, 1, 2, 0, 0, 0, 0, jakarta), BankName: "BCA"},
+			{ID: "B-UTC", Amount: newDecimalFromString("-100"), Date: newDate(2), BankName: "HSBC"},
+		}
+
+		_, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{})
+		assert.ErrorContains(t, err, "bank transactions are in more than one timezone: 'B-UTC' is in UTC, earlier ones in Asia/Jakarta")
+	})
+
+	t.Run("system transactions before the first bank date", func(t *testing.T) {
+		systemTxs := []domain.SystemTransaction{
+			{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(1)},
+			{ID: "S2", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(5)},
+		}
+		bankTxs := []domain.BankTransaction{{ID: "B1", Amount: newDecimalFromString("100"), Date: newDate(5)}}
+
+		summary, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.MatchedTransactions)
+		assert.Equal(t, []domain.SystemTransaction{systemTxs[0]}, summary.UnmatchedSystemTransactions)
+		assert.Equal(t, 2, summary.TotalSystemTransactions)
+		assert.Equal(t, 1, summary.TotalBankTransactions)
+	})
+
+	t.Run("input going back to a reconciled day", func(t *testing.T) {
+		systemTxs := []domain.SystemTransaction{
+			{ID: "S1", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(1)},
+			{ID: "S2", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(3)},
+		}
+		bankTxs := []domain.BankTransaction{
+			{ID: "B3", Amount: newDecimalFromString("100"), Date: newDate(3)},
+			{ID: "B1", Amount: newDecimalFromString("100"), Date: newDate(1)},
+		}
+		_, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{})
+		assert.ErrorContains(t, err, "bank transactions are not in date order: 'B1'")
+
+		_, err = engine.ReconcileStream(context.Background(), seqOf([]domain.SystemTransaction{systemTxs[1], systemTxs[0]}), seqOf([]domain.BankTransaction{bankTxs[1], bankTxs[0]}), MatchOptions{})
+		assert.ErrorContains(t, err, "system transactions are not in time order: 'S1'")
+	})
+
+	t.Run("order within a day does not matter", func(t *testing.T) {
+		systemTxs := []domain.SystemTransaction{
+			{ID: "S2", Amount: newDecimalFromString("100"), Type: domain.Credit, TransactionTime: newDate(1).Add(20 * time.Hour)},
+			{ID: "S1", Amount: newDecimalFromString("200"), Type: domain.Credit, TransactionTime: newDate(1).Add(2 * time.Hour)},
+		}
+		bankTxs := []domain.BankTransaction{
+			{ID: "B1", Amount: newDecimalFromString("200"), Date: newDate(1)},
+			{ID: "B2", Amount: newDecimalFromString("100"), Date: newDate(1)},
+		}
+		summary, err := engine.ReconcileStream(context.Background(), seqOf(systemTxs), seqOf(bankTxs), MatchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, summary.MatchedTransactions)
+		assert.True(t, summary.AmountDiscrepancyTotal.IsZero())
+	})
+
+	t.Run("reader errors stop the run", func(t *testing.T) {
+		bankErr := errors.New("broken statement")
+		_, err := engine.ReconcileStream(context.Background(), seqOf([]domain.SystemTransaction{}), seqOf([]domain.BankTransaction{}, bankErr), MatchOptions{})
+		assert.ErrorIs(t, err, bankErr)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		systemTxs, bankTxs := sortedTransactions(1)
+		_, err := engine.ReconcileStream(ctx, seqOf(systemTxs), seqOf(bankTxs), MatchOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestReconciliationEngine_ReconcileStream_ReleasesBuckets(t *testing.T) {
+	engine := NewReconciliationEngine()
+	systemTxs, bankTxs := sortedTransactions(7)
+
+	// Drive the state the way ReconcileStream does and watch the open buckets.
+	state := &streamState{
+		ctx:        context.Background(),
+		engine:     engine,
+		summary:    &domain.ReconciliationSummary{UnmatchedBankTransactions: map[string][]domain.BankTransaction{}},
+		buckets:    map[string]*streamBucket{},
+		seenBanks:  map[string]bool{},
+		breakdowns: newBreakdownTally(),
+	}
+	maxOpen, b := 0, 0
+	for i, tx := range systemTxs {
+		for b < len(bankTxs) && !tx.TransactionTime.Before(bankTxs[b].Date) {
+			require.NoError(t, state.addBank(bankTxs[b]))
+			b++
+		}
+		require.NoError(t, state.addSystem(tx))
+		bankMark := endOfStream
+		if b < len(bankTxs) {
+			bankMark = bankTxs[b].Date
+		}
+		systemMark := endOfStream
+		if i+1 < len(systemTxs) {
+			systemMark = systemTxs[i+1].TransactionTime
+		}
+		state.closeBuckets(systemMark, bankMark)
+		maxOpen = max(maxOpen, len(state.open))
+	}
+	// Two transaction types for the current day at most.
+	assert.LessOrEqual(t, maxOpen, 2)
+	assert.Empty(t, state.buckets)
+}
+
+func TestMergeBankTransactions(t *testing.T) {
+	bca := []domain.BankTransaction{{ID: "bca-1", Date: newDate(1)}, {ID: "bca-3", Date: newDate(3)}}
+	bri := []domain.BankTransaction{{ID: "bri-1", Date: newDate(1)}, {ID: "bri-2", Date: newDate(2)}}
+	readErr := errors.New("broken")
+
+	var ids []string
+	var errs []error
+	for tx, err := range MergeBankTransactions(seqOf(bca), seqOf(bri, readErr)) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ids = append(ids, tx.ID)
+	}
+	assert.Equal(t, []string{"bca-1", "bri-1", "bri-2", "bca-3"}, ids)
+	assert.Equal(t, []error{readErr}, errs)
+
+	ids = nil
+	for tx := range MergeBankTransactions(seqOf(bca), seqOf(bri)) {
+		ids = append(ids, tx.ID)
+		if len(ids) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"bca-1", "bri-1"}, ids)
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultBatchParallelism is used by RunBatch when no limit is given.
+const DefaultBatchParallelism = 4
+
+// BatchJob is one reconciliation of a batch. Every job brings its own usecase,
+// since the ledgers and statements of different accounts are usually read
+// differently.
+type BatchJob struct {
+	Name    string
+	Usecase *ReconciliationUsecase
+	Request ReconciliationRequest
+	// Err reports a job that could not be prepared; it is listed as failed
+	// without running.
+	Err error
+}
+
+type BatchJobResult struct {
+	Name     string
+	Response *ReconciliationResponse
+	Err      error
+	Duration time.Duration
+}
+
+type BatchResult struct {
+	// Jobs are in the order they were given.
+	Jobs     []BatchJobResult
+	Overview BatchOverview
+}
+
+// BatchOverview adds up the summaries of the jobs that succeeded.
+type BatchOverview struct {
+	Jobs                        int
+	Succeeded                   int
+	Failed                      int
+	TotalSystemTransactions     int
+	TotalBankTransactions       int
+	MatchedTransactions         int
+	UnmatchedSystemTransactions int
+	UnmatchedBankTransactions   int
+	AmountDiscrepancyTotal      decimal.Decimal
+}
+
+// RunBatch runs the jobs with at most parallelism of them at a time. A failing
+// job does not stop the others; once ctx is done, jobs that have not started
+// fail with its error.
+func RunBatch(ctx context.Context, jobs []BatchJob, parallelism int) *BatchResult {
+	if parallelism <= 0 {
+		parallelism = DefaultBatchParallelism
+	}
+
+	results := make([]BatchJobResult, len(jobs))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		results[i].Name = job.Name
+		if job.Err != nil {
+			results[i].Err = job.Err
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			started := time.Now()
+			results[i].Response, results[i].Err = job.Usecase.Run(ctx, job.Request)
+			results[i].Duration = time.Since(started)
+		}()
+	}
+	wg.Wait()
+
+	return &BatchResult{Jobs: results, Overview: overview(results)}
+}
+
+func overview(results []BatchJobResult) BatchOverview {
+	total := BatchOverview{Jobs: len(results), AmountDiscrepancyTotal: decimal.Zero}
+	for _, result := range results {
+		if result.Err != nil {
+			total.Failed++
+			continue
+		}
+		total.Succeeded++
+		summary := result.Response.Summary
+		total.TotalSystemTransactions += summary.TotalSystemTransactions
+		total.TotalBankTransactions += summary.TotalBankTransactions
+		total.MatchedTransactions += summary.MatchedTransactions
+		total.UnmatchedSystemTransactions += len(summary.UnmatchedSystemTransactions)
+		total.UnmatchedBankTransactions += summary.UnmatchedBankCount()
+		total.AmountDiscrepancyTotal = total.AmountDiscrepancyTotal.Add(summary.AmountDiscrepancyTotal)
+	}
+	return total
+}
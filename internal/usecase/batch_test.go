@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockConcurrencyReader records how many reads overlap.
+type mockConcurrencyReader struct {
+	mockSuccessReader
+	running, peak atomic.Int32
+}
+
+func (m *mockConcurrencyReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	running := m.running.Add(1)
+	defer m.running.Add(-1)
+	for {
+		peak := m.peak.Load()
+		if running <= peak || m.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return m.mockSuccessReader.ReadSystemTransactions(ctx, filePath, startDate, endDate)
+}
+
+func TestRunBatch(t *testing.T) {
+	engine := service.NewReconciliationEngine()
+	now := time.Now()
+	request := ReconciliationRequest{SystemFile: "system.csv", BankFiles: []string{"bank.csv"}, Period: domain.Period{Start: now, End: now}}
+
+	t.Run("per job results and overview", func(t *testing.T) {
+		invalid := request
+		invalid.BankFiles = nil
+		jobs := []BatchJob{
+			{Name: "BCA", Usecase: NewReconciliationUsecase(&mockSuccessReader{}, &mockSuccessReader{}, engine), Request: request},
+			{Name: "BRI", Usecase: NewReconciliationUsecase(&mockSuccessReader{}, &mockErrorReader{}, engine), Request: request},
+			{Name: "Mandiri", Usecase: NewReconciliationUsecase(&mockSuccessReader{}, &mockSuccessReader{}, engine), Request: invalid},
+			{Name: "BNI", Usecase: NewReconciliationUsecase(&mockSuccessReader{}, &mockSuccessReader{}, engine), Request: request},
+			{Name: "CIMB", Err: errors.New("no statement files")},
+		}
+
+		result := RunBatch(context.Background(), jobs, 2)
+		require.Len(t, result.Jobs, 5)
+		assert.Equal(t, []string{"BCA", "BRI", "Mandiri", "BNI", "CIMB"}, []string{result.Jobs[0].Name, result.Jobs[1].Name, result.Jobs[2].Name, result.Jobs[3].Name, result.Jobs[4].Name})
+		assert.NoError(t, result.Jobs[0].Err)
+		assert.EqualError(t, result.Jobs[1].Err, "failed to read bank statements: mock bank error")
+		assert.ErrorIs(t, result.Jobs[2].Err, ErrInvalidRequest)
+		assert.Equal(t, 1, result.Jobs[3].Response.Summary.MatchedTransactions)
+		assert.EqualError(t, result.Jobs[4].Err, "no statement files")
+
+		overview := result.Overview
+		assert.Equal(t, 5, overview.Jobs)
+		assert.Equal(t, 2, overview.Succeeded)
+		assert.Equal(t, 3, overview.Failed)
+		assert.Equal(t, 2, overview.MatchedTransactions)
+		assert.Equal(t, 2, overview.TotalBankTransactions)
+		assert.True(t, overview.AmountDiscrepancyTotal.IsZero())
+	})
+
+	t.Run("bounded parallelism", func(t *testing.T) {
+		reader := &mockConcurrencyReader{}
+		jobs := make([]BatchJob, 8)
+		for i := range jobs {
+			jobs[i] = BatchJob{Name: string(rune('A' + i)), Usecase: NewReconciliationUsecase(reader, &mockSuccessReader{}, engine), Request: request}
+		}
+		result := RunBatch(context.Background(), jobs, 3)
+		assert.Equal(t, 8, result.Overview.Succeeded)
+		assert.LessOrEqual(t, reader.peak.Load(), int32(3))
+		assert.Greater(t, reader.peak.Load(), int32(1))
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		jobs := []BatchJob{{Name: "BCA", Usecase: NewReconciliationUsecase(&mockBlockingReader{}, &mockBlockingReader{}, engine), Request: request}}
+		result := RunBatch(ctx, jobs, 1)
+		assert.ErrorIs(t, result.Jobs[0].Err, context.Canceled)
+		assert.Equal(t, 1, result.Overview.Failed)
+	})
+}
@@ -2,9 +2,11 @@ package usecase
 
 import (
 	"context"
+	"iter"
 	"time"
 
 	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/service"
 )
 
 type DataRepository interface {
@@ -15,3 +17,17 @@ type DataRepository interface {
 type Reconciler interface {
 	Reconcile(ctx context.Context, systemFile string, bankFiles []string, startDate, endDate time.Time) (*domain.ReconciliationSummary, error)
 }
+
+// PeriodCloseStore keeps signed-off periods; repository.PeriodCloseStore
+// implements it. Find and Reopen both look up the record overlapping period.
+type PeriodCloseStore interface {
+	Find(scope string, period domain.Period) (*domain.PeriodClose, error)
+	Save(record *domain.PeriodClose) error
+	Reopen(scope string, period domain.Period, reopen domain.PeriodReopen) (*domain.PeriodClose, error)
+}
+
+// Engine matches transactions; service.ReconciliationEngine implements it.
+type Engine interface {
+	Reconcile(ctx context.Context, systemTxs []domain.SystemTransaction, bankTxs []domain.BankTransaction, options service.MatchOptions) (*domain.ReconciliationSummary, error)
+	ReconcileStream(ctx context.Context, systemTxs iter.Seq2[domain.SystemTransaction, error], bankTxs iter.Seq2[domain.BankTransaction, error], options service.MatchOptions) (*domain.ReconciliationSummary, error)
+}
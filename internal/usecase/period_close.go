@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+var errNoPeriodCloses = errors.New("no period close store configured")
+
+// CloseRequest finalises the result of a run.
+type CloseRequest struct {
+	Response *ReconciliationResponse
+	// Inputs are the digests of the files the run read.
+	Inputs     []domain.InputDigest
+	Overrides  []domain.Override
+	ApprovedBy string
+}
+
+// ClosePeriod locks the period of a run with its result. Later runs of the
+// same scope over any day of the period are refused until it is reopened.
+func (uc *ReconciliationUsecase) ClosePeriod(request CloseRequest) (*domain.PeriodClose, error) {
+	if uc.closes == nil {
+		return nil, errNoPeriodCloses
+	}
+	switch {
+	case request.Response == nil || request.Response.Summary == nil:
+		return nil, fmt.Errorf("%w: no reconciliation result to close", ErrInvalidRequest)
+	case strings.TrimSpace(request.ApprovedBy) == "":
+		return nil, fmt.Errorf("%w: closing a period needs an approver", ErrInvalidRequest)
+	}
+	for i, override := range request.Overrides {
+		if override.SystemTransactionID == "" && override.BankTransactionID == "" {
+			return nil, fmt.Errorf("%w: override #%d names no transaction", ErrInvalidRequest, i+1)
+		}
+	}
+
+	record := &domain.PeriodClose{
+		Scope:      request.Response.Request.Scope,
+		Period:     request.Response.Request.Period,
+		Inputs:     request.Inputs,
+		Summary:    request.Response.Summary,
+		Overrides:  request.Overrides,
+		ApprovedBy: strings.TrimSpace(request.ApprovedBy),
+		ClosedAt:   time.Now().UTC(),
+	}
+	if err := uc.closes.Save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// PeriodService reopens and reprints closed periods, which needs no readers.
+type PeriodService struct {
+	closes PeriodCloseStore
+}
+
+func NewPeriodService(store PeriodCloseStore) *PeriodService {
+	return &PeriodService{closes: store}
+}
+
+// ReopenPeriod unlocks the closed period overlapping period, the one that
+// refuses runs over it. The closed record is kept with who reopened it and
+// why.
+func (s *PeriodService) ReopenPeriod(scope string, period domain.Period, reopenedBy, reason string) (*domain.PeriodClose, error) {
+	if s.closes == nil {
+		return nil, errNoPeriodCloses
+	}
+	if strings.TrimSpace(reopenedBy) == "" || strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reopening a period needs a name and a reason", ErrInvalidRequest)
+	}
+	return s.closes.Reopen(scope, period, domain.PeriodReopen{
+		ReopenedBy: strings.TrimSpace(reopenedBy),
+		Reason:     strings.TrimSpace(reason),
+		ReopenedAt: time.Now().UTC(),
+	})
+}
+
+// ClosedPeriod returns the closed record overlapping period, or nil when the
+// period is open.
+func (s *PeriodService) ClosedPeriod(scope string, period domain.Period) (*domain.PeriodClose, error) {
+	if s.closes == nil {
+		return nil, errNoPeriodCloses
+	}
+	return s.closes.Find(scope, period)
+}
+
+func (uc *ReconciliationUsecase) checkOpen(scope string, period domain.Period) error {
+	if uc.closes == nil {
+		return nil
+	}
+	closed, err := uc.closes.Find(scope, period)
+	if err != nil {
+		return err
+	}
+	if closed != nil {
+		return fmt.Errorf("%w: %s to %s was closed by %s on %s", domain.ErrPeriodClosed,
+			closed.Period.Start.Format("2006-01-02"), closed.Period.End.Format("2006-01-02"),
+			closed.ApprovedBy, closed.ClosedAt.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+	"github.com/nmmugia/reconciliation-service/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPeriodCloseStore keeps records in memory.
+type mockPeriodCloseStore struct {
+	records  []*domain.PeriodClose
+	reopened []*domain.PeriodClose
+}
+
+func (m *mockPeriodCloseStore) Find(scope string, period domain.Period) (*domain.PeriodClose, error) {
+	for _, record := range m.records {
+		if record.Scope == scope && record.Overlaps(period) {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockPeriodCloseStore) Save(record *domain.PeriodClose) error {
+	m.records = append(m.records, record)
+	return nil
+}
+
+func (m *mockPeriodCloseStore) Reopen(scope string, period domain.Period, reopen domain.PeriodReopen) (*domain.PeriodClose, error) {
+	for i, record := range m.records {
+		if record.Scope == scope && record.Period == period {
+			record.Reopened = &reopen
+			m.records = append(m.records[:i], m.records[i+1:]...)
+			m.reopened = append(m.reopened, record)
+			return record, nil
+		}
+	}
+	return nil, assert.AnError
+}
+
+func TestPeriodClose(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 6, d, 0, 0, 0, 0, time.UTC) }
+	june := domain.Period{Start: day(1), End: day(30)}
+	request := ReconciliationRequest{SystemFile: "system.csv", BankFiles: []string{"bank.csv"}, Period: june, Scope: "BCA"}
+
+	store := &mockPeriodCloseStore{}
+	uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockSuccessReader{}, service.NewReconciliationEngine()).WithPeriodCloses(store)
+	periods := NewPeriodService(store)
+
+	response, err := uc.Run(context.Background(), request)
+	require.NoError(t, err)
+
+	_, err = uc.ClosePeriod(CloseRequest{Response: response})
+	assert.ErrorIs(t, err, ErrInvalidRequest, "approver is required")
+
+	inputs := []domain.InputDigest{{Path: "bank.csv", SHA256: "abc"}}
+	overrides := []domain.Override{{BankTransactionID: "B9", Note: "bank fee"}}
+	record, err := uc.ClosePeriod(CloseRequest{Response: response, Inputs: inputs, Overrides: overrides, ApprovedBy: " Controller "})
+	require.NoError(t, err)
+	assert.Equal(t, "BCA", record.Scope)
+	assert.Equal(t, june, record.Period)
+	assert.Equal(t, "Controller", record.ApprovedBy)
+	assert.Equal(t, inputs, record.Inputs)
+	assert.Equal(t, overrides, record.Overrides)
+	assert.Same(t, response.Summary, record.Summary)
+	assert.False(t, record.ClosedAt.IsZero())
+
+	t.Run("runs over the closed period are refused", func(t *testing.T) {
+		partial := request
+		partial.Period = domain.Period{Start: day(15), End: day(30)}
+		_, err := uc.Run(context.Background(), partial)
+		assert.ErrorIs(t, err, domain.ErrPeriodClosed)
+		assert.ErrorContains(t, err, "2025-06-01 to 2025-06-30 was closed by Controller")
+
+		other := request
+		other.Scope = "BRI"
+		_, err = uc.Run(context.Background(), other)
+		assert.NoError(t, err, "other scopes stay open")
+
+		closed, err := periods.ClosedPeriod("BCA", partial.Period)
+		require.NoError(t, err)
+		assert.Same(t, record, closed)
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		_, err := periods.ReopenPeriod("BCA", june, "Controller", "")
+		assert.ErrorIs(t, err, ErrInvalidRequest, "reason is required")
+
+		reopened, err := periods.ReopenPeriod("BCA", june, "Controller", "corrected statement")
+		require.NoError(t, err)
+		assert.Equal(t, "corrected statement", reopened.Reopened.Reason)
+
+		_, err = uc.Run(context.Background(), request)
+		assert.NoError(t, err)
+	})
+
+	t.Run("without store", func(t *testing.T) {
+		plain := NewReconciliationUsecase(&mockSuccessReader{}, &mockSuccessReader{}, service.NewReconciliationEngine())
+		_, err := plain.ClosePeriod(CloseRequest{Response: response, ApprovedBy: "Controller"})
+		assert.Error(t, err)
+		_, err = NewPeriodService(nil).ReopenPeriod("BCA", june, "Controller", "corrected statement")
+		assert.Error(t, err)
+	})
+}
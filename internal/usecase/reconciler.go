@@ -1,7 +1,10 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"sync"
 	"time"
 
@@ -9,13 +12,22 @@ import (
 	"github.com/nmmugia/reconciliation-service/internal/service"
 )
 
+var _ Reconciler = (*ReconciliationUsecase)(nil)
+
 type ReconciliationUsecase struct {
 	sysTxReader  domain.TransactionDataReader
 	bankTxReader domain.BankStatementReader
-	engine       *service.ReconciliationEngine
+	engine       Engine
+	// policy applies to Reconcile and the Perform methods; Run takes the
+	// policy of its request.
+	policy       Policy
+	closes       PeriodCloseStore
+	observer     domain.Observer
+	rejected     *domain.RejectionLog
+	fingerprints *domain.FingerprintLog
 }
 
-func NewReconciliationUsecase(sysReader domain.TransactionDataReader, bankReader domain.BankStatementReader, engine *service.ReconciliationEngine) *ReconciliationUsecase {
+func NewReconciliationUsecase(sysReader domain.TransactionDataReader, bankReader domain.BankStatementReader, engine Engine) *ReconciliationUsecase {
 	return &ReconciliationUsecase{
 		sysTxReader:  sysReader,
 		bankTxReader: bankReader,
@@ -23,34 +35,305 @@ func NewReconciliationUsecase(sysReader domain.TransactionDataReader, bankReader
 	}
 }
 
-func (uc *ReconciliationUsecase) PerformReconciliation(sysTxPath string, bankTxPaths []string, start, end time.Time) (*domain.ReconciliationSummary, error) {
+// NewReconciliationUsecaseFromRepository loads both sides through repo.
+// Streaming needs iterator readers and is not available this way.
+func NewReconciliationUsecaseFromRepository(repo DataRepository, engine Engine) *ReconciliationUsecase {
+	return NewReconciliationUsecase(repositoryReader{repo}, repositoryReader{repo}, engine)
+}
+
+// WithPartialFailures lets a run continue when some bank statements cannot be
+// read. The failed sources are listed in the summary as not reconciled instead
+// of failing the whole run; system transaction errors are always fatal.
+func (uc *ReconciliationUsecase) WithPartialFailures(allow bool) *ReconciliationUsecase {
+	uc.policy.AllowPartial = allow
+	return uc
+}
+
+// WithDeclaredBalances sets the opening and closing balances the bank
+// statements are validated against; running balances are checked either way.
+func (uc *ReconciliationUsecase) WithDeclaredBalances(balances []domain.DeclaredBalance) *ReconciliationUsecase {
+	uc.policy.DeclaredBalances = balances
+	return uc
+}
+
+// WithStreaming makes Reconcile match while reading, see
+// PerformStreamingReconciliation.
+func (uc *ReconciliationUsecase) WithStreaming(stream bool) *ReconciliationUsecase {
+	uc.policy.Stream = stream
+	return uc
+}
+
+// WithPeriodCloses makes runs over a closed period fail with
+// domain.ErrPeriodClosed and enables ClosePeriod.
+func (uc *ReconciliationUsecase) WithPeriodCloses(store PeriodCloseStore) *ReconciliationUsecase {
+	uc.closes = store
+	return uc
+}
+
+// WithObserver sends the engine's match events and a run completed event to
+// observer. Events carry the scope of the request.
+func (uc *ReconciliationUsecase) WithObserver(observer domain.Observer) *ReconciliationUsecase {
+	uc.observer = observer
+	return uc
+}
+
+// WithRejectionLog lists the rows the readers report to log in the summary
+// of each run. The readers must be given the same log. It is emptied when a
+// run starts, so runs sharing it must not overlap.
+func (uc *ReconciliationUsecase) WithRejectionLog(log *domain.RejectionLog) *ReconciliationUsecase {
+	uc.rejected = log
+	return uc
+}
+
+// WithFingerprintLog leaves out bank statements whose content fingerprint the
+// bank reader reported to log for another statement. The bank reader must be
+// given the same log, which is emptied when a run starts.
+func (uc *ReconciliationUsecase) WithFingerprintLog(log *domain.FingerprintLog) *ReconciliationUsecase {
+	uc.fingerprints = log
+	return uc
+}
+
+// Reconcile implements Reconciler with the policy set through the With
+// methods.
+func (uc *ReconciliationUsecase) Reconcile(ctx context.Context, systemFile string, bankFiles []string, startDate, endDate time.Time) (*domain.ReconciliationSummary, error) {
+	response, err := uc.Run(ctx, ReconciliationRequest{
+		SystemFile: systemFile,
+		BankFiles:  bankFiles,
+		Period:     domain.Period{Start: startDate, End: endDate},
+		Policy:     uc.policy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Summary, nil
+}
+
+// Run validates the request and reconciles it.
+func (uc *ReconciliationUsecase) Run(ctx context.Context, request ReconciliationRequest) (*ReconciliationResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.checkOpen(request.Scope, request.Period); err != nil {
+		return nil, err
+	}
+	observer := uc.runObserver(request.Scope)
+	reconcile := uc.reconcileLoaded
+	if request.Policy.Stream {
+		reconcile = uc.reconcileStreaming
+	}
+	summary, err := reconcile(ctx, request, observer)
+	if err != nil {
+		return nil, err
+	}
+	domain.Notify(ctx, observer, domain.Event{Type: domain.EventRunCompleted, Summary: summary})
+	return &ReconciliationResponse{Request: request, Summary: summary}, nil
+}
+
+// PerformReconciliation loads both sides before matching them.
+//
+// Deprecated: use Reconcile, or Run for a per-call policy.
+func (uc *ReconciliationUsecase) PerformReconciliation(ctx context.Context, sysTxPath string, bankTxPaths []string, start, end time.Time) (*domain.ReconciliationSummary, error) {
+	return uc.reconcileLoaded(ctx, uc.request(sysTxPath, bankTxPaths, start, end), uc.runObserver(""))
+}
+
+// PerformStreamingReconciliation matches transactions while the inputs are
+// read instead of loading them first. Both readers must implement the
+// domain iterator interfaces, the system transactions must be in time order
+// and every bank statement in date order (see ReconcileStream). Partial
+// failures are not supported: the first read error ends the run. Copies of
+// whole statements are only recognised once they have been read (see
+// DuplicateStatementsOfStream).
+//
+// Deprecated: use Reconcile with WithStreaming, or Run with Policy.Stream.
+func (uc *ReconciliationUsecase) PerformStreamingReconciliation(ctx context.Context, sysTxPath string, bankTxPaths []string, start, end time.Time) (*domain.ReconciliationSummary, error) {
+	return uc.reconcileStreaming(ctx, uc.request(sysTxPath, bankTxPaths, start, end), uc.runObserver(""))
+}
+
+func (uc *ReconciliationUsecase) request(sysTxPath string, bankTxPaths []string, start, end time.Time) ReconciliationRequest {
+	return ReconciliationRequest{
+		SystemFile: sysTxPath,
+		BankFiles:  bankTxPaths,
+		Period:     domain.Period{Start: start, End: end},
+		Policy:     uc.policy,
+	}
+}
+
+func (uc *ReconciliationUsecase) reconcileLoaded(ctx context.Context, request ReconciliationRequest, observer domain.Observer) (*domain.ReconciliationSummary, error) {
+	start, end := request.Period.Start, request.Period.End
+	tolerates := func(bankErr error) bool {
+		return request.Policy.AllowPartial && domain.OnlySourceErrors(bankErr)
+	}
+
 	var sysTxs []domain.SystemTransaction
 	var bankTxs []domain.BankTransaction
 	var sysErr, bankErr error
 	var wg sync.WaitGroup
+	uc.rejected.Reset()
+	uc.fingerprints.Reset()
+
+	// A failure on one side stops the other reader early.
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	wg.Add(2)
 
 	go func() {
 		defer wg.Done()
-		sysTxs, sysErr = uc.sysTxReader.ReadSystemTransactions(sysTxPath, start, end)
+		sysTxs, sysErr = uc.sysTxReader.ReadSystemTransactions(readCtx, request.SystemFile, start, end)
+		if sysErr != nil {
+			cancel()
+		}
 	}()
 
 	go func() {
 		defer wg.Done()
-		bankTxs, bankErr = uc.bankTxReader.ReadBankTransactions(bankTxPaths, start, end)
+		bankTxs, bankErr = uc.bankTxReader.ReadBankTransactions(readCtx, uniquePaths(request.BankFiles), start, end)
+		if bankErr != nil && !tolerates(bankErr) {
+			cancel()
+		}
 	}()
 
 	wg.Wait()
 
-	if sysErr != nil {
-		return nil, fmt.Errorf("failed to read system transactions: %w", sysErr)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("reconciliation cancelled: %w", err)
+	}
+	if sysErr != nil && !(bankErr != nil && errors.Is(sysErr, context.Canceled)) {
+		return nil, inputError{fmt.Errorf("failed to read system transactions: %w", sysErr)}
 	}
-	if bankErr != nil {
-		return nil, fmt.Errorf("failed to read bank statements: %w", bankErr)
+	if bankErr != nil && (!tolerates(bankErr) || len(bankTxs) == 0) {
+		return nil, inputError{fmt.Errorf("failed to read bank statements: %w", bankErr)}
 	}
 
-	summary := uc.engine.Reconcile(sysTxs, bankTxs)
+	bankTxs, duplicateStatements, duplicateTxs := service.DeduplicateBankTransactions(bankTxs, uc.fingerprints.Fingerprints())
+	balanceGaps := service.ValidateBalances(bankTxs, request.Policy.DeclaredBalances)
 
+	summary, err := uc.engine.Reconcile(ctx, sysTxs, bankTxs, service.MatchOptions{Observer: observer})
+	if err != nil {
+		return nil, fmt.Errorf("reconciliation cancelled: %w", err)
+	}
+	summary.DuplicateStatements = duplicateStatements
+	summary.DuplicateBankTransactions = duplicateTxs
+	summary.BalanceGaps = balanceGaps
+
+	for _, failure := range domain.SourceErrors(bankErr) {
+		summary.UnreconciledSources = append(summary.UnreconciledSources, domain.UnreconciledSource{
+			Source: failure.Source,
+			Reason: failure.Err.Error(),
+		})
+	}
+	summary.RejectedRows = uc.rejected.Rows()
+	summary.Aging = service.AgeUnmatched(summary, end)
+
+	return summary, nil
+}
+
+func (uc *ReconciliationUsecase) reconcileStreaming(ctx context.Context, request ReconciliationRequest, observer domain.Observer) (*domain.ReconciliationSummary, error) {
+	start, end := request.Period.Start, request.Period.End
+	sysIterator, ok := uc.sysTxReader.(domain.TransactionDataIterator)
+	if !ok {
+		return nil, errors.New("system transaction reader does not support streaming")
+	}
+	bankIterator, ok := uc.bankTxReader.(domain.BankStatementIterator)
+	if !ok {
+		return nil, errors.New("bank statement reader does not support streaming")
+	}
+
+	uc.rejected.Reset()
+	uc.fingerprints.Reset()
+
+	// Statements are opened side by side and merged by date, since files of
+	// different banks cover the same days.
+	bankTxPaths := uniquePaths(request.BankFiles)
+	bankStreams := make([]iter.Seq2[domain.BankTransaction, error], len(bankTxPaths))
+	counters := make([]service.SourceCounter, len(bankTxPaths))
+	for i, path := range bankTxPaths {
+		bankStreams[i] = counters[i].Count(bankIterator.BankTransactions(ctx, []string{path}, start, end))
+	}
+
+	var duplicateTxs []domain.DuplicateBankTransaction
+	var balanceGaps []domain.BalanceGap
+	bankTxs := service.DeduplicateBankStream(service.MergeBankTransactions(bankStreams...), &duplicateTxs)
+	bankTxs = service.ValidateBalanceStream(bankTxs, request.Policy.DeclaredBalances, &balanceGaps)
+
+	summary, err := uc.engine.ReconcileStream(ctx,
+		wrapErrors(sysIterator.SystemTransactions(ctx, request.SystemFile, start, end), "failed to read system transactions"),
+		wrapErrors(bankTxs, "failed to read bank statements"),
+		service.MatchOptions{Observer: observer, KeepMatchedPairs: request.Policy.KeepMatchedPairs})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("reconciliation cancelled: %w", ctxErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	duplicateStatements, duplicateTxs, err := service.DuplicateStatementsOfStream(counters, uc.fingerprints.Fingerprints(), duplicateTxs)
+	if err != nil {
+		return nil, err
+	}
+	summary.RejectedRows = uc.rejected.Rows()
+	summary.DuplicateStatements = duplicateStatements
+	summary.DuplicateBankTransactions = duplicateTxs
+	summary.BalanceGaps = balanceGaps
+	summary.Aging = service.AgeUnmatched(summary, end)
 	return summary, nil
 }
+
+// uniquePaths drops repeated paths, so that a statement named twice is read
+// once; copies under other names are left to the duplicate detection.
+func uniquePaths(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+	unique := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	return unique
+}
+
+func wrapErrors[T any](seq iter.Seq2[T, error], message string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for item, err := range seq {
+			if err != nil {
+				err = inputError{fmt.Errorf("%s: %w", message, err)}
+			}
+			if !yield(item, err) {
+				return
+			}
+		}
+	}
+}
+
+// repositoryReader adapts a DataRepository to the domain reader interfaces.
+type repositoryReader struct {
+	repo DataRepository
+}
+
+func (r repositoryReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	return r.repo.LoadSystemTransactions(ctx, filePath, startDate, endDate)
+}
+
+func (r repositoryReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	return r.repo.LoadBankStatements(ctx, filePaths, startDate, endDate)
+}
+
+// runObserver returns the observer of the usecase tagging events with
+// scope, or nil without one.
+func (uc *ReconciliationUsecase) runObserver(scope string) domain.Observer {
+	if uc.observer == nil {
+		return nil
+	}
+	return scopedObserver{scope: scope, observer: uc.observer}
+}
+
+// scopedObserver tags events with the scope of the run they belong to.
+type scopedObserver struct {
+	scope    string
+	observer domain.Observer
+}
+
+func (o scopedObserver) OnEvent(ctx context.Context, event domain.Event) {
+	event.Scope = o.scope
+	o.observer.OnEvent(ctx, event)
+}
@@ -1,7 +1,14 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,16 +17,17 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockSuccessReader struct{}
 
-func (m *mockSuccessReader) ReadSystemTransactions(filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+func (m *mockSuccessReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
 	return []domain.SystemTransaction{
 		{ID: "sys1", Amount: decimal.NewFromInt(100), Type: domain.Credit, TransactionTime: time.Now()},
 	}, nil
 }
-func (m *mockSuccessReader) ReadBankTransactions(filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+func (m *mockSuccessReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
 	return []domain.BankTransaction{
 		{ID: "bank1", Amount: decimal.NewFromInt(100), Date: time.Now()},
 	}, nil
@@ -27,36 +35,427 @@ func (m *mockSuccessReader) ReadBankTransactions(filePaths []string, startDate,
 
 type mockErrorReader struct{}
 
-func (m *mockErrorReader) ReadSystemTransactions(filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+func (m *mockErrorReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
 	return nil, errors.New("mock system error")
 }
-func (m *mockErrorReader) ReadBankTransactions(filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+func (m *mockErrorReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
 	return nil, errors.New("mock bank error")
 }
 
+// mockBlockingReader only returns once its context is done.
+type mockBlockingReader struct{}
+
+func (m *mockBlockingReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+func (m *mockBlockingReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// mockPartialReader reads bank1 but fails on every other file.
+type mockPartialReader struct{}
+
+func (m *mockPartialReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	var txs []domain.BankTransaction
+	var errs []error
+	for _, path := range filePaths {
+		if path == "bank1.csv" {
+			txs = append(txs, domain.BankTransaction{ID: "bank1", Amount: decimal.NewFromInt(100), Date: time.Now(), BankName: path})
+			continue
+		}
+		errs = append(errs, &domain.SourceError{Source: path, Err: errors.New("could not open " + path)})
+	}
+	return txs, errors.Join(errs...)
+}
+
+// mockRejectingReader skips one row of every input it reads.
+type mockRejectingReader struct {
+	mockSuccessReader
+	rejected *domain.RejectionLog
+}
+
+func (m *mockRejectingReader) ReadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	m.rejected.Add(domain.RejectedRow{Source: filePath, Line: 3, Reason: "unknown transaction type 'REFUND'"})
+	return m.mockSuccessReader.ReadSystemTransactions(ctx, filePath, startDate, endDate)
+}
+func (m *mockRejectingReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	for _, path := range filePaths {
+		m.rejected.Add(domain.RejectedRow{Source: path, Line: 2, Reason: "invalid amount 'abc'"})
+	}
+	return m.mockSuccessReader.ReadBankTransactions(ctx, filePaths, startDate, endDate)
+}
+
+// mockStatementReader serves fixed transactions per statement path. It
+// fingerprints a statement by its transactions, so that statements listing
+// the same ones stand for identical files.
+type mockStatementReader struct {
+	statements   map[string][]domain.BankTransaction
+	fingerprints *domain.FingerprintLog
+}
+
+func (m mockStatementReader) ReadBankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	var txs []domain.BankTransaction
+	for _, path := range filePaths {
+		m.fingerprints.Add(path, fmt.Sprint(m.statements[path]))
+		for _, tx := range m.statements[path] {
+			tx.Source = path
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
 func TestReconciliationUsecase_PerformReconciliation(t *testing.T) {
 	engine := service.NewReconciliationEngine()
 
 	t.Run("successful reconciliation", func(t *testing.T) {
 		uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockSuccessReader{}, engine)
-		summary, err := uc.PerformReconciliation("sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
+		summary, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
 
 		assert.NoError(t, err)
 		assert.NotNil(t, summary)
 		assert.Equal(t, 1, summary.MatchedTransactions)
 	})
 
+	t.Run("rejected rows are listed in the summary", func(t *testing.T) {
+		log := &domain.RejectionLog{}
+		reader := &mockRejectingReader{rejected: log}
+		uc := NewReconciliationUsecase(reader, reader, engine).WithRejectionLog(log)
+		summary, err := uc.PerformReconciliation(context.Background(), "system.csv", []string{"bank.csv"}, time.Now(), time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.MatchedTransactions)
+		expected := []domain.RejectedRow{
+			{Source: "bank.csv", Line: 2, Reason: "invalid amount 'abc'"},
+			{Source: "system.csv", Line: 3, Reason: "unknown transaction type 'REFUND'"},
+		}
+		assert.Equal(t, expected, summary.RejectedRows)
+
+		summary, err = uc.PerformReconciliation(context.Background(), "system.csv", []string{"bank.csv"}, time.Now(), time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, expected, summary.RejectedRows, "rows of earlier runs are not repeated")
+	})
+
+	t.Run("duplicate statements are left out", func(t *testing.T) {
+		now := time.Now()
+		bank1 := domain.BankTransaction{ID: "bank1", Amount: decimal.NewFromInt(100), Date: now, BankName: "BCA"}
+		bank2 := domain.BankTransaction{ID: "bank2", Amount: decimal.NewFromInt(-40), Date: now, BankName: "BCA"}
+		log := &domain.FingerprintLog{}
+		reader := mockStatementReader{statements: map[string][]domain.BankTransaction{
+			"june.csv":      {bank1},
+			"june-copy.csv": {bank1},
+			"july.csv":      {bank1, bank2},
+		}, fingerprints: log}
+		uc := NewReconciliationUsecase(&mockSuccessReader{}, reader, engine).WithFingerprintLog(log)
+		summary, err := uc.PerformReconciliation(context.Background(), "system.csv", []string{"june.csv", "june-copy.csv", "july.csv"}, now, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, summary.TotalBankTransactions)
+		assert.Equal(t, 1, summary.MatchedTransactions)
+		assert.Len(t, summary.DuplicateStatements, 1)
+		assert.Equal(t, "june-copy.csv", summary.DuplicateStatements[0].Source)
+		assert.Len(t, summary.DuplicateBankTransactions, 1)
+		assert.Equal(t, "july.csv", summary.DuplicateBankTransactions[0].Transaction.Source)
+		assert.Equal(t, "june.csv", summary.DuplicateBankTransactions[0].DuplicateOf)
+	})
+
+	t.Run("balance gaps are reported", func(t *testing.T) {
+		now := time.Now()
+		reader := mockStatementReader{statements: map[string][]domain.BankTransaction{"bca.csv": {
+			{ID: "bank1", Amount: decimal.NewFromInt(100), Date: now, BankName: "BCA", Balance: decimal.NewNullDecimal(decimal.NewFromInt(1100))},
+			{ID: "bank3", Amount: decimal.NewFromInt(-40), Date: now, BankName: "BCA", Balance: decimal.NewNullDecimal(decimal.NewFromInt(1000))},
+		}}}
+		declared := []domain.DeclaredBalance{{BankName: "BCA", Opening: decimal.NewNullDecimal(decimal.NewFromInt(1000)), Closing: decimal.NewNullDecimal(decimal.NewFromInt(1000))}}
+		uc := NewReconciliationUsecase(&mockSuccessReader{}, reader, engine).WithDeclaredBalances(declared)
+		summary, err := uc.PerformReconciliation(context.Background(), "system.csv", []string{"bca.csv"}, now, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.MatchedTransactions)
+		if assert.Len(t, summary.BalanceGaps, 1) {
+			assert.Equal(t, "bank3", summary.BalanceGaps[0].TransactionID)
+			assert.Equal(t, "1060", summary.BalanceGaps[0].Expected.String())
+		}
+	})
+
 	t.Run("system reader error", func(t *testing.T) {
 		uc := NewReconciliationUsecase(&mockErrorReader{}, &mockSuccessReader{}, engine)
-		_, err := uc.PerformReconciliation("sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
+		_, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
 		assert.Error(t, err)
 		assert.Equal(t, "failed to read system transactions: mock system error", err.Error())
 	})
 
 	t.Run("bank reader error", func(t *testing.T) {
 		uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockErrorReader{}, engine)
-		_, err := uc.PerformReconciliation("sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
+		_, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
+		assert.Error(t, err)
+		assert.Equal(t, "failed to read bank statements: mock bank error", err.Error())
+	})
+
+	t.Run("reader error cancels the other reader", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockBlockingReader{}, &mockErrorReader{}, engine)
+		_, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
 		assert.Error(t, err)
 		assert.Equal(t, "failed to read bank statements: mock bank error", err.Error())
 	})
+
+	t.Run("bank source errors fail the run by default", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockPartialReader{}, engine)
+		_, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"bank1.csv", "bank2.csv", "bank3.csv"}, time.Now(), time.Now())
+		assert.EqualError(t, err, "failed to read bank statements: could not open bank2.csv\ncould not open bank3.csv")
+	})
+
+	t.Run("partial failures mark sources as not reconciled", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockPartialReader{}, engine).WithPartialFailures(true)
+		summary, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"bank1.csv", "bank2.csv", "bank3.csv"}, time.Now(), time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.MatchedTransactions)
+		assert.Equal(t, []domain.UnreconciledSource{
+			{Source: "bank2.csv", Reason: "could not open bank2.csv"},
+			{Source: "bank3.csv", Reason: "could not open bank3.csv"},
+		}, summary.UnreconciledSources)
+	})
+
+	t.Run("partial failures still need one readable source", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockPartialReader{}, engine).WithPartialFailures(true)
+		_, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"bank2.csv"}, time.Now(), time.Now())
+		assert.EqualError(t, err, "failed to read bank statements: could not open bank2.csv")
+	})
+
+	t.Run("partial failures do not hide untagged errors", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockErrorReader{}, engine).WithPartialFailures(true)
+		_, err := uc.PerformReconciliation(context.Background(), "sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
+		assert.EqualError(t, err, "failed to read bank statements: mock bank error")
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		uc := NewReconciliationUsecase(&mockBlockingReader{}, &mockSuccessReader{}, engine)
+		_, err := uc.PerformReconciliation(ctx, "sample/system.csv", []string{"sample/bank.csv"}, time.Now(), time.Now())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+// mockRepository serves the transactions of mockSuccessReader through the
+// DataRepository interface.
+type mockRepository struct {
+	mockSuccessReader
+}
+
+func (m *mockRepository) LoadSystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) ([]domain.SystemTransaction, error) {
+	return m.ReadSystemTransactions(ctx, filePath, startDate, endDate)
+}
+func (m *mockRepository) LoadBankStatements(ctx context.Context, filePaths []string, startDate, endDate time.Time) ([]domain.BankTransaction, error) {
+	return m.ReadBankTransactions(ctx, filePaths, startDate, endDate)
+}
+
+func TestReconciliationUsecase_Run(t *testing.T) {
+	engine := service.NewReconciliationEngine()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	request := ReconciliationRequest{SystemFile: "system.csv", BankFiles: []string{"1.csv", "2.csv"}, Period: domain.Period{Start: start, End: start}}
+
+	t.Run("loaded", func(t *testing.T) {
+		response, err := NewReconciliationUsecase(&mockStreamReader{}, &mockStreamReader{}, engine).Run(context.Background(), request)
+		assert.NoError(t, err)
+		assert.Equal(t, request, response.Request)
+		assert.Equal(t, 1, response.Summary.MatchedTransactions)
+		require.NotNil(t, response.Summary.Aging)
+		assert.Equal(t, start, response.Summary.Aging.AsOf, "aged at the period end")
+	})
+
+	t.Run("policy of the request", func(t *testing.T) {
+		streamed := request
+		streamed.Policy.Stream = true
+		uc := NewReconciliationUsecase(&mockStreamReader{}, &mockStreamReader{}, engine)
+		response, err := uc.Run(context.Background(), streamed)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, response.Summary.MatchedTransactions)
+		assert.Empty(t, response.Summary.MatchedPairs, "streamed pairs are only kept on request")
+		assert.NotNil(t, response.Summary.Aging)
+
+		streamed.Policy.KeepMatchedPairs = true
+		response, err = uc.Run(context.Background(), streamed)
+		assert.NoError(t, err)
+		assert.Len(t, response.Summary.MatchedPairs, 2)
+
+		partial := request
+		partial.BankFiles = []string{"bank1.csv", "bank2.csv"}
+		partial.Policy.AllowPartial = true
+		response, err = NewReconciliationUsecase(&mockSuccessReader{}, &mockPartialReader{}, engine).Run(context.Background(), partial)
+		assert.NoError(t, err)
+		assert.Len(t, response.Summary.UnreconciledSources, 1)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		invalid := request
+		invalid.BankFiles = nil
+		_, err := NewReconciliationUsecase(&mockSuccessReader{}, &mockSuccessReader{}, engine).Run(context.Background(), invalid)
+		assert.ErrorIs(t, err, ErrInvalidRequest)
+	})
+
+	t.Run("unreadable input", func(t *testing.T) {
+		_, err := NewReconciliationUsecase(&mockErrorReader{}, &mockSuccessReader{}, engine).Run(context.Background(), request)
+		assert.ErrorIs(t, err, ErrUnreadableInput)
+		assert.EqualError(t, err, "failed to read system transactions: mock system error")
+
+		broken := request
+		broken.BankFiles = []string{"1.csv", "broken.csv"}
+		broken.Policy.Stream = true
+		_, err = NewReconciliationUsecase(&mockStreamReader{}, &mockStreamReader{}, engine).Run(context.Background(), broken)
+		assert.ErrorIs(t, err, ErrUnreadableInput)
+
+		_, err = NewReconciliationUsecase(&mockSuccessReader{}, &mockStreamReader{}, engine).Run(context.Background(), broken)
+		assert.NotErrorIs(t, err, ErrUnreadableInput, "a reader that cannot stream is not an input problem")
+	})
+
+	t.Run("events", func(t *testing.T) {
+		observer := &mockObserver{}
+		scoped := request
+		scoped.Scope = "BCA"
+		for _, stream := range []bool{false, true} {
+			observer.events = nil
+			scoped.Policy.Stream = stream
+			response, err := NewReconciliationUsecase(&mockStreamReader{}, &mockStreamReader{}, engine).WithObserver(observer).Run(context.Background(), scoped)
+			require.NoError(t, err)
+
+			require.NotEmpty(t, observer.events)
+			last := observer.events[len(observer.events)-1]
+			assert.Equal(t, domain.EventRunCompleted, last.Type)
+			assert.Same(t, response.Summary, last.Summary)
+			matched := 0
+			for _, event := range observer.events {
+				assert.Equal(t, "BCA", event.Scope)
+				if event.Type == domain.EventMatched {
+					matched++
+				}
+			}
+			assert.Equal(t, response.Summary.MatchedTransactions, matched)
+		}
+	})
+}
+
+type mockObserver struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (m *mockObserver) OnEvent(_ context.Context, event domain.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+}
+
+func TestReconciliationUsecase_Reconcile(t *testing.T) {
+	engine := service.NewReconciliationEngine()
+	now := time.Now()
+
+	var reconciler Reconciler = NewReconciliationUsecaseFromRepository(&mockRepository{}, engine)
+	summary, err := reconciler.Reconcile(context.Background(), "system.csv", []string{"bank.csv"}, now, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.MatchedTransactions)
+
+	reconciler = NewReconciliationUsecase(&mockSuccessReader{}, &mockPartialReader{}, engine).WithPartialFailures(true)
+	summary, err = reconciler.Reconcile(context.Background(), "system.csv", []string{"bank1.csv", "bank2.csv"}, now, now)
+	assert.NoError(t, err)
+	assert.Len(t, summary.UnreconciledSources, 1)
+
+	reconciler = NewReconciliationUsecaseFromRepository(&mockRepository{}, engine).WithStreaming(true)
+	_, err = reconciler.Reconcile(context.Background(), "system.csv", []string{"bank.csv"}, now, now)
+	assert.EqualError(t, err, "system transaction reader does not support streaming")
+}
+
+// mockStreamReader streams one bank transaction per file, dated by the file's
+// base name, and fails on files named "broken.csv". Files with the same base
+// name have the same content. Its system transactions always come with one
+// rejected row.
+type mockStreamReader struct {
+	mockSuccessReader
+	rejected     *domain.RejectionLog
+	fingerprints *domain.FingerprintLog
+}
+
+func (m *mockStreamReader) SystemTransactions(ctx context.Context, filePath string, startDate, endDate time.Time) iter.Seq2[domain.SystemTransaction, error] {
+	return func(yield func(domain.SystemTransaction, error) bool) {
+		m.rejected.Add(domain.RejectedRow{Source: filePath, Line: 2, Reason: "invalid amount 'abc'"})
+		for day := 1; day <= 2; day++ {
+			tx := domain.SystemTransaction{ID: fmt.Sprintf("sys%d", day), Amount: decimal.NewFromInt(100), Type: domain.Credit, TransactionTime: startDate.AddDate(0, 0, day-1)}
+			if !yield(tx, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (m *mockStreamReader) BankTransactions(ctx context.Context, filePaths []string, startDate, endDate time.Time) iter.Seq2[domain.BankTransaction, error] {
+	return func(yield func(domain.BankTransaction, error) bool) {
+		for _, path := range filePaths {
+			if path == "broken.csv" {
+				yield(domain.BankTransaction{}, &domain.SourceError{Source: path, Err: errors.New("could not open broken.csv")})
+				return
+			}
+			name := filepath.Base(path)
+			day, _ := strconv.Atoi(strings.TrimSuffix(name, ".csv"))
+			if !yield(domain.BankTransaction{ID: name, Amount: decimal.NewFromInt(100), Date: startDate.AddDate(0, 0, day-1), BankName: path, Source: path}, nil) {
+				return
+			}
+			m.fingerprints.Add(path, name)
+		}
+	}
+}
+
+func TestReconciliationUsecase_PerformStreamingReconciliation(t *testing.T) {
+	engine := service.NewReconciliationEngine()
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("statements are merged by date", func(t *testing.T) {
+		log := &domain.RejectionLog{}
+		reader := &mockStreamReader{rejected: log}
+		uc := NewReconciliationUsecase(reader, reader, engine).WithRejectionLog(log)
+		summary, err := uc.PerformStreamingReconciliation(context.Background(), "system.csv", []string{"2.csv", "1.csv"}, start, start)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, summary.MatchedTransactions)
+		assert.Equal(t, []string{"1.csv", "2.csv"}, summary.BankNames)
+		assert.Equal(t, []domain.RejectedRow{{Source: "system.csv", Line: 2, Reason: "invalid amount 'abc'"}}, summary.RejectedRows)
+	})
+
+	t.Run("statements passed twice", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockStreamReader{}, &mockStreamReader{}, engine)
+		summary, err := uc.PerformStreamingReconciliation(context.Background(), "system.csv", []string{"1.csv", "1.csv"}, start, start)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, summary.TotalBankTransactions)
+	})
+
+	t.Run("identical statements", func(t *testing.T) {
+		log := &domain.FingerprintLog{}
+		reader := &mockStreamReader{fingerprints: log}
+		uc := NewReconciliationUsecase(reader, reader, engine).WithFingerprintLog(log)
+		summary, err := uc.PerformStreamingReconciliation(context.Background(), "system.csv", []string{"1.csv", "2.csv", "copy/1.csv"}, start, start)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.DuplicateStatement{{Source: "copy/1.csv", DuplicateOf: "1.csv", Fingerprint: "1.csv", Transactions: 1}}, summary.DuplicateStatements)
+		assert.Empty(t, summary.DuplicateBankTransactions)
+		assert.Equal(t, 2, summary.TotalBankTransactions)
+	})
+
+	t.Run("reader errors", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockStreamReader{}, &mockStreamReader{}, engine)
+		_, err := uc.PerformStreamingReconciliation(context.Background(), "system.csv", []string{"1.csv", "broken.csv"}, start, start)
+		assert.EqualError(t, err, "failed to read bank statements: could not open broken.csv")
+	})
+
+	t.Run("readers without iterators", func(t *testing.T) {
+		uc := NewReconciliationUsecase(&mockSuccessReader{}, &mockStreamReader{}, engine)
+		_, err := uc.PerformStreamingReconciliation(context.Background(), "system.csv", []string{"1.csv"}, start, start)
+		assert.EqualError(t, err, "system transaction reader does not support streaming")
+
+		uc = NewReconciliationUsecase(&mockStreamReader{}, &mockSuccessReader{}, engine)
+		_, err = uc.PerformStreamingReconciliation(context.Background(), "system.csv", []string{"1.csv"}, start, start)
+		assert.EqualError(t, err, "bank statement reader does not support streaming")
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		uc := NewReconciliationUsecase(&mockStreamReader{}, &mockStreamReader{}, engine)
+		_, err := uc.PerformStreamingReconciliation(ctx, "system.csv", []string{"1.csv"}, start, start)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
 }
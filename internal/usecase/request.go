@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+)
+
+// ErrInvalidRequest is wrapped by the errors of ReconciliationRequest.Validate.
+var ErrInvalidRequest = errors.New("invalid reconciliation request")
+
+// ErrUnreadableInput matches, with errors.Is, the errors of Run for inputs
+// that could not be read, as opposed to failures of the run itself.
+var ErrUnreadableInput = errors.New("unreadable input")
+
+// inputError marks a read failure as ErrUnreadableInput without changing its
+// message.
+type inputError struct {
+	err error
+}
+
+func (e inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e inputError) Unwrap() error {
+	return e.err
+}
+
+func (e inputError) Is(target error) bool {
+	return target == ErrUnreadableInput
+}
+
+// ReconciliationRequest describes one reconciliation run for Run.
+type ReconciliationRequest struct {
+	// SystemFile is passed to the system transaction reader; readers that do
+	// not read files, such as the database reader, ignore it.
+	SystemFile string
+	BankFiles  []string
+	Period     domain.Period
+	Policy     Policy
+	// Scope names the books being reconciled when periods are closed per
+	// account or job; see WithPeriodCloses.
+	Scope string
+}
+
+// Policy decides how a run treats its inputs.
+type Policy struct {
+	// AllowPartial continues without bank statements that cannot be read,
+	// see WithPartialFailures.
+	AllowPartial bool
+	// Stream matches while reading, see PerformStreamingReconciliation. It
+	// cannot be combined with AllowPartial.
+	Stream bool
+	// KeepMatchedPairs keeps the matched pairs of a streamed run in its
+	// summary, for reports that list them. Without it only their counts and
+	// breakdowns are kept, so that memory stays bounded. Loaded runs always
+	// keep them.
+	KeepMatchedPairs bool
+	// DeclaredBalances are checked against the statements' running balances.
+	DeclaredBalances []domain.DeclaredBalance
+}
+
+type ReconciliationResponse struct {
+	Request ReconciliationRequest
+	Summary *domain.ReconciliationSummary
+}
+
+func (r ReconciliationRequest) Validate() error {
+	switch {
+	case len(r.BankFiles) == 0:
+		return fmt.Errorf("%w: no bank statement files", ErrInvalidRequest)
+	case r.Period.Start.IsZero() || r.Period.End.IsZero():
+		return fmt.Errorf("%w: period start and end are required", ErrInvalidRequest)
+	case r.Period.End.Before(r.Period.Start):
+		return fmt.Errorf("%w: period ends on %s before it starts on %s", ErrInvalidRequest, r.Period.End.Format("2006-01-02"), r.Period.Start.Format("2006-01-02"))
+	case r.Policy.Stream && r.Policy.AllowPartial:
+		return fmt.Errorf("%w: streaming cannot be combined with partial failures", ErrInvalidRequest)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconciliationRequest_Validate(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	valid := ReconciliationRequest{SystemFile: "system.csv", BankFiles: []string{"bank.csv"}, Period: domain.Period{Start: start, End: end}}
+	assert.NoError(t, valid.Validate())
+
+	singleDay := valid
+	singleDay.Period.End = start
+	assert.NoError(t, singleDay.Validate())
+
+	fromDatabase := valid
+	fromDatabase.SystemFile = ""
+	assert.NoError(t, fromDatabase.Validate())
+
+	invalid := map[string]func(r *ReconciliationRequest){
+		"no bank files":      func(r *ReconciliationRequest) { r.BankFiles = nil },
+		"no period":          func(r *ReconciliationRequest) { r.Period = domain.Period{} },
+		"period reversed":    func(r *ReconciliationRequest) { r.Period = domain.Period{Start: end, End: start} },
+		"stream and partial": func(r *ReconciliationRequest) { r.Policy = Policy{Stream: true, AllowPartial: true} },
+	}
+	for name, change := range invalid {
+		request := valid
+		change(&request)
+		assert.ErrorIs(t, request.Validate(), ErrInvalidRequest, name)
+	}
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrThresholdExceeded is wrapped by the errors of Thresholds.Check.
+var ErrThresholdExceeded = errors.New("threshold exceeded")
+
+// Thresholds fail a completed run whose exceptions go beyond what is
+// acceptable, so that scheduled runs can alert.
+type Thresholds struct {
+	// MaxUnmatched caps the unmatched system and bank transactions together.
+	// A negative value means no limit.
+	MaxUnmatched int
+	// MaxDiscrepancy caps the total amount discrepancy of the matched pairs.
+	MaxDiscrepancy decimal.NullDecimal
+}
+
+// NoThresholds lets every completed run pass.
+var NoThresholds = Thresholds{MaxUnmatched: -1}
+
+// Check returns an error wrapping ErrThresholdExceeded for every limit the
+// summary goes beyond, or nil.
+func (t Thresholds) Check(summary *domain.ReconciliationSummary) error {
+	var errs []error
+	if unmatched := len(summary.UnmatchedSystemTransactions) + summary.UnmatchedBankCount(); t.MaxUnmatched >= 0 && unmatched > t.MaxUnmatched {
+		errs = append(errs, fmt.Errorf("%w: %d unmatched transactions, at most %d allowed", ErrThresholdExceeded, unmatched, t.MaxUnmatched))
+	}
+	if t.MaxDiscrepancy.Valid && summary.AmountDiscrepancyTotal.GreaterThan(t.MaxDiscrepancy.Decimal) {
+		errs = append(errs, fmt.Errorf("%w: amount discrepancy %s, at most %s allowed", ErrThresholdExceeded,
+			summary.AmountDiscrepancyTotal.String(), t.MaxDiscrepancy.Decimal.String()))
+	}
+	return errors.Join(errs...)
+}
+
+// HasExceptions reports whether a summary needs review: unmatched
+// transactions, amount discrepancies, balance gaps, statements that could not
+// be read or rejected rows. Duplicates that were left out are not exceptions.
+func HasExceptions(summary *domain.ReconciliationSummary) bool {
+	return len(summary.UnmatchedSystemTransactions)+summary.UnmatchedBankCount() > 0 ||
+		!summary.AmountDiscrepancyTotal.IsZero() ||
+		len(summary.BalanceGaps) > 0 ||
+		len(summary.UnreconciledSources) > 0 ||
+		len(summary.RejectedRows) > 0
+}
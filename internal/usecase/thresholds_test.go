@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/nmmugia/reconciliation-service/internal/domain"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholds_Check(t *testing.T) {
+	summary := &domain.ReconciliationSummary{
+		UnmatchedSystemTransactions: []domain.SystemTransaction{{ID: "sys1"}},
+		UnmatchedBankTransactions:   map[string][]domain.BankTransaction{"BCA": {{ID: "bank1"}, {ID: "bank2"}}},
+		AmountDiscrepancyTotal:      decimal.RequireFromString("250.50"),
+	}
+	limit := func(value string) decimal.NullDecimal {
+		return decimal.NewNullDecimal(decimal.RequireFromString(value))
+	}
+
+	cases := map[string]struct {
+		thresholds Thresholds
+		exceeded   []string
+	}{
+		"no limits":          {thresholds: NoThresholds},
+		"within limits":      {thresholds: Thresholds{MaxUnmatched: 3, MaxDiscrepancy: limit("250.50")}},
+		"too many unmatched": {thresholds: Thresholds{MaxUnmatched: 2}, exceeded: []string{"3 unmatched transactions, at most 2 allowed"}},
+		"nothing unmatched allowed": {
+			thresholds: Thresholds{MaxUnmatched: 0, MaxDiscrepancy: limit("100")},
+			exceeded:   []string{"3 unmatched transactions, at most 0 allowed", "amount discrepancy 250.5, at most 100 allowed"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.thresholds.Check(summary)
+			if tc.exceeded == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrThresholdExceeded)
+			for _, message := range tc.exceeded {
+				assert.ErrorContains(t, err, message)
+			}
+		})
+	}
+}
+
+func TestHasExceptions(t *testing.T) {
+	assert.False(t, HasExceptions(&domain.ReconciliationSummary{MatchedTransactions: 3}))
+	assert.False(t, HasExceptions(&domain.ReconciliationSummary{DuplicateStatements: []domain.DuplicateStatement{{Source: "copy.csv"}}}))
+
+	exceptions := map[string]*domain.ReconciliationSummary{
+		"unmatched system": {UnmatchedSystemTransactions: []domain.SystemTransaction{{ID: "sys1"}}},
+		"unmatched bank":   {UnmatchedBankTransactions: map[string][]domain.BankTransaction{"BCA": {{ID: "bank1"}}}},
+		"discrepancy":      {AmountDiscrepancyTotal: decimal.NewFromInt(1)},
+		"balance gap":      {BalanceGaps: []domain.BalanceGap{{BankName: "BCA"}}},
+		"unreadable":       {UnreconciledSources: []domain.UnreconciledSource{{Source: "broken.csv"}}},
+		"rejected rows":    {RejectedRows: []domain.RejectedRow{{Source: "system.csv", Line: 2}}},
+	}
+	for name, summary := range exceptions {
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, HasExceptions(summary))
+		})
+	}
+}
@@ -12,11 +12,18 @@ if [ ! -f "$SYS_TX_FILE" ] || [ ! -f "$BANK_TX_FILE" ]; then
 fi
 
 echo "Running reconciler..."
+# The sample data has unmatched transactions, so the run exits with 1.
+status=0
 output=$(/reconciler \
     -sys="$SYS_TX_FILE" \
     -bank="$BANK_TX_FILE" \
     -start="2025-06-23" \
-    -end="2025-06-24")
+    -end="2025-06-24") || status=$?
+if [ "$status" -ne 1 ]; then
+    echo "Error: Expected exit code 1 for a run with exceptions, got $status." >&2
+    echo "$output" >&2
+    exit 1
+fi
 
 check_output() {
     local expected="$1"